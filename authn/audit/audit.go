@@ -0,0 +1,106 @@
+// Package audit 定义认证相关的审计事件，记录登录、令牌签发、刷新、撤销与解析失败.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"maps"
+	"time"
+)
+
+// EventType 审计事件的类型.
+type EventType string
+
+// 审计事件的类型
+const (
+	EventLogin          EventType = "login"           // 密码校验成功
+	EventLoginFailed    EventType = "login_failed"    // 密码校验失败
+	EventTokenIssued    EventType = "token_issued"    // 签发令牌
+	EventTokenRefreshed EventType = "token_refreshed" // 使用刷新令牌签发新的令牌
+	EventTokenExchanged EventType = "token_exchanged" // 令牌交换
+	EventTokenRevoked   EventType = "token_revoked"   // 撤销令牌
+	EventSessionRevoked EventType = "session_revoked" // 注销会话
+	EventParseFailed    EventType = "parse_failed"    // 令牌解析失败
+)
+
+// Event 审计事件，Token 只保存令牌的指纹，不会保存令牌本身.
+type Event struct {
+	Type      EventType         `json:"type"`                // 事件类型
+	Time      time.Time         `json:"time"`                // 发生时间
+	Subject   string            `json:"subject,omitempty"`   // 主体
+	Actor     []string          `json:"actor,omitempty"`     // 参与者链，第一个为当前的参与者
+	SessionID string            `json:"sessionId,omitempty"` // 会话标识
+	Purpose   string            `json:"purpose,omitempty"`   // 一次性令牌的用途
+	Scope     string            `json:"scope,omitempty"`     // 授权范围
+	Token     string            `json:"token,omitempty"`     // 令牌指纹，见 Redact
+	Reason    string            `json:"reason,omitempty"`    // 失败原因
+	Metadata  map[string]string `json:"metadata,omitempty"`  // 通过 WithMetadata 附加的信息，例如 IP、请求 ID
+}
+
+// Auditor 审计事件的接收者，实现不应阻塞调用方.
+type Auditor interface {
+	Audit(ctx context.Context, event *Event)
+}
+
+// AuditorFunc 将普通函数适配为 Auditor.
+type AuditorFunc func(ctx context.Context, event *Event)
+
+// Audit 调用函数本身.
+func (f AuditorFunc) Audit(ctx context.Context, event *Event) {
+	f(ctx, event)
+}
+
+// Multi 将事件依次发送给多个 Auditor.
+func Multi(auditors ...Auditor) Auditor {
+	return AuditorFunc(func(ctx context.Context, event *Event) {
+		for _, a := range auditors {
+			a.Audit(ctx, event)
+		}
+	})
+}
+
+// Emit 补全事件的时间与 Context 中的附加信息，并发送给 Auditor，Auditor 为空时忽略.
+func Emit(ctx context.Context, auditor Auditor, event *Event) {
+	if auditor == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if md, ok := ctx.Value(metadataKey{}).(map[string]string); ok {
+		if event.Metadata == nil {
+			event.Metadata = make(map[string]string, len(md))
+		}
+		for k, v := range md {
+			if _, exists := event.Metadata[k]; !exists {
+				event.Metadata[k] = v
+			}
+		}
+	}
+	auditor.Audit(ctx, event)
+}
+
+// metadataKey 附加信息在 Context 中的键
+type metadataKey struct{}
+
+// WithMetadata 返回附加了审计信息的 Context，keyvals 为成对的键和值，例如 "ip", "10.0.0.1".
+func WithMetadata(ctx context.Context, keyvals ...string) context.Context {
+	md := make(map[string]string, len(keyvals)/2)
+	if parent, ok := ctx.Value(metadataKey{}).(map[string]string); ok {
+		maps.Copy(md, parent)
+	}
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		md[keyvals[i]] = keyvals[i+1]
+	}
+	return context.WithValue(ctx, metadataKey{}, md)
+}
+
+// Redact 返回令牌的指纹（SHA-256 的前 8 字节），可以关联同一个令牌的事件而不泄露令牌本身.
+func Redact(token string) string {
+	if token == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
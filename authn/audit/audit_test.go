@@ -0,0 +1,164 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LiangNing7/onex/pkg/log"
+)
+
+// recorder 记录收到的审计事件
+type recorder struct {
+	mu     sync.Mutex
+	events []*Event
+}
+
+func (r *recorder) Audit(_ context.Context, e *Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+func TestEmit(t *testing.T) {
+	r := &recorder{}
+	ctx := WithMetadata(context.Background(), "ip", "10.0.0.1", "request_id", "r1")
+	ctx = WithMetadata(ctx, "request_id", "r2", "dangling")
+
+	Emit(ctx, r, &Event{Type: EventLogin, Subject: "alice", Metadata: map[string]string{"ip": "explicit"}})
+	if len(r.events) != 1 {
+		t.Fatalf("%d events, want 1", len(r.events))
+	}
+	e := r.events[0]
+	if e.Time.IsZero() {
+		t.Fatal("event time was not set")
+	}
+	// 事件自身的附加信息优先，内层 Context 覆盖外层，落单的键被忽略
+	want := map[string]string{"ip": "explicit", "request_id": "r2"}
+	if len(e.Metadata) != len(want) || e.Metadata["ip"] != want["ip"] || e.Metadata["request_id"] != want["request_id"] {
+		t.Fatalf("metadata = %v, want %v", e.Metadata, want)
+	}
+
+	// 已经设置的时间不会被覆盖，Auditor 为空时忽略
+	at := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	Emit(context.Background(), r, &Event{Type: EventTokenIssued, Time: at})
+	if !r.events[1].Time.Equal(at) {
+		t.Fatalf("event time = %v, want %v", r.events[1].Time, at)
+	}
+	Emit(ctx, nil, &Event{Type: EventLogin})
+}
+
+func TestWithMetadataDoesNotModifyParent(t *testing.T) {
+	parent := WithMetadata(context.Background(), "ip", "10.0.0.1")
+	_ = WithMetadata(parent, "ip", "10.0.0.2")
+
+	r := &recorder{}
+	Emit(parent, r, &Event{Type: EventLogin})
+	if ip := r.events[0].Metadata["ip"]; ip != "10.0.0.1" {
+		t.Fatalf("parent metadata ip = %q, want 10.0.0.1", ip)
+	}
+}
+
+func TestMulti(t *testing.T) {
+	a, b := &recorder{}, &recorder{}
+	Emit(context.Background(), Multi(a, b), &Event{Type: EventTokenRevoked})
+	if len(a.events) != 1 || len(b.events) != 1 || a.events[0] != b.events[0] {
+		t.Fatal("Multi did not send the event to every auditor")
+	}
+}
+
+func TestRedact(t *testing.T) {
+	const token = "eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiJhbGljZSJ9.signature"
+	got := Redact(token)
+	if got != Redact(token) || got == Redact(token+"x") {
+		t.Fatal("Redact is not a stable fingerprint")
+	}
+	if !strings.HasPrefix(got, "sha256:") || len(got) != len("sha256:")+16 || strings.Contains(got, "signature") {
+		t.Fatalf("Redact() = %q, want a short sha256 fingerprint", got)
+	}
+	if Redact("") != "" {
+		t.Fatal("Redact(\"\") is not empty")
+	}
+}
+
+func TestWriterSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewWriterSink(&buf)
+
+	const n = 50
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			Emit(context.Background(), sink, &Event{Type: EventTokenIssued, Subject: "alice", Token: Redact("t")})
+		}()
+	}
+	wg.Wait()
+
+	// 并发写入的每一行都是完整的 JSON
+	lines := 0
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", scanner.Text(), err)
+		}
+		if e.Type != EventTokenIssued || e.Subject != "alice" {
+			t.Fatalf("event = %+v", e)
+		}
+		lines++
+	}
+	if lines != n {
+		t.Fatalf("%d lines, want %d", lines, n)
+	}
+}
+
+func TestLogSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.json")
+	logger := log.NewLogger(&log.Options{Level: "info", Format: "json", OutputPaths: []string{path}})
+	sink := NewLogSink(logger)
+
+	ctx := WithMetadata(context.Background(), "ip", "10.0.0.1")
+	Emit(ctx, sink, &Event{Type: EventLogin, Subject: "alice"})
+	Emit(ctx, sink, &Event{Type: EventParseFailed, Token: Redact("t"), Reason: "expired", Actor: []string{"svc"}})
+	logger.Sync()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read log: %v", err)
+	}
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("%d log entries, want 2", len(entries))
+	}
+
+	// 成功事件使用 info 级别，空字段不输出
+	if entries[0]["level"] != "info" || entries[0]["type"] != "login" || entries[0]["subject"] != "alice" {
+		t.Fatalf("login entry = %v", entries[0])
+	}
+	if _, ok := entries[0]["reason"]; ok {
+		t.Fatalf("login entry has an empty reason: %v", entries[0])
+	}
+	// 失败事件使用 warn 级别
+	if entries[1]["level"] != "warn" || entries[1]["reason"] != "expired" || entries[1]["token"] != Redact("t") {
+		t.Fatalf("parse failure entry = %v", entries[1])
+	}
+	if md, _ := entries[1]["metadata"].(map[string]any); md["ip"] != "10.0.0.1" {
+		t.Fatalf("parse failure metadata = %v", entries[1]["metadata"])
+	}
+}
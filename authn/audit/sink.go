@@ -0,0 +1,72 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/LiangNing7/onex/pkg/log"
+)
+
+// logSink 将审计事件写入 log.Logger
+type logSink struct {
+	logger log.Logger
+}
+
+// NewLogSink 返回将审计事件写入 log.Logger 的 Auditor，logger 为空时使用 log.Default().
+// 失败事件使用 warn 级别，其他事件使用 info 级别；配合 json 格式的日志即可得到结构化的审计记录.
+func NewLogSink(logger log.Logger) Auditor {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &logSink{logger: logger}
+}
+
+// Audit 写入一条日志
+func (s *logSink) Audit(_ context.Context, e *Event) {
+	keyvals := []any{"type", string(e.Type), "time", e.Time}
+	for _, kv := range []struct{ key, val string }{
+		{"subject", e.Subject},
+		{"sessionId", e.SessionID},
+		{"purpose", e.Purpose},
+		{"scope", e.Scope},
+		{"token", e.Token},
+		{"reason", e.Reason},
+	} {
+		if kv.val != "" {
+			keyvals = append(keyvals, kv.key, kv.val)
+		}
+	}
+	if len(e.Actor) > 0 {
+		keyvals = append(keyvals, "actor", e.Actor)
+	}
+	if len(e.Metadata) > 0 {
+		keyvals = append(keyvals, "metadata", e.Metadata)
+	}
+
+	switch e.Type {
+	case EventLoginFailed, EventParseFailed:
+		s.logger.Warnw("Authentication audit event", keyvals...)
+	default:
+		s.logger.Infow("Authentication audit event", keyvals...)
+	}
+}
+
+// writerSink 将审计事件以 JSON Lines 格式写入 io.Writer
+type writerSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewWriterSink 返回将审计事件以 JSON Lines 格式写入 w 的 Auditor，可以并发调用.
+func NewWriterSink(w io.Writer) Auditor {
+	return &writerSink{enc: json.NewEncoder(w)}
+}
+
+// Audit 写入一行 JSON，写入失败时丢弃事件
+func (s *writerSink) Audit(_ context.Context, e *Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = s.enc.Encode(e)
+}
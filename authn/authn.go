@@ -2,8 +2,10 @@ package authn
 
 import (
 	"context"
+	"github.com/LiangNing7/onex/pkg/authn/audit"
 	"github.com/golang-jwt/jwt/v4"
 	"golang.org/x/crypto/bcrypt"
+	"sync/atomic"
 )
 
 // IToken 定义了实现通用令牌的方法.
@@ -14,6 +16,15 @@ type IToken interface {
 	EncodeToJSON() ([]byte, error) // JSON 编码
 }
 
+// OAuth2Token 在 IToken 的基础上提供 RFC 6749 令牌响应需要的字段，jwt 包签发的令牌都实现了该接口.
+type OAuth2Token interface {
+	IToken
+	GetRefreshToken() string // 获取刷新令牌，没有时为空。
+	GetScope() string        // 获取以空格分隔的授权范围，没有时为空。
+	GetIssuedAt() int64      // 获取令牌签发时间戳。
+	GetExpiresIn() int64     // 获取令牌有效期（秒）。
+}
+
 // Authenticator 定义了用于令牌处理的方法.
 type Authenticator interface {
 	// Sign 用于生成一个令牌.
@@ -36,3 +47,58 @@ func Encrypt(source string) (string, error) {
 func Compare(hashedPassword, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
+
+// dummyHash 以 bcrypt.DefaultCost 预先计算的固定哈希，明文没有意义，只是为了让 bcrypt 有东西可算.
+// 预先计算避免第一次调用 CompareDummy 时额外生成哈希，使首个不存在用户的请求明显变慢.
+var dummyHash = []byte("$2a$10$d1yeSRv50r85yAAkr2lFWu9U5/iiNyUUa7zotSx5plB/Bgtk.yMYq")
+
+// CompareDummy 对一个固定的哈希执行与 Compare 等价的计算，并始终返回不匹配的错误.
+// 当用户不存在时调用它代替 Compare，使响应时间与用户存在时一致，避免通过耗时枚举用户.
+// 用户的密码哈希使用 bcrypt.DefaultCost 以外的成本时，耗时仍然不一致.
+func CompareDummy(password string) error {
+	_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+	return bcrypt.ErrMismatchedHashAndPassword
+}
+
+// auditor 保存密码校验使用的 audit.Auditor
+var auditor atomic.Pointer[audit.Auditor]
+
+// SetAuditor 设置 CompareContext 与 CompareDummyContext 使用的 audit.Auditor，传入 nil 表示不记录.
+func SetAuditor(a audit.Auditor) {
+	if a == nil {
+		auditor.Store(nil)
+		return
+	}
+	auditor.Store(&a)
+}
+
+// CompareContext 与 Compare 相同，并记录登录成功或失败的审计事件，subject 为登录的账号.
+func CompareContext(ctx context.Context, subject, hashedPassword, password string) error {
+	err := Compare(hashedPassword, password)
+	reason := "password_mismatch"
+	if err != nil && err != bcrypt.ErrMismatchedHashAndPassword {
+		reason = "invalid_hash"
+	}
+	emitLogin(ctx, subject, err, reason)
+	return err
+}
+
+// CompareDummyContext 与 CompareDummy 相同，并记录登录失败的审计事件，subject 为登录的账号.
+func CompareDummyContext(ctx context.Context, subject, password string) error {
+	err := CompareDummy(password)
+	emitLogin(ctx, subject, err, "unknown_subject")
+	return err
+}
+
+// emitLogin 记录登录的审计事件
+func emitLogin(ctx context.Context, subject string, err error, reason string) {
+	a := auditor.Load()
+	if a == nil {
+		return
+	}
+	event := &audit.Event{Type: audit.EventLogin, Subject: subject}
+	if err != nil {
+		event.Type, event.Reason = audit.EventLoginFailed, reason
+	}
+	audit.Emit(ctx, *a, event)
+}
@@ -0,0 +1,103 @@
+package authn
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/LiangNing7/onex/pkg/authn/audit"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestCompareDummy(t *testing.T) {
+	// 固定的哈希与 Encrypt 使用相同的成本，耗时才能一致
+	cost, err := bcrypt.Cost(dummyHash)
+	if err != nil {
+		t.Fatalf("dummy hash is invalid: %v", err)
+	}
+	if cost != bcrypt.DefaultCost {
+		t.Fatalf("dummy hash cost = %d, want %d", cost, bcrypt.DefaultCost)
+	}
+
+	for _, password := range []string{"", "secret", "onex(#)dummy"} {
+		if err := CompareDummy(password); !errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			t.Fatalf("CompareDummy(%q) error = %v, want %v", password, err, bcrypt.ErrMismatchedHashAndPassword)
+		}
+	}
+}
+
+// auditRecorder 记录收到的审计事件
+type auditRecorder struct {
+	mu     sync.Mutex
+	events []*audit.Event
+}
+
+func (r *auditRecorder) Audit(_ context.Context, e *audit.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+// useAuditor 在测试期间设置全局的 Auditor
+func useAuditor(t *testing.T) *auditRecorder {
+	t.Helper()
+	r := &auditRecorder{}
+	SetAuditor(r)
+	t.Cleanup(func() { SetAuditor(nil) })
+	return r
+}
+
+func TestCompareContext(t *testing.T) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("GenerateFromPassword() error = %v", err)
+	}
+	ctx := audit.WithMetadata(context.Background(), "ip", "10.0.0.1")
+
+	tests := []struct {
+		name       string
+		hashed     string
+		password   string
+		wantErr    bool
+		wantType   audit.EventType
+		wantReason string
+	}{
+		{"match", string(hashed), "secret", false, audit.EventLogin, ""},
+		{"mismatch", string(hashed), "wrong", true, audit.EventLoginFailed, "password_mismatch"},
+		{"invalid hash", "not-a-hash", "secret", true, audit.EventLoginFailed, "invalid_hash"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := useAuditor(t)
+			err := CompareContext(ctx, "alice", tt.hashed, tt.password)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("CompareContext() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(r.events) != 1 {
+				t.Fatalf("%d audit events, want 1", len(r.events))
+			}
+			e := r.events[0]
+			if e.Type != tt.wantType || e.Reason != tt.wantReason || e.Subject != "alice" || e.Metadata["ip"] != "10.0.0.1" {
+				t.Fatalf("audit event = %+v, want %s with reason %q", e, tt.wantType, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestCompareDummyContext(t *testing.T) {
+	r := useAuditor(t)
+	if err := CompareDummyContext(context.Background(), "nobody", "secret"); !errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		t.Fatalf("CompareDummyContext() error = %v, want %v", err, bcrypt.ErrMismatchedHashAndPassword)
+	}
+	if len(r.events) != 1 || r.events[0].Type != audit.EventLoginFailed || r.events[0].Reason != "unknown_subject" {
+		t.Fatalf("audit events = %+v, want one login_failed with reason unknown_subject", r.events)
+	}
+
+	// 取消设置后不再记录
+	SetAuditor(nil)
+	_ = CompareDummyContext(context.Background(), "nobody", "secret")
+	if len(r.events) != 1 {
+		t.Fatalf("%d audit events after SetAuditor(nil), want 1", len(r.events))
+	}
+}
@@ -0,0 +1,19 @@
+package authn
+
+import "time"
+
+// Clock 提供当前时间，测试时可以替换为可以拨动的时钟.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc 将普通函数适配为 Clock.
+type ClockFunc func() time.Time
+
+// Now 调用函数本身.
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// SystemClock 使用 time.Now 的 Clock.
+var SystemClock Clock = ClockFunc(time.Now)
@@ -0,0 +1,158 @@
+// Package cookie 为浏览器应用提供基于 HttpOnly Cookie 的令牌传输，并使用双重提交 Cookie 防御 CSRF.
+package cookie
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/LiangNing7/onex/pkg/authn"
+)
+
+// 定义 Cookie 的配置
+type options struct {
+	name       string        // 令牌 Cookie 的名称
+	csrfName   string        // CSRF Cookie 的名称
+	csrfHeader string        // 携带 CSRF 令牌的请求头
+	domain     string        // Cookie 的 Domain
+	path       string        // Cookie 的 Path
+	secure     bool          // 是否只通过 HTTPS 发送
+	sameSite   http.SameSite // Cookie 的 SameSite
+}
+
+// 定义默认配置
+var defaultOptions = options{
+	name:       "access_token",
+	csrfName:   "csrf_token",
+	csrfHeader: "X-CSRF-Token",
+	path:       "/",
+	secure:     true,
+	sameSite:   http.SameSiteLaxMode,
+}
+
+// Option 定义配置函数，用于选项模式
+type Option func(*options)
+
+// WithName 设置令牌 Cookie 的名称（默认 access_token）。
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// WithCSRF 设置 CSRF Cookie 的名称与携带 CSRF 令牌的请求头（默认 csrf_token 与 X-CSRF-Token）。
+func WithCSRF(name, header string) Option {
+	return func(o *options) {
+		o.csrfName = name
+		o.csrfHeader = header
+	}
+}
+
+// WithDomain 设置 Cookie 的 Domain（默认为当前域名）。
+func WithDomain(domain string) Option {
+	return func(o *options) {
+		o.domain = domain
+	}
+}
+
+// WithPath 设置 Cookie 的 Path（默认 /）。
+func WithPath(path string) Option {
+	return func(o *options) {
+		o.path = path
+	}
+}
+
+// WithSecure 设置 Cookie 是否只通过 HTTPS 发送（默认 true），仅应在本地开发时关闭。
+func WithSecure(secure bool) Option {
+	return func(o *options) {
+		o.secure = secure
+	}
+}
+
+// WithSameSite 设置 Cookie 的 SameSite（默认 Lax）。
+func WithSameSite(sameSite http.SameSite) Option {
+	return func(o *options) {
+		o.sameSite = sameSite
+	}
+}
+
+// Manager 负责读写令牌 Cookie 与 CSRF Cookie.
+type Manager struct {
+	opts *options
+}
+
+// New 创建一个新的 Manager 实例
+func New(opts ...Option) *Manager {
+	o := defaultOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Manager{opts: &o}
+}
+
+// SetToken 将令牌写入 HttpOnly Cookie，过期时间取自 GetExpiresAt，并同时写入新的 CSRF 令牌.
+// 返回的 CSRF 令牌也可以放在响应体中交给前端.
+func (m *Manager) SetToken(w http.ResponseWriter, token authn.IToken) (string, error) {
+	expires := time.Unix(token.GetExpiresAt(), 0)
+	csrf, err := newCSRFToken()
+	if err != nil {
+		return "", err
+	}
+	http.SetCookie(w, m.cookie(m.opts.name, token.GetToken(), expires, true))
+	// CSRF Cookie 需要被前端脚本读取，因此不能是 HttpOnly
+	http.SetCookie(w, m.cookie(m.opts.csrfName, csrf, expires, false))
+	return csrf, nil
+}
+
+// ClearToken 删除令牌 Cookie 与 CSRF Cookie，用于退出登录.
+func (m *Manager) ClearToken(w http.ResponseWriter) {
+	for _, name := range []string{m.opts.name, m.opts.csrfName} {
+		http.SetCookie(w, m.cookie(name, "", time.Unix(0, 0), name == m.opts.name))
+	}
+}
+
+// Token 从请求头的 Cookie 中读取令牌，没有时返回空字符串.
+func (m *Manager) Token(header authn.Header) string {
+	return readCookie(header, m.opts.name)
+}
+
+// Extractor 返回用于 authz.WithExtractor 的令牌提取函数：优先读取 Cookie，没有时回退到 Authorization 头.
+func (m *Manager) Extractor() authn.Extractor {
+	return func(header authn.Header) string {
+		if token := m.Token(header); token != "" {
+			return token
+		}
+		return authn.BearerToken(header)
+	}
+}
+
+// cookie 根据配置创建 Cookie，expires 为零值时为会话 Cookie，已经过去时 MaxAge 为 -1，浏览器会立即删除 Cookie
+func (m *Manager) cookie(name, value string, expires time.Time, httpOnly bool) *http.Cookie {
+	// MaxAge 为 0 表示会话 Cookie，过期的令牌会一直保留到浏览器关闭，因此使用 -1
+	var maxAge int
+	if !expires.IsZero() {
+		if maxAge = int(time.Until(expires).Seconds()); maxAge <= 0 {
+			maxAge = -1
+		}
+	}
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Domain:   m.opts.domain,
+		Path:     m.opts.path,
+		Expires:  expires,
+		MaxAge:   maxAge,
+		Secure:   m.opts.secure,
+		HttpOnly: httpOnly,
+		SameSite: m.opts.sameSite,
+	}
+}
+
+// readCookie 从请求头的 Cookie 中读取指定名称的值
+func readCookie(header authn.Header, name string) string {
+	req := http.Request{Header: http.Header{"Cookie": header.Values("Cookie")}}
+	c, err := req.Cookie(name)
+	if err != nil {
+		return ""
+	}
+	return c.Value
+}
@@ -0,0 +1,150 @@
+package cookie
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LiangNing7/onex/pkg/authn"
+	"github.com/go-kratos/kratos/v2/errors"
+)
+
+// testToken 只实现测试需要的字段
+type testToken struct {
+	authn.IToken
+	token     string
+	expiresAt int64
+}
+
+func (t *testToken) GetToken() string    { return t.token }
+func (t *testToken) GetExpiresAt() int64 { return t.expiresAt }
+
+// setCookies 返回响应中按名称索引的 Cookie 与原始的 Set-Cookie 头
+func setCookies(rec *httptest.ResponseRecorder) (map[string]*http.Cookie, map[string]string) {
+	cookies, raw := make(map[string]*http.Cookie), make(map[string]string)
+	for _, c := range rec.Result().Cookies() {
+		cookies[c.Name] = c
+	}
+	for _, line := range rec.Header().Values("Set-Cookie") {
+		raw[line[:strings.Index(line, "=")]] = line
+	}
+	return cookies, raw
+}
+
+func TestSetToken(t *testing.T) {
+	m := New()
+	rec := httptest.NewRecorder()
+	csrf, err := m.SetToken(rec, &testToken{token: "jwt", expiresAt: time.Now().Add(time.Hour).Unix()})
+	if err != nil {
+		t.Fatalf("SetToken() error = %v", err)
+	}
+
+	cookies, _ := setCookies(rec)
+	token, csrfCookie := cookies["access_token"], cookies["csrf_token"]
+	if token == nil || token.Value != "jwt" || !token.HttpOnly || !token.Secure || token.MaxAge <= 3500 {
+		t.Fatalf("token cookie = %+v, want HttpOnly secure cookie valid for an hour", token)
+	}
+	if csrfCookie == nil || csrfCookie.Value != csrf || csrfCookie.HttpOnly {
+		t.Fatalf("csrf cookie = %+v, want readable cookie with %q", csrfCookie, csrf)
+	}
+}
+
+func TestSetTokenExpired(t *testing.T) {
+	m := New()
+	rec := httptest.NewRecorder()
+	if _, err := m.SetToken(rec, &testToken{token: "jwt", expiresAt: time.Now().Add(-time.Minute).Unix()}); err != nil {
+		t.Fatalf("SetToken() error = %v", err)
+	}
+
+	// 过期的令牌不能成为会话 Cookie，浏览器应当立即删除
+	cookies, raw := setCookies(rec)
+	for _, name := range []string{"access_token", "csrf_token"} {
+		if c := cookies[name]; c == nil || c.MaxAge != -1 || !strings.Contains(raw[name], "Max-Age=0") {
+			t.Fatalf("%s = %q, want Max-Age=0", name, raw[name])
+		}
+	}
+}
+
+func TestClearToken(t *testing.T) {
+	rec := httptest.NewRecorder()
+	New().ClearToken(rec)
+	_, raw := setCookies(rec)
+	for _, name := range []string{"access_token", "csrf_token"} {
+		if !strings.Contains(raw[name], "Max-Age=0") {
+			t.Fatalf("%s = %q, want Max-Age=0", name, raw[name])
+		}
+	}
+}
+
+func TestSetCSRF(t *testing.T) {
+	rec := httptest.NewRecorder()
+	if _, err := New().SetCSRF(rec); err != nil {
+		t.Fatalf("SetCSRF() error = %v", err)
+	}
+	// CSRF Cookie 是会话 Cookie，不带 Max-Age 与 Expires
+	_, raw := setCookies(rec)
+	if line := raw["csrf_token"]; line == "" || strings.Contains(line, "Max-Age") || strings.Contains(line, "Expires") {
+		t.Fatalf("csrf_token = %q, want a session cookie", line)
+	}
+}
+
+func TestExtractor(t *testing.T) {
+	extract := New().Extractor()
+	tests := []struct {
+		name   string
+		header http.Header
+		want   string
+	}{
+		{"cookie", http.Header{"Cookie": {"access_token=from-cookie"}, "Authorization": {"Bearer from-header"}}, "from-cookie"},
+		{"bearer", http.Header{"Authorization": {"bearer from-header"}}, "from-header"},
+		{"other scheme", http.Header{"Authorization": {"Basic abc"}}, ""},
+		{"none", http.Header{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extract(tt.header); got != tt.want {
+				t.Fatalf("Extractor() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyCSRF(t *testing.T) {
+	m := New()
+	ctx := context.Background()
+	withCookie := func(csrfCookie, csrfHeader string) http.Header {
+		h := http.Header{"Cookie": {"access_token=jwt; csrf_token=" + csrfCookie}}
+		if csrfHeader != "" {
+			h.Set("X-CSRF-Token", csrfHeader)
+		}
+		return h
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		header  http.Header
+		wantErr bool
+	}{
+		{"safe method", http.MethodGet, withCookie("a", ""), false},
+		{"bearer only", http.MethodPost, http.Header{"Authorization": {"Bearer jwt"}}, false},
+		{"match", http.MethodPost, withCookie("a", "a"), false},
+		{"missing header", http.MethodPost, withCookie("a", ""), true},
+		{"mismatch", http.MethodDelete, withCookie("a", "b"), true},
+		{"missing cookie", http.MethodPut, http.Header{"Cookie": {"access_token=jwt"}, "X-Csrf-Token": {""}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := m.VerifyCSRF(ctx, tt.method, tt.header)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("VerifyCSRF() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.IsForbidden(err) {
+				t.Fatalf("VerifyCSRF() error = %v, want Forbidden", err)
+			}
+		})
+	}
+}
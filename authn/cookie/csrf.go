@@ -0,0 +1,74 @@
+package cookie
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"time"
+
+	"github.com/LiangNing7/onex/pkg/authn"
+	"github.com/LiangNing7/onex/pkg/i18n"
+	"github.com/go-kratos/kratos/v2/errors"
+	goi18n "github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+// reason 保存错误原因.
+const reason string = "Forbidden"
+
+// 定义错误类型
+var (
+	// ErrCSRFTokenInvalid 表示 CSRF 令牌缺失或不匹配
+	ErrCSRFTokenInvalid = errors.Forbidden(reason, "CSRF token is missing or invalid")
+)
+
+// 定义 I18n 的消息
+var (
+	MessageCSRFTokenInvalid = &goi18n.Message{ID: "authn.csrf.invalid", Other: ErrCSRFTokenInvalid.Message}
+)
+
+// csrfTokenLength CSRF 令牌的随机字节数
+const csrfTokenLength = 32
+
+// SetCSRF 写入新的 CSRF Cookie 并返回 CSRF 令牌，CSRF Cookie 在浏览器关闭时过期.
+func (m *Manager) SetCSRF(w http.ResponseWriter) (string, error) {
+	csrf, err := newCSRFToken()
+	if err != nil {
+		return "", err
+	}
+	http.SetCookie(w, m.cookie(m.opts.csrfName, csrf, time.Time{}, false))
+	return csrf, nil
+}
+
+// VerifyCSRF 校验双重提交的 CSRF 令牌：请求头中的令牌必须与 CSRF Cookie 一致.
+// GET、HEAD、OPTIONS、TRACE 等安全方法，以及没有携带令牌 Cookie 的请求（例如使用 Authorization 头）不需要校验.
+func (m *Manager) VerifyCSRF(ctx context.Context, method string, header authn.Header) error {
+	if isSafeMethod(method) || m.Token(header) == "" {
+		return nil
+	}
+	expected := readCookie(header, m.opts.csrfName)
+	actual := header.Get(m.opts.csrfHeader)
+	if expected == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(actual)) != 1 {
+		return errors.Forbidden(reason, i18n.FromContext(ctx).LocalizeT(MessageCSRFTokenInvalid))
+	}
+	return nil
+}
+
+// isSafeMethod 判断请求方法是否不会改变服务端状态
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// newCSRFToken 生成随机的 CSRF 令牌
+func newCSRFToken() (string, error) {
+	b := make([]byte, csrfTokenLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,18 @@
+package cookie
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/go-kratos/kratos/v2/errors"
+)
+
+// CSRF 返回用于 gin 的 CSRF 校验中间件，需要放在 authz.Gin 之前.
+func (m *Manager) CSRF() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := m.VerifyCSRF(c.Request.Context(), c.Request.Method, c.Request.Header); err != nil {
+			e := errors.FromError(err)
+			c.AbortWithStatusJSON(int(e.Code), e)
+			return
+		}
+		c.Next()
+	}
+}
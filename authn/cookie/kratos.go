@@ -0,0 +1,25 @@
+package cookie
+
+import (
+	"context"
+
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+)
+
+// Server 返回用于 Kratos 服务端的 CSRF 校验中间件，只对 HTTP 请求生效.
+func (m *Manager) Server() middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			if tr, ok := transport.FromServerContext(ctx); ok {
+				if ht, ok := tr.(khttp.Transporter); ok {
+					if err := m.VerifyCSRF(ctx, ht.Request().Method, tr.RequestHeader()); err != nil {
+						return nil, err
+					}
+				}
+			}
+			return handler(ctx, req)
+		}
+	}
+}
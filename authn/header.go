@@ -0,0 +1,22 @@
+package authn
+
+import "strings"
+
+// Header 表示请求头，http.Header 与 Kratos 的 transport.Header 都实现了该接口.
+type Header interface {
+	Get(key string) string
+	Values(key string) []string
+}
+
+// Extractor 从请求头中提取令牌，返回空字符串表示没有令牌.
+type Extractor func(header Header) string
+
+// BearerToken 从 Authorization 头中提取 Bearer 令牌，是默认的 Extractor.
+func BearerToken(header Header) string {
+	const prefix = "bearer "
+	auth := header.Get("Authorization")
+	if len(auth) > len(prefix) && strings.EqualFold(auth[:len(prefix)], prefix) {
+		return strings.TrimSpace(auth[len(prefix):])
+	}
+	return ""
+}
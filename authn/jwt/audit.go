@@ -0,0 +1,72 @@
+package jwt
+
+import (
+	"context"
+
+	"github.com/LiangNing7/onex/pkg/authn/audit"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithAuditor 设置接收审计事件的 audit.Auditor（默认不记录）。
+// 签发、刷新、交换、撤销令牌以及解析失败都会产生审计事件，事件中只包含令牌的指纹。
+func WithAuditor(auditor audit.Auditor) Option {
+	return func(o *options) {
+		o.auditor = auditor
+	}
+}
+
+// withEvent 设置签发令牌时的审计事件类型，刷新令牌时使用
+func withEvent(typ audit.EventType) SignOption {
+	return func(o *signOptions) {
+		o.event = typ
+	}
+}
+
+// failureKey 解析失败原因在 Context 中的键
+type failureKey struct{}
+
+// failure 保存一次解析中记录的失败原因
+type failure struct {
+	reason string
+	span   trace.Span // 解析操作的 span，存储调用的子 span 中记录的失败原因同样写入该 span
+}
+
+// withFailure 返回用于记录解析失败原因的 Context，telemetry.parseFailed 会写入失败原因.
+// 需要在解析操作的 span 开始之后调用
+func withFailure(ctx context.Context) (context.Context, *failure) {
+	f := &failure{span: trace.SpanFromContext(ctx)}
+	return context.WithValue(ctx, failureKey{}, f), f
+}
+
+// audit 发送审计事件
+func (a *JWTAuth) audit(ctx context.Context, event *audit.Event) {
+	audit.Emit(ctx, a.opts.auditor, event)
+}
+
+// auditToken 发送与令牌相关的审计事件
+func (a *JWTAuth) auditToken(ctx context.Context, typ audit.EventType, token string, claims *Claims) {
+	if a.opts.auditor == nil {
+		return
+	}
+	a.audit(ctx, &audit.Event{
+		Type:      typ,
+		Subject:   claims.Subject,
+		Actor:     claims.ActorChain(),
+		SessionID: claims.SessionID,
+		Purpose:   claims.Purpose,
+		Scope:     claims.Scope,
+		Token:     audit.Redact(token),
+	})
+}
+
+// auditParse 解析失败时发送审计事件
+func (a *JWTAuth) auditParse(ctx context.Context, f *failure, token string, err error) {
+	if err == nil || a.opts.auditor == nil {
+		return
+	}
+	reason := f.reason
+	if reason == "" {
+		reason = failureOther
+	}
+	a.audit(ctx, &audit.Event{Type: audit.EventParseFailed, Token: audit.Redact(token), Reason: reason})
+}
@@ -0,0 +1,112 @@
+package jwt
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/LiangNing7/onex/pkg/authn"
+	"github.com/LiangNing7/onex/pkg/authn/audit"
+	"github.com/LiangNing7/onex/pkg/i18n"
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/golang-jwt/jwt/v4"
+	goi18n "github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+// 定义令牌交换的错误类型
+var (
+	// ErrTokenScopeExceeded 表示请求的授权范围超出了原令牌
+	ErrTokenScopeExceeded = errors.Forbidden(reasonForbidden, "Requested scope exceeds the subject token")
+)
+
+// 定义令牌交换的 I18n 消息
+var (
+	MessageTokenScopeExceeded = &goi18n.Message{ID: "jwt.token.scope.exceeded", Other: ErrTokenScopeExceeded.Message}
+)
+
+// Exchange 实现 RFC 8693 令牌交换：校验 subjectToken，为同一主体签发授权范围更小的令牌.
+// actorToken 非空时同样会被校验，其主体成为新令牌的参与者，subjectToken 已有的参与者链嵌套在其后.
+// 通过 WithScope 请求的授权范围必须是原令牌的子集（原令牌没有授权范围时不能请求任何授权范围），未指定时沿用原令牌的授权范围；
+// 新令牌不会晚于原令牌过期，不签发刷新令牌，并沿用原令牌的会话：会话被注销时新令牌随之失效，
+// 但撤销新令牌不会删除原令牌的会话.
+func (a *JWTAuth) Exchange(ctx context.Context, subjectToken, actorToken string, opts ...SignOption) (_ authn.IToken, err error) {
+	ctx, span := a.tel.start(ctx, "jwt.Exchange")
+	defer func() { end(span, err) }()
+
+	subject, err := a.ParseTokenClaims(ctx, subjectToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// 应用签发选项
+	so := &signOptions{actor: subject.Act}
+	for _, opt := range opts {
+		opt(so)
+	}
+	if actorToken != "" {
+		actor, err := a.ParseTokenClaims(ctx, actorToken)
+		if err != nil {
+			return nil, err
+		}
+		so.actor = &Actor{Subject: actor.Subject, Act: subject.Act}
+	}
+
+	// 授权范围只能缩小
+	scope := subject.Scope
+	if len(so.scopes) > 0 {
+		if !containsScopes(subject.Scope, so.scopes) {
+			return nil, errors.Forbidden(reasonForbidden, i18n.FromContext(ctx).LocalizeT(MessageTokenScopeExceeded))
+		}
+		scope = strings.Join(so.scopes, " ")
+	}
+
+	// 新令牌不会晚于原令牌过期
+	now := a.opts.clock.Now()
+	expiresAt := now.Add(a.opts.expired)
+	if subject.ExpiresAt != nil && subject.ExpiresAt.Time.Before(expiresAt) {
+		expiresAt = subject.ExpiresAt.Time
+	}
+
+	claims := &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    a.opts.issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			NotBefore: jwt.NewNumericDate(now),
+			Subject:   subject.Subject,
+			Audience:  so.audience,
+		},
+		Scope:     scope,
+		SessionID: subject.SessionID,
+		Act:       so.actor,
+		Exchanged: true,
+	}
+	token, err := a.signClaims(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	a.tel.tokenIssued(ctx, "exchange")
+	a.auditToken(ctx, audit.EventTokenExchanged, token, claims)
+	return &tokenInfo{
+		Token:     token,
+		Type:      a.opts.tokenType,
+		ExpiresAt: expiresAt.Unix(),
+		IssuedAt:  now.Unix(),
+		ExpiresIn: int64(expiresAt.Sub(now) / time.Second),
+		Scope:     scope,
+		format:    a.opts.format,
+	}, nil
+}
+
+// containsScopes 判断 granted 是否包含所有请求的授权范围，granted 为空时不包含任何授权范围
+func containsScopes(granted string, requested []string) bool {
+	fields := strings.Fields(granted)
+	for _, scope := range requested {
+		if !slices.Contains(fields, scope) {
+			return false
+		}
+	}
+	return true
+}
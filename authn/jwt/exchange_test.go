@@ -0,0 +1,94 @@
+package jwt_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LiangNing7/onex/pkg/authn/jwt"
+	"github.com/LiangNing7/onex/pkg/authn/jwt/jwttest"
+	"github.com/LiangNing7/onex/pkg/authn/jwt/store/memory"
+	"github.com/go-kratos/kratos/v2/errors"
+)
+
+func TestExchangeKeepsSession(t *testing.T) {
+	ctx := context.Background()
+	clock := jwttest.NewClock(time.Now())
+	auth, _ := jwttest.New(clock, jwt.WithSessionStore(memory.NewStore(memory.WithClock(clock))))
+
+	orig, err := auth.SignWith(ctx, "alice", jwt.WithScope("order:read", "order:write"))
+	if err != nil {
+		t.Fatalf("SignWith() error = %v", err)
+	}
+	exchanged, err := auth.Exchange(ctx, orig.GetToken(), "", jwt.WithScope("order:read"))
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	claims, err := auth.ParseTokenClaims(ctx, exchanged.GetToken())
+	if err != nil {
+		t.Fatalf("ParseTokenClaims() error = %v", err)
+	}
+	origClaims, _ := auth.ParseTokenClaims(ctx, orig.GetToken())
+	if !claims.Exchanged || claims.SessionID == "" || claims.SessionID != origClaims.SessionID {
+		t.Fatalf("exchanged claims = %+v, want exchanged token in session %q", claims, origClaims.SessionID)
+	}
+
+	// 撤销交换得到的令牌不会删除原令牌的会话
+	if err := auth.Destroy(ctx, exchanged.GetToken()); err != nil {
+		t.Fatalf("Destroy() error = %v", err)
+	}
+	if err := auth.DestroyMulti(ctx, []string{exchanged.GetToken()}); err != nil {
+		t.Fatalf("DestroyMulti() error = %v", err)
+	}
+	if _, err := auth.ParseTokenClaims(ctx, orig.GetToken()); err != nil {
+		t.Fatalf("original token rejected after destroying the exchanged token: %v", err)
+	}
+	if sessions, _ := auth.Sessions(ctx, "alice"); len(sessions) != 1 {
+		t.Fatalf("Sessions() = %d sessions, want 1", len(sessions))
+	}
+
+	// 注销会话后交换得到的令牌随之失效
+	again, err := auth.Exchange(ctx, orig.GetToken(), "")
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	if err := auth.RevokeSession(ctx, "alice", origClaims.SessionID); err != nil {
+		t.Fatalf("RevokeSession() error = %v", err)
+	}
+	if _, err := auth.ParseTokenClaims(ctx, again.GetToken()); !errors.IsUnauthorized(err) {
+		t.Fatalf("ParseTokenClaims() after session revoked error = %v, want Unauthorized", err)
+	}
+}
+
+func TestExchangeScopeExceeded(t *testing.T) {
+	ctx := context.Background()
+	auth, _ := jwttest.New(jwttest.NewClock(time.Now()))
+
+	orig, err := auth.SignWith(ctx, "alice", jwt.WithScope("order:read"))
+	if err != nil {
+		t.Fatalf("SignWith() error = %v", err)
+	}
+	_, err = auth.Exchange(ctx, orig.GetToken(), "", jwt.WithScope("order:write"))
+	if !errors.IsForbidden(err) || errors.Reason(err) != jwt.ErrTokenScopeExceeded.Reason {
+		t.Fatalf("Exchange() error = %v, want %v", err, jwt.ErrTokenScopeExceeded)
+	}
+
+	// 没有授权范围的令牌不能通过交换获得授权范围
+	unscoped, err := auth.Sign(ctx, "alice")
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	_, err = auth.Exchange(ctx, unscoped.GetToken(), "", jwt.WithScope("admin:write"))
+	if !errors.IsForbidden(err) || errors.Reason(err) != jwt.ErrTokenScopeExceeded.Reason {
+		t.Fatalf("Exchange() of an unscoped token error = %v, want %v", err, jwt.ErrTokenScopeExceeded)
+	}
+
+	// 不请求授权范围时仍然可以交换
+	exchanged, err := auth.Exchange(ctx, unscoped.GetToken(), "")
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	if claims, err := auth.ParseTokenClaims(ctx, exchanged.GetToken()); err != nil || claims.Scope != "" {
+		t.Fatalf("exchanged claims = %+v, %v, want no scope", claims, err)
+	}
+}
@@ -3,16 +3,25 @@ package jwt
 import (
 	"context"
 	"github.com/LiangNing7/onex/pkg/authn"
+	"github.com/LiangNing7/onex/pkg/authn/audit"
 	"github.com/LiangNing7/onex/pkg/i18n"
 	"github.com/go-kratos/kratos/v2/errors"
 	"github.com/golang-jwt/jwt/v4"
 	goi18n "github.com/nicksnyder/go-i18n/v2/i18n"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+	"strings"
 	"time"
 )
 
 const (
 	// reason 保存错误原因.
 	reason string = "Unauthorized"
+	// reasonForbidden 保存权限不足时的错误原因.
+	reasonForbidden string = "Forbidden"
 
 	// defaultKey 保证用于签署 jwt 令牌的默认密钥.
 	defaultKey = "onex(#)666"
@@ -43,13 +52,24 @@ var (
 
 // 定义 JWT 的配置
 type options struct {
-	signingMethod jwt.SigningMethod // 签名算法
-	signingKey    any               //签名密钥
-	keyfunc       jwt.Keyfunc       // 密钥验证回调函数
-	issuer        string            // 签发者
-	expired       time.Duration     // 过期时间
-	tokenType     string            // 令牌类型
-	tokenHeader   map[string]any    // 令牌头部信息
+	signingMethod  jwt.SigningMethod    // 签名算法
+	signingKey     any                  //签名密钥
+	keyfunc        jwt.Keyfunc          // 密钥验证回调函数
+	issuer         string               // 签发者
+	expired        time.Duration        // 过期时间
+	tokenType      string               // 令牌类型
+	tokenHeader    map[string]any       // 令牌头部信息
+	refreshExpired time.Duration        // 刷新令牌的过期时间，为 0 时不签发刷新令牌
+	format         Format               // 令牌的 JSON 编码格式
+	auditor        audit.Auditor        // 审计事件的接收者，为空时不记录
+	sessions       SessionStorer        // 会话存储，为空时不记录会话
+	touchInterval  time.Duration        // 更新会话最后活跃时间的最小间隔
+	storeTimeout   time.Duration        // 存储调用的超时时间
+	clock          authn.Clock          // 时钟
+	leeway         time.Duration        // 校验时间声明时允许的时钟偏差
+	tracerProvider trace.TracerProvider // 链路追踪
+	meterProvider  metric.MeterProvider // 指标
+	err            error                // 配置错误，签发令牌时返回
 }
 
 // 定义默认配置
@@ -67,6 +87,10 @@ var defaultOptions = options{
 		// 返回默认的密钥
 		return []byte(defaultKey), nil
 	},
+	touchInterval:  time.Minute,                   // 最多每分钟更新一次会话的最后活跃时间
+	clock:          authn.SystemClock,             // 默认使用系统时钟
+	tracerProvider: tracenoop.NewTracerProvider(), // 默认不记录链路追踪
+	meterProvider:  metricnoop.NewMeterProvider(), // 默认不记录指标
 }
 
 // Option 定义配置函数，用于选项模式
@@ -114,10 +138,128 @@ func WithTokenHeader(header map[string]any) Option {
 	}
 }
 
+// WithRefreshExpired 设置刷新令牌的过期时间（默认为 0，不签发刷新令牌），签发刷新令牌需要配置 Storer。
+func WithRefreshExpired(expired time.Duration) Option {
+	return func(o *options) {
+		o.refreshExpired = expired
+	}
+}
+
+// WithFormat 设置 IToken.EncodeToJSON 的编码格式（默认为 FormatLegacy）。
+func WithFormat(format Format) Option {
+	return func(o *options) {
+		o.format = format
+	}
+}
+
+// WithSessionStore 设置会话存储，设置后每个签发的令牌都会记录一个会话（默认不记录）。
+func WithSessionStore(store SessionStorer) Option {
+	return func(o *options) {
+		o.sessions = store
+	}
+}
+
+// WithSessionTouchInterval 设置更新会话最后活跃时间的最小间隔（默认 1 分钟）。
+func WithSessionTouchInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.touchInterval = interval
+	}
+}
+
+// WithStoreTimeout 设置每次存储调用的超时时间（默认只使用调用方 Context 的截止时间）。
+func WithStoreTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.storeTimeout = timeout
+	}
+}
+
+// WithClock 设置签发与校验令牌使用的时钟（默认 authn.SystemClock）。
+func WithClock(clock authn.Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// WithLeeway 设置校验 exp、nbf、iat 时允许的时钟偏差（默认为 0）。
+func WithLeeway(leeway time.Duration) Option {
+	return func(o *options) {
+		o.leeway = leeway
+	}
+}
+
+// WithTracerProvider 设置用于记录链路追踪的 TracerProvider（默认不记录）。
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(o *options) {
+		o.tracerProvider = tp
+	}
+}
+
+// WithMeterProvider 设置用于记录指标的 MeterProvider（默认不记录）。
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(o *options) {
+		o.meterProvider = mp
+	}
+}
+
+// 定义签发令牌时的配置
+type signOptions struct {
+	scopes    []string // 授权范围
+	audience  []string // 令牌的接收方
+	actor     *Actor   // 代表主体执行操作的参与者
+	device    string   // 设备名称
+	ip        string   // 客户端 IP
+	userAgent string   // 客户端 User-Agent
+	sessionID string   // 沿用的会话标识，刷新令牌时使用
+
+	event audit.EventType // 审计事件类型
+}
+
+// SignOption 定义签发令牌时的配置函数
+type SignOption func(*signOptions)
+
+// WithScope 设置令牌的授权范围。
+func WithScope(scopes ...string) SignOption {
+	return func(o *signOptions) {
+		o.scopes = append(o.scopes, scopes...)
+	}
+}
+
+// WithAudience 设置令牌的接收方。
+func WithAudience(audience ...string) SignOption {
+	return func(o *signOptions) {
+		o.audience = append(o.audience, audience...)
+	}
+}
+
+// WithActor 设置代表主体执行操作的参与者，例如客服以用户身份登录时 actor 为客服。
+func WithActor(actor *Actor) SignOption {
+	return func(o *signOptions) {
+		o.actor = actor
+	}
+}
+
+// WithSessionInfo 设置会话的设备、IP 和 User-Agent，只在配置了 WithSessionStore 时生效。
+func WithSessionInfo(device, ip, userAgent string) SignOption {
+	return func(o *signOptions) {
+		o.device = device
+		o.ip = ip
+		o.userAgent = userAgent
+	}
+}
+
+// withSessionID 沿用已有的会话，刷新令牌时使用
+func withSessionID(id string) SignOption {
+	return func(o *signOptions) {
+		o.sessionID = id
+	}
+}
+
 // JWTAuth implement the authn.Authenticator interface.
 type JWTAuth struct {
-	opts  *options
-	store Storer
+	opts   *options
+	store  Storer
+	tel    *telemetry
+	parser *jwt.Parser // 不校验时间声明的解析器，时间声明由 verifyTime 使用 Clock 校验
 }
 
 // New 创建一个新的 JWTAuth 实例
@@ -128,45 +270,75 @@ func New(store Storer, opts ...Option) *JWTAuth {
 		opt(&o)
 	}
 	// 返回 JWTAuth 实例
-	return &JWTAuth{opts: &o, store: store}
+	return &JWTAuth{
+		opts:   &o,
+		store:  store,
+		tel:    newTelemetry(o.tracerProvider, o.meterProvider),
+		parser: jwt.NewParser(jwt.WithoutClaimsValidation()),
+	}
 }
 
 // 下面实现 authn.Authenticator interface 的方法: Sign Destroy ParseClaims Release
 
 // Sign 用于生成一个新的 Token
 func (a *JWTAuth) Sign(ctx context.Context, userID string) (authn.IToken, error) {
+	return a.SignWith(ctx, userID)
+}
+
+// SignWith 使用签发选项生成一个新的 Token，配置了 WithRefreshExpired 时同时签发刷新令牌
+func (a *JWTAuth) SignWith(ctx context.Context, userID string, opts ...SignOption) (_ authn.IToken, err error) {
+	ctx, span := a.tel.start(ctx, "jwt.Sign")
+	defer func() { end(span, err) }()
+
+	// 调用方已经取消时不再签发
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// 应用签发选项
+	so := &signOptions{event: audit.EventTokenIssued}
+	for _, opt := range opts {
+		opt(so)
+	}
+
 	// 获取当前时间
-	now := time.Now()
+	now := a.opts.clock.Now()
 
 	// 计算令牌过期时间
 	expiresAt := now.Add(a.opts.expired)
 
-	// 创建新的令牌
-	token := jwt.NewWithClaims(a.opts.signingMethod, &jwt.RegisteredClaims{
-		// Issuer = iss,令牌颁发者。它表示该令牌是由谁创建的
-		Issuer: a.opts.issuer,
-		// IssuedAt = iat,令牌颁发时的时间戳。它表示令牌是何时被创建的
-		IssuedAt: jwt.NewNumericDate(now),
-		// ExpiresAt = exp,令牌的过期时间戳。它表示令牌将在何时过期
-		ExpiresAt: jwt.NewNumericDate(expiresAt),
-		// NotBefore = nbf,令牌的生效时的时间戳。它表示令牌从什么时候开始生效
-		NotBefore: jwt.NewNumericDate(now),
-		// Subject = sub,令牌的主体。它表示该令牌是关于谁的
-		Subject: userID,
-	})
-
-	// 添加 tokenHeader
-	if a.opts.tokenHeader != nil {
-		for k, v := range a.opts.tokenHeader {
-			token.Header[k] = v
+	// 创建并签署新的令牌
+	claims := &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			// Issuer = iss,令牌颁发者。它表示该令牌是由谁创建的
+			Issuer: a.opts.issuer,
+			// IssuedAt = iat,令牌颁发时的时间戳。它表示令牌是何时被创建的
+			IssuedAt: jwt.NewNumericDate(now),
+			// ExpiresAt = exp,令牌的过期时间戳。它表示令牌将在何时过期
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			// NotBefore = nbf,令牌的生效时的时间戳。它表示令牌从什么时候开始生效
+			NotBefore: jwt.NewNumericDate(now),
+			// Subject = sub,令牌的主体。它表示该令牌是关于谁的
+			Subject: userID,
+			// Audience = aud,令牌的接收方
+			Audience: so.audience,
+		},
+		// Scope = scope,令牌的授权范围，以空格分隔
+		Scope: strings.Join(so.scopes, " "),
+		// Act = act,代表主体执行操作的参与者
+		Act: so.actor,
+	}
+	// SessionID = sid,令牌所属的会话
+	if a.opts.sessions != nil {
+		if claims.SessionID = so.sessionID; claims.SessionID == "" {
+			if claims.SessionID, err = newTokenID(); err != nil {
+				return nil, errors.Unauthorized(reason, i18n.FromContext(ctx).LocalizeT(MessageSignTokenFailed))
+			}
 		}
 	}
-
-	// 使用签名密钥对令牌进行签名
-	refreshToken, err := token.SignedString(a.opts.signingKey)
+	accessToken, err := a.signClaims(ctx, claims)
 	if err != nil {
-		// 签名失败，返回错误信息
-		return nil, errors.Unauthorized(reason, i18n.FromContext(ctx).LocalizeT(MessageSignTokenFailed))
+		return nil, err
 	}
 
 	// 创建 tokenInfo
@@ -176,58 +348,171 @@ func (a *JWTAuth) Sign(ctx context.Context, userID string) (authn.IToken, error)
 		// 设置令牌类型
 		Type: a.opts.tokenType,
 		// 设置令牌内容
-		Token: refreshToken,
+		Token: accessToken,
+		// 设置签发时间、有效期和授权范围
+		IssuedAt:  now.Unix(),
+		ExpiresIn: int64(a.opts.expired / time.Second),
+		Scope:     claims.Scope,
+		format:    a.opts.format,
+	}
+
+	// 签发刷新令牌，刷新令牌是用途为 refresh 的一次性令牌，使用后即失效
+	if a.opts.refreshExpired > 0 {
+		refresh, err := a.signOneTime(ctx, &Claims{
+			RegisteredClaims: jwt.RegisteredClaims{Subject: userID, Audience: so.audience},
+			Purpose:          PurposeRefresh,
+			Scope:            claims.Scope,
+			SessionID:        claims.SessionID,
+			Act:              claims.Act,
+		}, a.opts.refreshExpired)
+		if err != nil {
+			return nil, err
+		}
+		tokenInfo.RefreshToken = refresh.Token
+	}
+
+	// 记录会话，会话的有效期覆盖访问令牌和刷新令牌
+	if claims.SessionID != "" {
+		sessionExpiresAt := expiresAt
+		if refresh := now.Add(a.opts.refreshExpired); refresh.After(sessionExpiresAt) {
+			sessionExpiresAt = refresh
+		}
+		if err := a.saveSession(ctx, claims, so, now, sessionExpiresAt); err != nil {
+			return nil, err
+		}
 	}
+
+	a.tel.tokenIssued(ctx, "")
+	a.auditToken(ctx, so.event, accessToken, claims)
 	return tokenInfo, nil
 }
 
+// signClaims 使用签名密钥对声明进行签名
+func (a *JWTAuth) signClaims(ctx context.Context, claims jwt.Claims) (string, error) {
+	// 配置无效时不签发令牌
+	if a.opts.err != nil {
+		return "", a.opts.err
+	}
+	// 创建新的令牌
+	token := jwt.NewWithClaims(a.opts.signingMethod, claims)
+
+	// 添加 tokenHeader
+	if a.opts.tokenHeader != nil {
+		for k, v := range a.opts.tokenHeader {
+			token.Header[k] = v
+		}
+	}
+
+	// 使用签名密钥对令牌进行签名
+	signed, err := token.SignedString(a.opts.signingKey)
+	if err != nil {
+		// 签名失败，返回错误信息
+		return "", errors.Unauthorized(reason, i18n.FromContext(ctx).LocalizeT(MessageSignTokenFailed))
+	}
+	return signed, nil
+}
+
 // parseToken 用于解析输入的 refreshToken
-func (a *JWTAuth) parseToken(ctx context.Context, refreshToken string) (*jwt.RegisteredClaims, error) {
+func (a *JWTAuth) parseToken(ctx context.Context, refreshToken string) (*Claims, error) {
 	// 使用提供的 keyfunc 解析令牌
-	token, err := jwt.ParseWithClaims(refreshToken, &jwt.RegisteredClaims{}, a.opts.keyfunc)
+	token, err := a.parser.ParseWithClaims(refreshToken, &Claims{}, a.opts.keyfunc)
 	if err != nil {
 		// 解析错误
 		ve, ok := err.(*jwt.ValidationError)
 		if !ok {
 			// 如果错误不是 ValidationError 类型，则返回未授权的错误
+			a.tel.parseFailed(ctx, failureOther)
 			return nil, errors.Unauthorized(reason, err.Error())
 		}
 		if ve.Errors&jwt.ValidationErrorMalformed != 0 {
 			// 令牌格式错误
+			a.tel.parseFailed(ctx, failureMalformed)
 			return nil, errors.Unauthorized(reason, i18n.FromContext(ctx).LocalizeT(MessageTokenInvalid))
 		}
 		if ve.Errors&(jwt.ValidationErrorExpired|jwt.ValidationErrorNotValidYet) != 0 {
 			// 令牌已经过期或尚未生效
+			a.tel.parseFailed(ctx, failureExpired)
 			return nil, errors.Unauthorized(reason, i18n.FromContext(ctx).LocalizeT(MessageTokenExpired))
 		}
+		if ve.Errors&jwt.ValidationErrorSignatureInvalid != 0 {
+			// 签名无效，令牌可能被伪造或篡改
+			a.tel.parseFailed(ctx, failureInvalid)
+			return nil, errors.Unauthorized(reason, i18n.FromContext(ctx).LocalizeT(MessageTokenParseFail))
+		}
 		// 其他解析错误
+		a.tel.parseFailed(ctx, failureOther)
 		return nil, errors.Unauthorized(reason, i18n.FromContext(ctx).LocalizeT(MessageTokenParseFail))
 	}
 
 	// 验证令牌是否有效
 	if !token.Valid {
+		a.tel.parseFailed(ctx, failureInvalid)
 		return nil, errors.Unauthorized(reason, i18n.FromContext(ctx).LocalizeT(MessageTokenInvalid))
 	}
 
-	// 检查签名算法是否与配置一致
-	if token.Method != a.opts.signingMethod {
+	// 检查签名算法是否与配置一致，解析得到的是标准库注册的签名方法，因此比较算法名称
+	if token.Method.Alg() != a.opts.signingMethod.Alg() {
+		a.tel.parseFailed(ctx, failureSigningMethod)
 		return nil, errors.Unauthorized(reason, i18n.FromContext(ctx).LocalizeT(MessageUnSupportSigningMethod))
 	}
-	return token.Claims.(*jwt.RegisteredClaims), nil
+
+	// 使用 Clock 校验时间声明
+	claims := token.Claims.(*Claims)
+	if !a.verifyTime(claims) {
+		a.tel.parseFailed(ctx, failureExpired)
+		return nil, errors.Unauthorized(reason, i18n.FromContext(ctx).LocalizeT(MessageTokenExpired))
+	}
+	return claims, nil
 }
 
-// callStore 执行传入的存储函数
-func (a *JWTAuth) callStore(fn func(Storer) error) error {
-	// 检查存储是否存在，如果存在则执行传入的函数 fn
-	if store := a.store; store != nil {
-		return fn(store)
+// verifyTime 使用 Clock 与允许的时钟偏差校验 exp、nbf 与 iat
+func (a *JWTAuth) verifyTime(claims *Claims) bool {
+	now := a.opts.clock.Now()
+	return claims.VerifyExpiresAt(now.Add(-a.opts.leeway), false) &&
+		claims.VerifyNotBefore(now.Add(a.opts.leeway), false) &&
+		claims.VerifyIssuedAt(now.Add(a.opts.leeway), false)
+}
+
+// callStore 执行传入的存储函数，op 为操作名，用于链路追踪和指标
+func (a *JWTAuth) callStore(ctx context.Context, op string, fn func(context.Context, Storer) error) error {
+	// 检查存储是否存在，如果不存在则返回 nil
+	store := a.store
+	if store == nil {
+		return nil
 	}
-	// 如果存储不存在，则返回 nil
-	return nil
+	return a.instrument(ctx, op, func(ctx context.Context) error {
+		return fn(ctx, store)
+	})
+}
+
+// instrument 为存储调用记录链路追踪和耗时，并设置超时时间
+func (a *JWTAuth) instrument(ctx context.Context, op string, fn func(context.Context) error) (err error) {
+	ctx, span := a.tel.start(ctx, "jwt.store."+op, attribute.String("operation", op))
+	defer func() { end(span, err) }()
+
+	// 为存储调用设置超时时间
+	if a.opts.storeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.opts.storeTimeout)
+		defer cancel()
+	}
+
+	begin := time.Now()
+	defer func() { a.tel.storeCalled(ctx, op, begin, err) }()
+	// 执行传入的函数 fn
+	return fn(ctx)
 }
 
 // Destroy 用于销毁令牌
-func (a *JWTAuth) Destroy(ctx context.Context, refreshToken string) error {
+func (a *JWTAuth) Destroy(ctx context.Context, refreshToken string) (err error) {
+	ctx, span := a.tel.start(ctx, "jwt.Destroy")
+	defer func() { end(span, err) }()
+
+	// 调用方已经取消时不再处理
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	// 解析令牌声明
 	claims, err := a.parseToken(ctx, refreshToken)
 	if err != nil {
@@ -235,20 +520,51 @@ func (a *JWTAuth) Destroy(ctx context.Context, refreshToken string) error {
 	}
 
 	// 如果设置了 storage，将未过期的令牌放入
-	store := func(store Storer) error {
+	store := func(ctx context.Context, store Storer) error {
 		// 设置令牌剩余时间
-		expired := time.Until(claims.ExpiresAt.Time)
+		expired := claims.ExpiresAt.Sub(a.opts.clock.Now())
 		// 将令牌放入Store
 		return store.Set(ctx, refreshToken, expired)
 	}
 	// 调用存储函数
-	return a.callStore(store)
+	if err := a.callStore(ctx, "set", store); err != nil {
+		return err
+	}
+	a.auditToken(ctx, audit.EventTokenRevoked, refreshToken, claims)
+	// 删除令牌所属的会话，交换得到的令牌不拥有会话
+	if claims.SessionID != "" && !claims.Exchanged {
+		return a.callSessions(ctx, "session.delete", func(ctx context.Context, sessions SessionStorer) error {
+			_, err := sessions.DeleteSession(ctx, claims.Subject, claims.SessionID)
+			return err
+		})
+	}
+	return nil
 }
 
 // ParseClaims 解析令牌并返回声明
 func (a *JWTAuth) ParseClaims(ctx context.Context, refreshToken string) (*jwt.RegisteredClaims, error) {
+	claims, err := a.ParseTokenClaims(ctx, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	return &claims.RegisteredClaims, nil
+}
+
+// ParseTokenClaims 与 ParseClaims 相同，但返回完整的声明，包括授权范围与参与者链
+func (a *JWTAuth) ParseTokenClaims(ctx context.Context, refreshToken string) (_ *Claims, err error) {
+	ctx, span := a.tel.start(ctx, "jwt.ParseClaims")
+	defer func() { end(span, err) }()
+	ctx, f := withFailure(ctx)
+	defer func() { a.auditParse(ctx, f, refreshToken, err) }()
+
+	// 调用方已经取消时不再处理
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// 如果令牌为空，则返回 TokenInvalid 错误
 	if refreshToken == "" {
+		a.tel.parseFailed(ctx, failureMalformed)
 		return nil, errors.Unauthorized(reason, i18n.FromContext(ctx).LocalizeT(MessageTokenInvalid))
 	}
 	// 解析令牌声明
@@ -256,30 +572,47 @@ func (a *JWTAuth) ParseClaims(ctx context.Context, refreshToken string) (*jwt.Re
 	if err != nil {
 		return nil, err
 	}
-	// 检查存储中是否存在该令牌
-	store := func(store Storer) error {
-		exists, err := store.Check(ctx, refreshToken)
+	// 一次性令牌不能作为会话令牌使用
+	if claims.Purpose != "" {
+		a.tel.parseFailed(ctx, failurePurpose)
+		return nil, errors.Unauthorized(reason, i18n.FromContext(ctx).LocalizeT(MessageTokenPurposeMismatch))
+	}
+	// 检查令牌是否已被销毁
+	if err := a.checkRevoked(ctx, refreshToken); err != nil {
+		return nil, err
+	}
+	// 检查令牌所属的会话是否已被注销
+	if err := a.checkSession(ctx, claims); err != nil {
+		return nil, err
+	}
+	// 返回解析后的声明
+	return claims, nil
+}
+
+// checkRevoked 检查存储中是否存在该令牌，令牌已被销毁时返回未授权的错误
+func (a *JWTAuth) checkRevoked(ctx context.Context, token string) error {
+	store := func(ctx context.Context, store Storer) error {
+		exists, err := store.Check(ctx, token)
 		if err != nil {
+			a.tel.parseFailed(ctx, failureStore)
 			return err
 		}
 		// 如果存在令牌，则返回未授权的错误，【因为销毁令牌是放入存储中】
 		if exists {
+			a.tel.parseFailed(ctx, failureRevoked)
 			return errors.Unauthorized(reason, i18n.FromContext(ctx).LocalizeT(MessageTokenInvalid))
 		}
 		return nil
 	}
 	// 执行调用函数
-	if err := a.callStore(store); err != nil {
-		return nil, err
-	}
-	// 返回解析后的声明
-	return claims, nil
+	return a.callStore(ctx, "check", store)
 }
 
 // Release 用于释放请求的资源
 func (a *JWTAuth) Release() error {
 	// 调用存储的 Close 方法释放资源
-	return a.callStore(func(store Storer) error {
-		return store.Close()
-	})
+	if a.store == nil {
+		return nil
+	}
+	return a.store.Close()
 }
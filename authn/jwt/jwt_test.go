@@ -0,0 +1,106 @@
+package jwt_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LiangNing7/onex/pkg/authn/jwt"
+	"github.com/LiangNing7/onex/pkg/authn/jwt/jwttest"
+	"github.com/go-kratos/kratos/v2/errors"
+)
+
+func TestParseMintedTokens(t *testing.T) {
+	ctx := context.Background()
+	auth, minter := jwttest.New(jwttest.NewClock(time.Unix(1700000000, 0)))
+	valid := minter.Valid(t, "alice", time.Hour)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"valid", valid, false},
+		{"expired", minter.Expired(t, "alice", time.Second), true},
+		{"not yet valid", minter.Future(t, "alice", time.Second), true},
+		{"forged", minter.Forged(t, "alice"), true},
+		{"none", minter.None(t, "alice"), true},
+		{"tampered", minter.Tampered(t, valid, "mallory"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := auth.ParseClaims(ctx, tt.token)
+			if tt.wantErr {
+				if !errors.IsUnauthorized(err) {
+					t.Fatalf("ParseClaims() error = %v, want Unauthorized", err)
+				}
+				return
+			}
+			if err != nil || claims.Subject != "alice" {
+				t.Fatalf("ParseClaims() = %v, %v, want alice", claims, err)
+			}
+		})
+	}
+}
+
+func TestClockExpiry(t *testing.T) {
+	ctx := context.Background()
+	clock := jwttest.NewClock(time.Unix(1700000000, 0))
+	auth, _ := jwttest.New(clock, jwt.WithExpired(time.Hour))
+
+	token, err := auth.Sign(ctx, "alice")
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if token.GetExpiresAt() != clock.Now().Add(time.Hour).Unix() {
+		t.Fatalf("GetExpiresAt() = %d, want an hour after the clock", token.GetExpiresAt())
+	}
+
+	// 拨动时钟到过期之前，令牌仍然有效
+	clock.Advance(time.Hour - time.Second)
+	if _, err := auth.ParseClaims(ctx, token.GetToken()); err != nil {
+		t.Fatalf("ParseClaims() before expiry error = %v", err)
+	}
+	// 到达过期时间后令牌失效
+	clock.Advance(time.Second)
+	_, err = auth.ParseClaims(ctx, token.GetToken())
+	if !errors.IsUnauthorized(err) || errors.FromError(err).Message != jwt.MessageTokenExpired.Other {
+		t.Fatalf("ParseClaims() after expiry error = %v, want %q", err, jwt.MessageTokenExpired.Other)
+	}
+	// 时钟回拨到签发之前，令牌尚未生效
+	clock.Set(time.Unix(1700000000, 0).Add(-time.Minute))
+	if _, err := auth.ParseClaims(ctx, token.GetToken()); !errors.IsUnauthorized(err) {
+		t.Fatalf("ParseClaims() before issue error = %v, want Unauthorized", err)
+	}
+}
+
+func TestLeeway(t *testing.T) {
+	ctx := context.Background()
+	const leeway = 30 * time.Second
+
+	tests := []struct {
+		name    string
+		token   func(m *jwttest.Minter) string
+		leeway  time.Duration
+		wantErr bool
+	}{
+		{"expired without leeway", func(m *jwttest.Minter) string { return m.Expired(t, "alice", time.Second) }, 0, true},
+		{"expired within leeway", func(m *jwttest.Minter) string { return m.Expired(t, "alice", leeway-time.Second) }, leeway, false},
+		{"expired beyond leeway", func(m *jwttest.Minter) string { return m.Expired(t, "alice", leeway+time.Second) }, leeway, true},
+		{"not before without leeway", func(m *jwttest.Minter) string { return m.Future(t, "alice", time.Second) }, 0, true},
+		{"not before within leeway", func(m *jwttest.Minter) string { return m.Future(t, "alice", leeway-time.Second) }, leeway, false},
+		{"not before beyond leeway", func(m *jwttest.Minter) string { return m.Future(t, "alice", leeway+time.Second) }, leeway, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, minter := jwttest.New(jwttest.NewClock(time.Unix(1700000000, 0)), jwt.WithLeeway(tt.leeway))
+			_, err := auth.ParseClaims(ctx, tt.token(minter))
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("ParseClaims() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && errors.FromError(err).Message != jwt.MessageTokenExpired.Other {
+				t.Fatalf("ParseClaims() error = %v, want %q", err, jwt.MessageTokenExpired.Other)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+// Package jwttest 为测试提供可以拨动的时钟，以及签发过期、尚未生效、伪造与篡改令牌的辅助函数.
+package jwttest
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LiangNing7/onex/pkg/authn"
+	"github.com/LiangNing7/onex/pkg/authn/jwt"
+	"github.com/LiangNing7/onex/pkg/authn/jwt/store/memory"
+	gojwt "github.com/golang-jwt/jwt/v4"
+)
+
+// Key 测试使用的 HMAC 密钥.
+var Key = []byte("jwttest(#)key")
+
+// Clock 可以拨动的时钟，实现了 authn.Clock，可以并发使用.
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+var _ authn.Clock = (*Clock)(nil)
+
+// NewClock 创建一个从 now 开始的时钟.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now 返回当前时间.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance 将时钟向后拨动 d.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set 将时钟设置为 now.
+func (c *Clock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// New 返回使用 Key、HS256、clock 与内存存储的 JWTAuth，以及使用相同密钥与时钟的 Minter.
+// opts 会在默认配置之后应用.
+func New(clock *Clock, opts ...jwt.Option) (*jwt.JWTAuth, *Minter) {
+	base := []jwt.Option{
+		jwt.WithSigningMethod(gojwt.SigningMethodHS256),
+		jwt.WithSigningKey(Key),
+		jwt.WithKeyfunc(func(t *gojwt.Token) (any, error) {
+			return Key, nil
+		}),
+		jwt.WithClock(clock),
+	}
+	a := jwt.New(memory.NewStore(memory.WithClock(clock)), append(base, opts...)...)
+	return a, NewMinter(gojwt.SigningMethodHS256, Key, clock)
+}
+
+// Minter 使用与被测 JWTAuth 相同的密钥签发特殊的令牌.
+type Minter struct {
+	method gojwt.SigningMethod
+	key    any
+	clock  authn.Clock
+}
+
+// NewMinter 创建一个新的 Minter 实例，clock 为空时使用 authn.SystemClock.
+func NewMinter(method gojwt.SigningMethod, key any, clock authn.Clock) *Minter {
+	if clock == nil {
+		clock = authn.SystemClock
+	}
+	return &Minter{method: method, key: key, clock: clock}
+}
+
+// Sign 签发包含任意声明的令牌.
+func (m *Minter) Sign(tb testing.TB, claims gojwt.Claims) string {
+	tb.Helper()
+	token, err := gojwt.NewWithClaims(m.method, claims).SignedString(m.key)
+	if err != nil {
+		tb.Fatalf("jwttest: sign token: %v", err)
+	}
+	return token
+}
+
+// Valid 签发一个当前有效、ttl 后过期的令牌.
+func (m *Minter) Valid(tb testing.TB, subject string, ttl time.Duration) string {
+	tb.Helper()
+	now := m.clock.Now()
+	return m.Sign(tb, m.claims(subject, now, now, now.Add(ttl)))
+}
+
+// Expired 签发一个 ago 之前已经过期的令牌.
+func (m *Minter) Expired(tb testing.TB, subject string, ago time.Duration) string {
+	tb.Helper()
+	expiresAt := m.clock.Now().Add(-ago)
+	issuedAt := expiresAt.Add(-time.Hour)
+	return m.Sign(tb, m.claims(subject, issuedAt, issuedAt, expiresAt))
+}
+
+// Future 签发一个 in 之后才生效（nbf 与 iat 在未来）的令牌.
+func (m *Minter) Future(tb testing.TB, subject string, in time.Duration) string {
+	tb.Helper()
+	notBefore := m.clock.Now().Add(in)
+	return m.Sign(tb, m.claims(subject, notBefore, notBefore, notBefore.Add(time.Hour)))
+}
+
+// Forged 签发一个声明有效、但使用其他密钥签名的令牌.
+func (m *Minter) Forged(tb testing.TB, subject string) string {
+	tb.Helper()
+	forger := &Minter{method: gojwt.SigningMethodHS256, key: []byte("jwttest(#)forged"), clock: m.clock}
+	now := m.clock.Now()
+	return forger.Sign(tb, forger.claims(subject, now, now, now.Add(time.Hour)))
+}
+
+// None 签发一个使用 alg=none、没有签名的令牌.
+func (m *Minter) None(tb testing.TB, subject string) string {
+	tb.Helper()
+	now := m.clock.Now()
+	token, err := gojwt.NewWithClaims(gojwt.SigningMethodNone, m.claims(subject, now, now, now.Add(time.Hour))).
+		SignedString(gojwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		tb.Fatalf("jwttest: sign none token: %v", err)
+	}
+	return token
+}
+
+// Tampered 将 token 的声明替换为 subject 的声明，但保留原来的签名.
+func (m *Minter) Tampered(tb testing.TB, token, subject string) string {
+	tb.Helper()
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		tb.Fatalf("jwttest: malformed token %q", token)
+	}
+	forged := strings.Split(m.Valid(tb, subject, time.Hour), ".")
+	return parts[0] + "." + forged[1] + "." + parts[2]
+}
+
+// claims 创建令牌的声明
+func (m *Minter) claims(subject string, issuedAt, notBefore, expiresAt time.Time) *jwt.Claims {
+	return &jwt.Claims{RegisteredClaims: gojwt.RegisteredClaims{
+		Subject:   subject,
+		IssuedAt:  gojwt.NewNumericDate(issuedAt),
+		NotBefore: gojwt.NewNumericDate(notBefore),
+		ExpiresAt: gojwt.NewNumericDate(expiresAt),
+	}}
+}
@@ -0,0 +1,184 @@
+package jwt
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/LiangNing7/onex/pkg/authn"
+	"github.com/LiangNing7/onex/pkg/authn/audit"
+	"github.com/LiangNing7/onex/pkg/i18n"
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/golang-jwt/jwt/v4"
+	goi18n "github.com/nicksnyder/go-i18n/v2/i18n"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// 常用的一次性令牌用途
+const (
+	PurposeVerifyEmail   = "verify_email"   // 验证邮箱
+	PurposeResetPassword = "reset_password" // 重置密码
+	PurposeMagicLink     = "magic_link"     // 免密登录链接
+	PurposeRefresh       = "refresh"        // 刷新令牌
+)
+
+// onceKeyPrefix 已消费的一次性令牌在 Storer 中的键前缀
+const onceKeyPrefix = "once:"
+
+// 定义一次性令牌的错误类型
+var (
+	// ErrTokenPurposeMismatch 表示令牌的用途不符
+	ErrTokenPurposeMismatch = errors.Unauthorized(reason, "Token used for wrong purpose")
+	// ErrTokenConsumed 表示令牌已经被使用过
+	ErrTokenConsumed = errors.Unauthorized(reason, "Token has already been used")
+	// ErrStoreRequired 表示一次性令牌需要配置 Storer
+	ErrStoreRequired = errors.InternalServer("StoreRequired", "One-time tokens require a token store")
+)
+
+// 定义一次性令牌的 I18n 消息
+var (
+	MessageTokenPurposeMismatch = &goi18n.Message{ID: "jwt.token.purpose.mismatch", Other: ErrTokenPurposeMismatch.Message}
+	MessageTokenConsumed        = &goi18n.Message{ID: "jwt.token.consumed", Other: ErrTokenConsumed.Message}
+)
+
+// SignOneTime 签发一个用途限定、只能使用一次的令牌，例如邮箱验证、密码重置和免密登录链接.
+// expired 为 0 时使用 JWTAuth 配置的过期时间；audience 用于限定令牌的接收方.
+func (a *JWTAuth) SignOneTime(ctx context.Context, userID, purpose string, expired time.Duration, audience ...string) (_ authn.IToken, err error) {
+	ctx, span := a.tel.start(ctx, "jwt.SignOneTime", attribute.String("purpose", purpose))
+	defer func() { end(span, err) }()
+
+	claims := &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: userID, Audience: audience},
+		Purpose:          purpose,
+	}
+	token, err := a.signOneTime(ctx, claims, expired)
+	if err != nil {
+		return nil, err
+	}
+	a.auditToken(ctx, audit.EventTokenIssued, token.Token, claims)
+	return token, nil
+}
+
+// Refresh 使用刷新令牌签发新的令牌，刷新令牌只能使用一次，新令牌会带上新的刷新令牌.
+func (a *JWTAuth) Refresh(ctx context.Context, refreshToken string) (authn.IToken, error) {
+	claims, err := a.ConsumeOneTime(ctx, refreshToken, PurposeRefresh, "")
+	if err != nil {
+		return nil, err
+	}
+	// 会话已被注销时不再签发
+	if err := a.checkSession(ctx, claims); err != nil {
+		return nil, err
+	}
+	return a.SignWith(ctx, claims.Subject, WithScope(strings.Fields(claims.Scope)...), WithAudience(claims.Audience...),
+		WithActor(claims.Act), withSessionID(claims.SessionID), withEvent(audit.EventTokenRefreshed))
+}
+
+// signOneTime 补全一次性令牌的声明并签名
+func (a *JWTAuth) signOneTime(ctx context.Context, claims *Claims, expired time.Duration) (*tokenInfo, error) {
+	if a.store == nil {
+		return nil, ErrStoreRequired
+	}
+	if expired <= 0 {
+		expired = a.opts.expired
+	}
+
+	jti, err := newTokenID()
+	if err != nil {
+		return nil, errors.Unauthorized(reason, i18n.FromContext(ctx).LocalizeT(MessageSignTokenFailed))
+	}
+
+	now := a.opts.clock.Now()
+	expiresAt := now.Add(expired)
+	claims.Issuer = a.opts.issuer
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(expiresAt)
+	claims.NotBefore = jwt.NewNumericDate(now)
+	// ID = jti, 令牌的唯一标识，用于记录令牌是否已被消费
+	claims.ID = jti
+
+	token, err := a.signClaims(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	a.tel.tokenIssued(ctx, claims.Purpose)
+	return &tokenInfo{
+		Token:     token,
+		Type:      a.opts.tokenType,
+		ExpiresAt: expiresAt.Unix(),
+		IssuedAt:  now.Unix(),
+		ExpiresIn: int64(expired / time.Second),
+		format:    a.opts.format,
+	}, nil
+}
+
+// ConsumeOneTime 校验并消费一次性令牌，同一个令牌只能成功消费一次，通过 Destroy 撤销的令牌不能再被消费.
+// purpose 必须与签发时一致；audience 非空时令牌的 aud 必须包含它.
+func (a *JWTAuth) ConsumeOneTime(ctx context.Context, token, purpose, audience string) (_ *Claims, err error) {
+	ctx, span := a.tel.start(ctx, "jwt.ConsumeOneTime", attribute.String("purpose", purpose))
+	defer func() { end(span, err) }()
+	ctx, f := withFailure(ctx)
+	defer func() { a.auditParse(ctx, f, token, err) }()
+
+	if a.store == nil {
+		return nil, ErrStoreRequired
+	}
+
+	claims, err := a.parseToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	// 检查令牌用途，会话令牌的用途为空，同样会被拒绝
+	if claims.Purpose == "" || claims.Purpose != purpose {
+		a.tel.parseFailed(ctx, failurePurpose)
+		return nil, errors.Unauthorized(reason, i18n.FromContext(ctx).LocalizeT(MessageTokenPurposeMismatch))
+	}
+	if audience != "" && !claims.VerifyAudience(audience, true) {
+		a.tel.parseFailed(ctx, failureAudience)
+		return nil, errors.Unauthorized(reason, i18n.FromContext(ctx).LocalizeT(MessageTokenInvalid))
+	}
+	if claims.ID == "" {
+		a.tel.parseFailed(ctx, failureInvalid)
+		return nil, errors.Unauthorized(reason, i18n.FromContext(ctx).LocalizeT(MessageTokenInvalid))
+	}
+	// 通过 Destroy 撤销的令牌不能再被消费
+	if err := a.checkRevoked(ctx, token); err != nil {
+		return nil, err
+	}
+
+	// 记录令牌已被消费，记录保留到令牌过期为止
+	key := onceKeyPrefix + claims.ID
+	expired := claims.ExpiresAt.Sub(a.opts.clock.Now())
+	var consumed bool
+	err = a.callStore(ctx, "consume", func(ctx context.Context, store Storer) error {
+		if once, ok := store.(OnceStorer); ok {
+			ok, err := once.SetNX(ctx, key, expired)
+			consumed = !ok
+			return err
+		}
+		exists, err := store.Check(ctx, key)
+		if err != nil || exists {
+			consumed = exists
+			return err
+		}
+		return store.Set(ctx, key, expired)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if consumed {
+		a.tel.parseFailed(ctx, failureRevoked)
+		return nil, errors.Unauthorized(reason, i18n.FromContext(ctx).LocalizeT(MessageTokenConsumed))
+	}
+	return claims, nil
+}
+
+// newTokenID 生成随机的令牌唯一标识
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,148 @@
+package jwt_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LiangNing7/onex/pkg/authn/jwt"
+	"github.com/go-kratos/kratos/v2/errors"
+	goi18n "github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+// wantUnauthorized 检查 err 是否为指定消息的 Unauthorized 错误
+func wantUnauthorized(t *testing.T, err error, want *goi18n.Message) {
+	t.Helper()
+	if !errors.IsUnauthorized(err) || errors.FromError(err).Message != want.Other {
+		t.Fatalf("error = %v, want Unauthorized with message %q", err, want.Other)
+	}
+}
+
+func TestConsumeOneTime(t *testing.T) {
+	auth, _, r := newTelemetryAuth(t)
+	ctx := context.Background()
+
+	sign := func() string {
+		t.Helper()
+		token, err := auth.SignOneTime(ctx, "alice", jwt.PurposeResetPassword, time.Minute, "web")
+		if err != nil {
+			t.Fatalf("SignOneTime() error = %v", err)
+		}
+		return token.GetToken()
+	}
+
+	t.Run("single use", func(t *testing.T) {
+		token := sign()
+		claims, err := auth.ConsumeOneTime(ctx, token, jwt.PurposeResetPassword, "web")
+		if err != nil {
+			t.Fatalf("ConsumeOneTime() error = %v", err)
+		}
+		if claims.Subject != "alice" || claims.Purpose != jwt.PurposeResetPassword {
+			t.Fatalf("claims = %+v, want alice with purpose %s", claims, jwt.PurposeResetPassword)
+		}
+		_, err = auth.ConsumeOneTime(ctx, token, jwt.PurposeResetPassword, "web")
+		wantUnauthorized(t, err, jwt.MessageTokenConsumed)
+	})
+
+	t.Run("wrong purpose", func(t *testing.T) {
+		token := sign()
+		_, err := auth.ConsumeOneTime(ctx, token, jwt.PurposeVerifyEmail, "web")
+		wantUnauthorized(t, err, jwt.MessageTokenPurposeMismatch)
+		// 被拒绝的令牌没有被消费
+		if _, err := auth.ConsumeOneTime(ctx, token, jwt.PurposeResetPassword, "web"); err != nil {
+			t.Fatalf("ConsumeOneTime() after a rejected attempt error = %v", err)
+		}
+	})
+
+	t.Run("audience mismatch", func(t *testing.T) {
+		token := sign()
+		before := r.counter(t, "authn.jwt.parse.failures", "reason", "audience")
+		_, err := auth.ConsumeOneTime(ctx, token, jwt.PurposeResetPassword, "mobile")
+		wantUnauthorized(t, err, jwt.MessageTokenInvalid)
+		if n := r.counter(t, "authn.jwt.parse.failures", "reason", "audience"); n != before+1 {
+			t.Fatalf("audience failures = %d, want %d", n, before+1)
+		}
+		if v, _ := spanAttr(r.span(t, "jwt.ConsumeOneTime"), "authn.failure.reason"); v.AsString() != "audience" {
+			t.Fatalf("failure reason = %q, want audience", v.AsString())
+		}
+		// 不限定接收方时可以消费
+		if _, err := auth.ConsumeOneTime(ctx, token, jwt.PurposeResetPassword, ""); err != nil {
+			t.Fatalf("ConsumeOneTime() error = %v", err)
+		}
+	})
+
+	t.Run("destroyed", func(t *testing.T) {
+		token := sign()
+		if err := auth.Destroy(ctx, token); err != nil {
+			t.Fatalf("Destroy() error = %v", err)
+		}
+		_, err := auth.ConsumeOneTime(ctx, token, jwt.PurposeResetPassword, "web")
+		wantUnauthorized(t, err, jwt.MessageTokenInvalid)
+		if v, _ := spanAttr(r.span(t, "jwt.ConsumeOneTime"), "authn.failure.reason"); v.AsString() != "revoked" {
+			t.Fatalf("failure reason = %q, want revoked", v.AsString())
+		}
+	})
+
+	t.Run("session token", func(t *testing.T) {
+		token, err := auth.Sign(ctx, "alice")
+		if err != nil {
+			t.Fatalf("Sign() error = %v", err)
+		}
+		_, err = auth.ConsumeOneTime(ctx, token.GetToken(), jwt.PurposeResetPassword, "")
+		wantUnauthorized(t, err, jwt.MessageTokenPurposeMismatch)
+	})
+
+	t.Run("parse rejects one-time tokens", func(t *testing.T) {
+		_, err := auth.ParseClaims(ctx, sign())
+		wantUnauthorized(t, err, jwt.MessageTokenPurposeMismatch)
+	})
+}
+
+func TestRefresh(t *testing.T) {
+	auth, _, _ := newTelemetryAuth(t, jwt.WithRefreshExpired(time.Hour))
+	ctx := context.Background()
+	refreshToken := func(token any) string {
+		t.Helper()
+		rt, _ := token.(interface{ GetRefreshToken() string })
+		if rt == nil || rt.GetRefreshToken() == "" {
+			t.Fatal("token has no refresh token")
+		}
+		return rt.GetRefreshToken()
+	}
+
+	first, err := auth.SignWith(ctx, "alice", jwt.WithScope("post:read"))
+	if err != nil {
+		t.Fatalf("SignWith() error = %v", err)
+	}
+	second, err := auth.Refresh(ctx, refreshToken(first))
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	claims, err := auth.ParseTokenClaims(ctx, second.GetToken())
+	if err != nil || claims.Subject != "alice" || claims.Scope != "post:read" {
+		t.Fatalf("refreshed claims = %+v, %v, want alice with scope post:read", claims, err)
+	}
+	if refreshToken(second) == refreshToken(first) {
+		t.Fatal("Refresh() reused the refresh token")
+	}
+
+	// 刷新令牌只能使用一次
+	_, err = auth.Refresh(ctx, refreshToken(first))
+	wantUnauthorized(t, err, jwt.MessageTokenConsumed)
+
+	// 其他用途的一次性令牌与会话令牌不能用于刷新
+	reset, err := auth.SignOneTime(ctx, "alice", jwt.PurposeResetPassword, time.Minute)
+	if err != nil {
+		t.Fatalf("SignOneTime() error = %v", err)
+	}
+	for name, token := range map[string]string{"one-time": reset.GetToken(), "session": second.GetToken()} {
+		_, err := auth.Refresh(ctx, token)
+		if !errors.IsUnauthorized(err) || errors.FromError(err).Message != jwt.ErrTokenPurposeMismatch.Message {
+			t.Fatalf("Refresh() with a %s token error = %v, want %v", name, err, jwt.MessageTokenPurposeMismatch)
+		}
+	}
+	// 被拒绝的重置令牌仍然可以按原用途使用
+	if _, err := auth.ConsumeOneTime(ctx, reset.GetToken(), jwt.PurposeResetPassword, ""); err != nil {
+		t.Fatalf("ConsumeOneTime() error = %v", err)
+	}
+}
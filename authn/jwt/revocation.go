@@ -0,0 +1,197 @@
+package jwt
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/LiangNing7/onex/pkg/authn/audit"
+	"github.com/LiangNing7/onex/pkg/i18n"
+	"github.com/go-kratos/kratos/v2/errors"
+)
+
+// importBatchSize 导入撤销记录时每批写入的数量
+const importBatchSize = 1000
+
+// ErrExportUnsupported 表示 Storer 不支持导出
+var ErrExportUnsupported = errors.InternalServer("ExportUnsupported", "Token store does not support export")
+
+// Revocation 表示一条撤销记录，是导出与导入时使用的 JSON Lines 格式.
+// 使用绝对的过期时间，导入到其他区域后保留原有的剩余有效期.
+type Revocation struct {
+	Token     string    `json:"token"`      // 被撤销的令牌
+	ExpiresAt time.Time `json:"expires_at"` // 撤销记录的过期时间
+}
+
+// DestroyMulti 批量销毁令牌，用于事故响应.
+// 已经过期的令牌会被跳过；无效的令牌不会阻止其余令牌被撤销，返回的错误由每个无效令牌的错误
+// （"token <下标>: <错误>"）通过 errors.Join 组成，存储失败时直接返回存储的错误.
+func (a *JWTAuth) DestroyMulti(ctx context.Context, tokens []string) (err error) {
+	ctx, span := a.tel.start(ctx, "jwt.DestroyMulti")
+	defer func() { end(span, err) }()
+
+	// 调用方已经取消时不再处理
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// 解析所有令牌，计算剩余时间
+	now := a.opts.clock.Now()
+	entries := make(map[string]time.Duration, len(tokens))
+	revoked := make(map[string]*Claims, len(tokens))
+	var rejected []error
+	for i, token := range tokens {
+		claims, err := a.revocationClaims(ctx, token)
+		if err != nil {
+			rejected = append(rejected, fmt.Errorf("token %d: %w", i, err))
+			continue
+		}
+		if claims == nil {
+			continue
+		}
+		entries[token] = claims.ExpiresAt.Sub(now)
+		revoked[token] = claims
+	}
+	if err := a.setMulti(ctx, entries); err != nil {
+		return err
+	}
+	for token, claims := range revoked {
+		a.auditToken(ctx, audit.EventTokenRevoked, token, claims)
+	}
+
+	// 删除令牌所属的会话，交换得到的令牌不拥有会话
+	for _, claims := range revoked {
+		if claims.SessionID == "" || claims.Exchanged {
+			continue
+		}
+		err := a.callSessions(ctx, "session.delete", func(ctx context.Context, sessions SessionStorer) error {
+			_, err := sessions.DeleteSession(ctx, claims.Subject, claims.SessionID)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return stderrors.Join(rejected...)
+}
+
+// Revoked 批量检查令牌是否已被撤销，结果与 tokens 一一对应.
+func (a *JWTAuth) Revoked(ctx context.Context, tokens []string) ([]bool, error) {
+	revoked := make([]bool, len(tokens))
+	err := a.callStore(ctx, "check_multi", func(ctx context.Context, store Storer) (err error) {
+		if batch, ok := store.(BatchStorer); ok {
+			revoked, err = batch.CheckMulti(ctx, tokens)
+			return err
+		}
+		for i, token := range tokens {
+			if revoked[i], err = store.Check(ctx, token); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return revoked, err
+}
+
+// ExportRevocations 以 JSON Lines 格式导出所有未过期的撤销记录，Storer 需要实现 ExportStorer.
+func (a *JWTAuth) ExportRevocations(ctx context.Context, w io.Writer) (err error) {
+	ctx, span := a.tel.start(ctx, "jwt.ExportRevocations")
+	defer func() { end(span, err) }()
+
+	exporter, ok := a.store.(ExportStorer)
+	if !ok {
+		return ErrExportUnsupported
+	}
+	enc := json.NewEncoder(w)
+	return a.callStore(ctx, "export", func(ctx context.Context, _ Storer) error {
+		now := a.opts.clock.Now()
+		return exporter.Export(ctx, func(token string, ttl time.Duration) error {
+			return enc.Encode(&Revocation{Token: token, ExpiresAt: now.Add(ttl).UTC()})
+		})
+	})
+}
+
+// ImportRevocations 导入 ExportRevocations 导出的撤销记录，已经过期的记录会被跳过，返回导入的数量.
+func (a *JWTAuth) ImportRevocations(ctx context.Context, r io.Reader) (n int, err error) {
+	ctx, span := a.tel.start(ctx, "jwt.ImportRevocations")
+	defer func() { end(span, err) }()
+
+	if a.store == nil {
+		return 0, ErrStoreRequired
+	}
+
+	entries := make(map[string]time.Duration, importBatchSize)
+	flush := func() error {
+		if err := a.setMulti(ctx, entries); err != nil {
+			return err
+		}
+		n += len(entries)
+		clear(entries)
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rev Revocation
+		if err := json.Unmarshal(scanner.Bytes(), &rev); err != nil {
+			return n, err
+		}
+		ttl := rev.ExpiresAt.Sub(a.opts.clock.Now())
+		if rev.Token == "" || ttl <= 0 {
+			continue
+		}
+		entries[rev.Token] = ttl
+		if len(entries) >= importBatchSize {
+			if err := flush(); err != nil {
+				return n, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return n, err
+	}
+	return n, flush()
+}
+
+// setMulti 批量写入令牌数据，Storer 未实现 BatchStorer 时逐个写入
+func (a *JWTAuth) setMulti(ctx context.Context, entries map[string]time.Duration) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return a.callStore(ctx, "set_multi", func(ctx context.Context, store Storer) error {
+		if batch, ok := store.(BatchStorer); ok {
+			return batch.SetMulti(ctx, entries)
+		}
+		for token, expired := range entries {
+			if err := store.Set(ctx, token, expired); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// revocationClaims 解析需要撤销的令牌，已经过期的令牌返回 nil
+func (a *JWTAuth) revocationClaims(ctx context.Context, token string) (*Claims, error) {
+	claims := &Claims{}
+	parsed, err := a.parser.ParseWithClaims(token, claims, a.opts.keyfunc)
+	if err == nil && parsed.Valid && parsed.Method.Alg() == a.opts.signingMethod.Alg() && claims.ExpiresAt != nil {
+		if !claims.VerifyExpiresAt(a.opts.clock.Now().Add(-a.opts.leeway), true) {
+			return nil, nil
+		}
+		return claims, nil
+	}
+	// 其他情况交给 parseToken 返回统一的错误
+	if _, err := a.parseToken(ctx, token); err != nil {
+		return nil, err
+	}
+	return nil, errors.Unauthorized(reason, i18n.FromContext(ctx).LocalizeT(MessageTokenInvalid))
+}
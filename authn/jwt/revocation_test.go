@@ -0,0 +1,112 @@
+package jwt_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LiangNing7/onex/pkg/authn/jwt"
+	"github.com/LiangNing7/onex/pkg/authn/jwt/jwttest"
+	"github.com/go-kratos/kratos/v2/errors"
+)
+
+func TestDestroyMultiSkipsInvalidTokens(t *testing.T) {
+	ctx := context.Background()
+	clock := jwttest.NewClock(time.Now())
+	auth, minter := jwttest.New(clock)
+
+	valid, err := auth.Sign(ctx, "alice")
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	tokens := []string{
+		valid.GetToken(),
+		minter.Forged(t, "mallory"),
+		minter.Expired(t, "bob", time.Hour),
+		"not-a-token",
+	}
+
+	err = auth.DestroyMulti(ctx, tokens)
+	if err == nil {
+		t.Fatal("DestroyMulti() error = nil, want errors for invalid tokens")
+	}
+	// 无效的令牌逐个列出，已经过期的令牌被跳过
+	msg := err.Error()
+	if !strings.Contains(msg, "token 1:") || !strings.Contains(msg, "token 3:") || strings.Contains(msg, "token 0:") || strings.Contains(msg, "token 2:") {
+		t.Fatalf("DestroyMulti() error = %q, want errors for tokens 1 and 3 only", msg)
+	}
+	if !errors.IsUnauthorized(err) {
+		t.Fatalf("DestroyMulti() error = %v, want Unauthorized", err)
+	}
+
+	// 有效的令牌仍然被撤销
+	revoked, err := auth.Revoked(ctx, tokens[:1])
+	if err != nil || !revoked[0] {
+		t.Fatalf("Revoked() = %v, %v, want the valid token revoked", revoked, err)
+	}
+	if _, err := auth.ParseTokenClaims(ctx, valid.GetToken()); !errors.IsUnauthorized(err) {
+		t.Fatalf("ParseTokenClaims() error = %v, want Unauthorized", err)
+	}
+}
+
+func TestExportImportRevocations(t *testing.T) {
+	ctx := context.Background()
+	start := time.Unix(1700000000, 0)
+	auth, _ := jwttest.New(jwttest.NewClock(start))
+
+	revoked, err := auth.Sign(ctx, "alice")
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	// 一分钟后过期的一次性令牌，导入时已经过期
+	short, err := auth.SignOneTime(ctx, "alice", jwt.PurposeVerifyEmail, time.Minute)
+	if err != nil {
+		t.Fatalf("SignOneTime() error = %v", err)
+	}
+	active, err := auth.Sign(ctx, "bob")
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	for _, token := range []string{revoked.GetToken(), short.GetToken()} {
+		if err := auth.Destroy(ctx, token); err != nil {
+			t.Fatalf("Destroy() error = %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := auth.ExportRevocations(ctx, &buf); err != nil {
+		t.Fatalf("ExportRevocations() error = %v", err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 2 {
+		t.Fatalf("exported %d revocations, want 2:\n%s", lines, buf.String())
+	}
+
+	// 导入到两分钟后启动的新实例
+	fresh, _ := jwttest.New(jwttest.NewClock(start.Add(2 * time.Minute)))
+	if _, err := fresh.ParseClaims(ctx, revoked.GetToken()); err != nil {
+		t.Fatalf("ParseClaims() before import error = %v", err)
+	}
+	n, err := fresh.ImportRevocations(ctx, &buf)
+	if err != nil {
+		t.Fatalf("ImportRevocations() error = %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("ImportRevocations() = %d, want 1 with the expired entry skipped", n)
+	}
+	if _, err := fresh.ParseClaims(ctx, revoked.GetToken()); !errors.IsUnauthorized(err) {
+		t.Fatalf("ParseClaims() of an imported revocation error = %v, want Unauthorized", err)
+	}
+	if _, err := fresh.ParseClaims(ctx, active.GetToken()); err != nil {
+		t.Fatalf("ParseClaims() of an active token error = %v", err)
+	}
+	if got, err := fresh.Revoked(ctx, []string{short.GetToken()}); err != nil || got[0] {
+		t.Fatalf("Revoked() of the expired entry = %v, %v, want not imported", got, err)
+	}
+
+	// 格式错误的记录返回错误
+	if _, err := fresh.ImportRevocations(ctx, strings.NewReader("{not json}\n")); err == nil {
+		t.Fatal("ImportRevocations() error = nil for malformed input")
+	}
+}
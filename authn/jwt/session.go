@@ -0,0 +1,154 @@
+package jwt
+
+import (
+	"context"
+	"time"
+
+	"github.com/LiangNing7/onex/pkg/authn/audit"
+	"github.com/LiangNing7/onex/pkg/i18n"
+	"github.com/go-kratos/kratos/v2/errors"
+)
+
+// ErrSessionStoreRequired 表示会话管理需要配置 SessionStorer
+var ErrSessionStoreRequired = errors.InternalServer("StoreRequired", "Sessions require a session store")
+
+// Session 表示一次登录会话，同一个会话中刷新得到的令牌共享会话标识.
+type Session struct {
+	ID        string    `json:"id"`                  // 会话标识，即令牌的 sid 声明
+	Subject   string    `json:"subject"`             // 会话所属的主体
+	Device    string    `json:"device,omitempty"`    // 设备名称
+	IP        string    `json:"ip,omitempty"`        // 客户端 IP
+	UserAgent string    `json:"userAgent,omitempty"` // 客户端 User-Agent
+	CreatedAt time.Time `json:"createdAt"`           // 登录时间
+	LastSeen  time.Time `json:"lastSeen"`            // 最后活跃时间
+	ExpiresAt time.Time `json:"expiresAt"`           // 过期时间
+}
+
+// SessionStorer 会话存储接口.
+type SessionStorer interface {
+	// SaveSession 保存会话，会话在 ExpiresAt 之后过期
+	SaveSession(ctx context.Context, session *Session) error
+	// GetSession 获取会话，会话不存在时返回 nil
+	GetSession(ctx context.Context, subject, id string) (*Session, error)
+	// TouchSession 仅当会话存在时更新最后活跃时间，expiresAt 不为零值时同时更新过期时间，返回会话是否存在.
+	// 会话在读取与写入之间被删除时不能重新创建，否则已注销的会话会恢复
+	TouchSession(ctx context.Context, subject, id string, lastSeen, expiresAt time.Time) (bool, error)
+	// ListSessions 列出主体所有未过期的会话
+	ListSessions(ctx context.Context, subject string) ([]*Session, error)
+	// DeleteSession 删除会话
+	DeleteSession(ctx context.Context, subject, id string) (bool, error)
+}
+
+// Sessions 列出主体所有未过期的会话，用于回答 "我在哪些地方登录过".
+func (a *JWTAuth) Sessions(ctx context.Context, subject string) ([]*Session, error) {
+	if a.opts.sessions == nil {
+		return nil, ErrSessionStoreRequired
+	}
+	var list []*Session
+	err := a.callSessions(ctx, "session.list", func(ctx context.Context, sessions SessionStorer) (err error) {
+		list, err = sessions.ListSessions(ctx, subject)
+		return err
+	})
+	return list, err
+}
+
+// RevokeSession 注销主体的某个会话，该会话的访问令牌和刷新令牌随即失效.
+func (a *JWTAuth) RevokeSession(ctx context.Context, subject, id string) error {
+	if a.opts.sessions == nil {
+		return ErrSessionStoreRequired
+	}
+	err := a.callSessions(ctx, "session.delete", func(ctx context.Context, sessions SessionStorer) error {
+		_, err := sessions.DeleteSession(ctx, subject, id)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	a.audit(ctx, &audit.Event{Type: audit.EventSessionRevoked, Subject: subject, SessionID: id})
+	return nil
+}
+
+// RevokeSessions 注销主体的所有会话，即 "退出所有设备".
+func (a *JWTAuth) RevokeSessions(ctx context.Context, subject string) error {
+	list, err := a.Sessions(ctx, subject)
+	if err != nil {
+		return err
+	}
+	for _, session := range list {
+		if err := a.RevokeSession(ctx, subject, session.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// callSessions 执行传入的会话存储函数，未配置会话存储时返回 nil
+func (a *JWTAuth) callSessions(ctx context.Context, op string, fn func(context.Context, SessionStorer) error) error {
+	sessions := a.opts.sessions
+	if sessions == nil {
+		return nil
+	}
+	return a.instrument(ctx, op, func(ctx context.Context) error {
+		return fn(ctx, sessions)
+	})
+}
+
+// saveSession 创建会话，或在刷新令牌时更新已有会话的过期时间
+func (a *JWTAuth) saveSession(ctx context.Context, claims *Claims, so *signOptions, now, expiresAt time.Time) error {
+	return a.callSessions(ctx, "session.save", func(ctx context.Context, sessions SessionStorer) error {
+		// 刷新令牌时沿用原有会话的登录信息，会话在刷新期间被注销时拒绝刷新
+		if so.sessionID != "" {
+			ok, err := sessions.TouchSession(ctx, claims.Subject, claims.SessionID, now, expiresAt)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				a.tel.parseFailed(ctx, failureRevoked)
+				return errors.Unauthorized(reason, i18n.FromContext(ctx).LocalizeT(MessageTokenInvalid))
+			}
+			return nil
+		}
+		return sessions.SaveSession(ctx, &Session{
+			ID:        claims.SessionID,
+			Subject:   claims.Subject,
+			Device:    so.device,
+			IP:        so.ip,
+			UserAgent: so.userAgent,
+			CreatedAt: now,
+			LastSeen:  now,
+			ExpiresAt: expiresAt,
+		})
+	})
+}
+
+// checkSession 检查令牌所属的会话是否存在，并按间隔更新最后活跃时间
+func (a *JWTAuth) checkSession(ctx context.Context, claims *Claims) error {
+	if claims.SessionID == "" {
+		return nil
+	}
+	return a.callSessions(ctx, "session.check", func(ctx context.Context, sessions SessionStorer) error {
+		session, err := sessions.GetSession(ctx, claims.Subject, claims.SessionID)
+		if err != nil {
+			a.tel.parseFailed(ctx, failureStore)
+			return err
+		}
+		if session != nil {
+			now := a.opts.clock.Now()
+			if now.Sub(session.LastSeen) < a.opts.touchInterval {
+				return nil
+			}
+			// 只更新仍然存在的会话，避免恢复在读取之后被注销的会话
+			ok, err := sessions.TouchSession(ctx, claims.Subject, claims.SessionID, now, time.Time{})
+			if err != nil {
+				a.tel.parseFailed(ctx, failureStore)
+				return err
+			}
+			if ok {
+				return nil
+			}
+		}
+		// 会话不存在说明已被注销
+		a.tel.parseFailed(ctx, failureRevoked)
+		return errors.Unauthorized(reason, i18n.FromContext(ctx).LocalizeT(MessageTokenInvalid))
+	})
+}
@@ -0,0 +1,129 @@
+package jwt_test
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/LiangNing7/onex/pkg/authn"
+	"github.com/LiangNing7/onex/pkg/authn/jwt"
+	"github.com/LiangNing7/onex/pkg/authn/jwt/jwttest"
+	"github.com/LiangNing7/onex/pkg/authn/jwt/store/memory"
+	"github.com/go-kratos/kratos/v2/errors"
+)
+
+// newSessionAuth 创建配置了内存会话存储的 JWTAuth
+func newSessionAuth(t *testing.T) (*jwt.JWTAuth, *jwttest.Clock) {
+	t.Helper()
+	clock := jwttest.NewClock(time.Now())
+	auth, _ := jwttest.New(clock, jwt.WithSessionStore(memory.NewStore(memory.WithClock(clock))))
+	return auth, clock
+}
+
+// signSession 签发令牌并返回令牌与会话标识
+func signSession(t *testing.T, auth *jwt.JWTAuth, subject, device string) (authn.IToken, string) {
+	t.Helper()
+	ctx := context.Background()
+	token, err := auth.SignWith(ctx, subject, jwt.WithSessionInfo(device, "10.0.0.1", "test-agent"))
+	if err != nil {
+		t.Fatalf("SignWith() error = %v", err)
+	}
+	claims, err := auth.ParseTokenClaims(ctx, token.GetToken())
+	if err != nil {
+		t.Fatalf("ParseTokenClaims() error = %v", err)
+	}
+	if claims.SessionID == "" {
+		t.Fatal("token has no session id")
+	}
+	return token, claims.SessionID
+}
+
+func TestSessions(t *testing.T) {
+	ctx := context.Background()
+	auth, clock := newSessionAuth(t)
+
+	_, laptop := signSession(t, auth, "alice", "laptop")
+	clock.Advance(time.Second)
+	_, phone := signSession(t, auth, "alice", "phone")
+	signSession(t, auth, "bob", "laptop")
+
+	sessions, err := auth.Sessions(ctx, "alice")
+	if err != nil {
+		t.Fatalf("Sessions() error = %v", err)
+	}
+	got := make(map[string]*jwt.Session, len(sessions))
+	for _, s := range sessions {
+		got[s.ID] = s
+	}
+	if len(got) != 2 || got[laptop] == nil || got[phone] == nil {
+		t.Fatalf("Sessions() = %+v, want sessions %q and %q", sessions, laptop, phone)
+	}
+	if s := got[phone]; s.Subject != "alice" || s.Device != "phone" || s.IP != "10.0.0.1" || s.UserAgent != "test-agent" {
+		t.Fatalf("session = %+v, want the sign-in details", s)
+	}
+	if sessions, _ := auth.Sessions(ctx, "carol"); len(sessions) != 0 {
+		t.Fatalf("Sessions() of an unknown subject = %+v, want none", sessions)
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	ctx := context.Background()
+	auth, _ := newSessionAuth(t)
+
+	laptopToken, laptop := signSession(t, auth, "alice", "laptop")
+	phoneToken, _ := signSession(t, auth, "alice", "phone")
+
+	if err := auth.RevokeSession(ctx, "alice", laptop); err != nil {
+		t.Fatalf("RevokeSession() error = %v", err)
+	}
+	if _, err := auth.ParseClaims(ctx, laptopToken.GetToken()); !errors.IsUnauthorized(err) {
+		t.Fatalf("ParseClaims() of the revoked session error = %v, want Unauthorized", err)
+	}
+	if _, err := auth.ParseClaims(ctx, phoneToken.GetToken()); err != nil {
+		t.Fatalf("ParseClaims() of another session error = %v", err)
+	}
+	if sessions, _ := auth.Sessions(ctx, "alice"); len(sessions) != 1 {
+		t.Fatalf("Sessions() = %d sessions, want 1", len(sessions))
+	}
+}
+
+func TestRevokeSessions(t *testing.T) {
+	ctx := context.Background()
+	auth, _ := newSessionAuth(t)
+
+	laptopToken, _ := signSession(t, auth, "alice", "laptop")
+	phoneToken, _ := signSession(t, auth, "alice", "phone")
+	bobToken, _ := signSession(t, auth, "bob", "laptop")
+
+	if err := auth.RevokeSessions(ctx, "alice"); err != nil {
+		t.Fatalf("RevokeSessions() error = %v", err)
+	}
+	for _, token := range []authn.IToken{laptopToken, phoneToken} {
+		if _, err := auth.ParseClaims(ctx, token.GetToken()); !errors.IsUnauthorized(err) {
+			t.Fatalf("ParseClaims() after RevokeSessions() error = %v, want Unauthorized", err)
+		}
+	}
+	if sessions, _ := auth.Sessions(ctx, "alice"); len(sessions) != 0 {
+		t.Fatalf("Sessions() = %+v, want none", sessions)
+	}
+	// 其他主体的会话不受影响
+	if _, err := auth.ParseClaims(ctx, bobToken.GetToken()); err != nil {
+		t.Fatalf("ParseClaims() of another subject error = %v", err)
+	}
+}
+
+func TestSessionStoreRequired(t *testing.T) {
+	ctx := context.Background()
+	auth, _ := jwttest.New(jwttest.NewClock(time.Now()))
+
+	if _, err := auth.Sessions(ctx, "alice"); !stderrors.Is(err, jwt.ErrSessionStoreRequired) {
+		t.Fatalf("Sessions() error = %v, want ErrSessionStoreRequired", err)
+	}
+	if err := auth.RevokeSession(ctx, "alice", "sid"); !stderrors.Is(err, jwt.ErrSessionStoreRequired) {
+		t.Fatalf("RevokeSession() error = %v, want ErrSessionStoreRequired", err)
+	}
+	if err := auth.RevokeSessions(ctx, "alice"); !stderrors.Is(err, jwt.ErrSessionStoreRequired) {
+		t.Fatalf("RevokeSessions() error = %v, want ErrSessionStoreRequired", err)
+	}
+}
@@ -0,0 +1,150 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/asn1"
+	"math/big"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// ErrUnsupportedSigner 表示 WithSigner 传入的签名器的公钥类型不受支持
+var ErrUnsupportedSigner = errors.InternalServer("UnsupportedSigner", "Signer key type is not supported")
+
+// SigningMethodSigner 使用 crypto.Signer 签名的 jwt.SigningMethod.
+// 私钥可以保存在 HSM/KMS 中，进程内只需要持有实现了 crypto.Signer 的句柄；验证时使用对应的公钥.
+type SigningMethodSigner struct {
+	alg     string        // 算法名称，例如 RS256、ES256、EdDSA
+	hash    crypto.Hash   // 摘要算法，EdDSA 为 0
+	keySize int           // ECDSA 签名中 R、S 的字节长度
+	signer  crypto.Signer // 签名器
+}
+
+var _ jwt.SigningMethod = (*SigningMethodSigner)(nil)
+
+// NewSigningMethod 根据 crypto.Signer 的公钥类型创建签名方法.
+// 支持 RSA（RS256）、ECDSA P-256/P-384/P-521（ES256/ES384/ES512）与 Ed25519（EdDSA）.
+func NewSigningMethod(signer crypto.Signer) (*SigningMethodSigner, error) {
+	m := &SigningMethodSigner{signer: signer}
+	switch pub := signer.Public().(type) {
+	case *rsa.PublicKey:
+		m.alg, m.hash = "RS256", crypto.SHA256
+	case *ecdsa.PublicKey:
+		m.keySize = (pub.Curve.Params().BitSize + 7) / 8
+		switch pub.Curve {
+		case elliptic.P256():
+			m.alg, m.hash = "ES256", crypto.SHA256
+		case elliptic.P384():
+			m.alg, m.hash = "ES384", crypto.SHA384
+		case elliptic.P521():
+			m.alg, m.hash = "ES512", crypto.SHA512
+		default:
+			return nil, jwt.ErrInvalidKeyType
+		}
+	case ed25519.PublicKey:
+		m.alg = "EdDSA"
+	default:
+		return nil, jwt.ErrInvalidKeyType
+	}
+	return m, nil
+}
+
+// Alg 返回算法名称.
+func (m *SigningMethodSigner) Alg() string {
+	return m.alg
+}
+
+// Public 返回用于验证签名的公钥.
+func (m *SigningMethodSigner) Public() crypto.PublicKey {
+	return m.signer.Public()
+}
+
+// Sign 使用 crypto.Signer 对 signingString 签名，key 参数会被忽略.
+func (m *SigningMethodSigner) Sign(signingString string, _ any) (string, error) {
+	if m.alg == "" {
+		return "", jwt.ErrInvalidKeyType
+	}
+	digest := []byte(signingString)
+	if m.hash != 0 {
+		if !m.hash.Available() {
+			return "", jwt.ErrHashUnavailable
+		}
+		hasher := m.hash.New()
+		hasher.Write(digest)
+		digest = hasher.Sum(nil)
+	}
+
+	sig, err := m.signer.Sign(rand.Reader, digest, m.hash)
+	if err != nil {
+		return "", err
+	}
+	// crypto.Signer 返回 ASN.1 DER 编码的 ECDSA 签名，JWS 要求定长的 R || S
+	if m.keySize > 0 {
+		if sig, err = derToRS(sig, m.keySize); err != nil {
+			return "", err
+		}
+	}
+	return jwt.EncodeSegment(sig), nil
+}
+
+// Verify 使用公钥验证签名，key 必须是与签名器对应的公钥.
+func (m *SigningMethodSigner) Verify(signingString, signature string, key any) error {
+	method := jwt.GetSigningMethod(m.alg)
+	if method == nil {
+		return jwt.ErrInvalidKeyType
+	}
+	return method.Verify(signingString, signature, key)
+}
+
+// WithSigner 设置使用 crypto.Signer 签名，签名算法根据公钥类型确定，验证时使用 signer.Public()。
+// 公钥类型不受支持时，签发令牌会返回 ErrUnsupportedSigner，解析令牌时所有令牌都被视为无效。
+func WithSigner(signer crypto.Signer) Option {
+	return func(o *options) {
+		m, err := NewSigningMethod(signer)
+		if err != nil {
+			o.err = ErrUnsupportedSigner.WithCause(err)
+			o.keyfunc = func(*jwt.Token) (any, error) {
+				return nil, ErrTokenInvalid
+			}
+			return
+		}
+		o.err = nil
+		o.signingMethod = m
+		o.signingKey = signer
+		o.keyfunc = func(t *jwt.Token) (any, error) {
+			// 检查 token 的签名算法是否与签名器一致
+			if t.Method.Alg() != m.alg {
+				return nil, ErrTokenInvalid
+			}
+			return signer.Public(), nil
+		}
+	}
+}
+
+// ecdsaSignature ASN.1 DER 编码的 ECDSA 签名
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// derToRS 将 ASN.1 DER 编码的 ECDSA 签名转换为定长的 R || S
+func derToRS(der []byte, keySize int) ([]byte, error) {
+	var sig ecdsaSignature
+	rest, err := asn1.Unmarshal(der, &sig)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 || sig.R.Sign() <= 0 || sig.S.Sign() <= 0 ||
+		len(sig.R.Bytes()) > keySize || len(sig.S.Bytes()) > keySize {
+		return nil, jwt.ErrECDSAVerification
+	}
+	out := make([]byte, 2*keySize)
+	sig.R.FillBytes(out[:keySize])
+	sig.S.FillBytes(out[keySize:])
+	return out, nil
+}
@@ -0,0 +1,116 @@
+// Package signer 提供基于 PEM 文件的 crypto.Signer，用于测试或本地开发时代替 HSM/KMS.
+package signer
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// File 从 PEM 文件加载私钥的 crypto.Signer.
+type File struct {
+	path   string        // 私钥文件路径
+	signer crypto.Signer // 私钥
+}
+
+var _ crypto.Signer = (*File)(nil)
+
+// NewFile 从 PEM 文件加载私钥，支持 PKCS#8、PKCS#1（RSA）与 SEC 1（EC）格式.
+func NewFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("signer: %s: %w", path, err)
+	}
+	return &File{path: path, signer: signer}, nil
+}
+
+// Path 返回私钥文件路径.
+func (f *File) Path() string {
+	return f.path
+}
+
+// Public 返回私钥对应的公钥.
+func (f *File) Public() crypto.PublicKey {
+	return f.signer.Public()
+}
+
+// Sign 使用私钥签名.
+func (f *File) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return f.signer.Sign(rand, digest, opts)
+}
+
+// Parse 解析 PEM 编码的私钥.
+func Parse(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+}
+
+// Generate 生成一个新的私钥并以 PKCS#8 格式写入 path，alg 为 RS256、ES256、ES384、ES512 或 EdDSA.
+func Generate(path, alg string) (*File, error) {
+	signer, err := generateKey(alg)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(signer)
+	if err != nil {
+		return nil, err
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return nil, err
+	}
+	return &File{path: path, signer: signer}, nil
+}
+
+// generateKey 根据算法名称生成私钥
+func generateKey(alg string) (crypto.Signer, error) {
+	switch alg {
+	case "RS256":
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case "ES256":
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "ES384":
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case "ES512":
+		return ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	case "EdDSA":
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", alg)
+	}
+}
@@ -0,0 +1,111 @@
+package signer
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	digest := sha256.Sum256([]byte("payload"))
+	for _, alg := range []string{"RS256", "ES256", "ES384", "ES512", "EdDSA"} {
+		t.Run(alg, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "key.pem")
+			generated, err := Generate(path, alg)
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+			if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+				t.Fatalf("key file = %v, %v, want mode 0600", info, err)
+			}
+			f, err := NewFile(path)
+			if err != nil {
+				t.Fatalf("NewFile() error = %v", err)
+			}
+			if f.Path() != path {
+				t.Fatalf("Path() = %q, want %q", f.Path(), path)
+			}
+			if !f.Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(generated.Public()) {
+				t.Fatal("loaded key does not match the generated key")
+			}
+
+			// 签名可以用公钥验证
+			var ok bool
+			switch pub := f.Public().(type) {
+			case *rsa.PublicKey:
+				sig, err := f.Sign(rand.Reader, digest[:], crypto.SHA256)
+				ok = err == nil && rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) == nil
+			case *ecdsa.PublicKey:
+				sig, err := f.Sign(rand.Reader, digest[:], crypto.SHA256)
+				ok = err == nil && ecdsa.VerifyASN1(pub, digest[:], sig)
+			case ed25519.PublicKey:
+				sig, err := f.Sign(rand.Reader, []byte("payload"), crypto.Hash(0))
+				ok = err == nil && ed25519.Verify(pub, []byte("payload"), sig)
+			}
+			if !ok {
+				t.Fatal("signature does not verify")
+			}
+		})
+	}
+
+	if _, err := Generate(filepath.Join(t.TempDir(), "key.pem"), "HS256"); err == nil {
+		t.Fatal("Generate() error = nil for an unsupported algorithm")
+	}
+}
+
+func TestParse(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	sec1, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey() error = %v", err)
+	}
+	encode := func(typ string, der []byte) []byte {
+		return pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+	}
+
+	// PKCS#1 与 SEC 1 格式
+	for name, data := range map[string][]byte{
+		"pkcs1": encode("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)),
+		"sec1":  encode("EC PRIVATE KEY", sec1),
+	} {
+		if _, err := Parse(data); err != nil {
+			t.Fatalf("Parse(%s) error = %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{"no pem", []byte("not a key"), "no PEM block"},
+		{"public key", encode("PUBLIC KEY", []byte{0}), "unsupported PEM block type"},
+		{"corrupt pkcs8", encode("PRIVATE KEY", []byte{0}), "asn1"},
+	}
+	for _, tt := range tests {
+		if _, err := Parse(tt.data); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Fatalf("Parse(%s) error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+
+	if _, err := NewFile(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Fatal("NewFile() error = nil for a missing file")
+	}
+}
@@ -0,0 +1,156 @@
+package jwt_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/LiangNing7/onex/pkg/authn/jwt"
+	"github.com/LiangNing7/onex/pkg/authn/jwt/signer"
+	"github.com/LiangNing7/onex/pkg/authn/jwt/store/memory"
+	"github.com/go-kratos/kratos/v2/errors"
+	gojwt "github.com/golang-jwt/jwt/v4"
+)
+
+// generateSigner 生成私钥文件并重新加载，保证签名器来自 PEM 文件
+func generateSigner(t *testing.T, alg string) *signer.File {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), alg+".pem")
+	if _, err := signer.Generate(path, alg); err != nil {
+		t.Fatalf("Generate(%s) error = %v", alg, err)
+	}
+	f, err := signer.NewFile(path)
+	if err != nil {
+		t.Fatalf("NewFile() error = %v", err)
+	}
+	return f
+}
+
+// tokenHeader 返回令牌头部中的 key
+func tokenHeader(t *testing.T, token, key string) any {
+	t.Helper()
+	parsed, _, err := gojwt.NewParser().ParseUnverified(token, &gojwt.RegisteredClaims{})
+	if err != nil {
+		t.Fatalf("ParseUnverified() error = %v", err)
+	}
+	return parsed.Header[key]
+}
+
+func TestSignerRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	auths := make(map[string]*jwt.JWTAuth)
+	tokens := make(map[string]string)
+	for _, alg := range []string{"RS256", "ES256", "ES384", "ES512", "EdDSA"} {
+		auth := jwt.New(memory.NewStore(), jwt.WithSigner(generateSigner(t, alg)))
+		token, err := auth.Sign(ctx, "alice")
+		if err != nil {
+			t.Fatalf("%s: Sign() error = %v", alg, err)
+		}
+		if got := tokenHeader(t, token.GetToken(), "alg"); got != alg {
+			t.Fatalf("%s: alg header = %v", alg, got)
+		}
+		claims, err := auth.ParseClaims(ctx, token.GetToken())
+		if err != nil {
+			t.Fatalf("%s: ParseClaims() error = %v", alg, err)
+		}
+		if claims.Subject != "alice" {
+			t.Fatalf("%s: subject = %q, want alice", alg, claims.Subject)
+		}
+		auths[alg], tokens[alg] = auth, token.GetToken()
+	}
+
+	// 其它签名器签发的令牌都会被拒绝
+	for alg, auth := range auths {
+		for other, token := range tokens {
+			if other == alg {
+				continue
+			}
+			if _, err := auth.ParseClaims(ctx, token); !errors.IsUnauthorized(err) {
+				t.Fatalf("%s accepted a %s token: %v", alg, other, err)
+			}
+		}
+	}
+}
+
+func TestSignerKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	oldKey, newKey := generateSigner(t, "ES256"), generateSigner(t, "ES256")
+	oldAuth := jwt.New(memory.NewStore(), jwt.WithSigner(oldKey), jwt.WithTokenHeader(map[string]any{"kid": "2024"}))
+	newAuth := jwt.New(memory.NewStore(), jwt.WithSigner(newKey), jwt.WithTokenHeader(map[string]any{"kid": "2025"}))
+
+	oldToken, err := oldAuth.Sign(ctx, "alice")
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	newToken, err := newAuth.Sign(ctx, "bob")
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if kid := tokenHeader(t, newToken.GetToken(), "kid"); kid != "2025" {
+		t.Fatalf("kid header = %v, want 2025", kid)
+	}
+	// 只使用新密钥时，旧密钥签发的令牌被拒绝
+	if _, err := newAuth.ParseClaims(ctx, oldToken.GetToken()); !errors.IsUnauthorized(err) {
+		t.Fatalf("ParseClaims() with the old key error = %v, want Unauthorized", err)
+	}
+
+	// 轮换期间按 kid 选择公钥，新旧令牌都可以通过验证
+	keys := map[string]any{"2024": oldKey.Public(), "2025": newKey.Public()}
+	verifier := jwt.New(memory.NewStore(), jwt.WithSigner(newKey), jwt.WithKeyfunc(func(token *gojwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		if key, ok := keys[kid]; ok && token.Method.Alg() == "ES256" {
+			return key, nil
+		}
+		return nil, jwt.ErrTokenInvalid
+	}))
+	for subject, token := range map[string]string{"alice": oldToken.GetToken(), "bob": newToken.GetToken()} {
+		claims, err := verifier.ParseClaims(ctx, token)
+		if err != nil || claims.Subject != subject {
+			t.Fatalf("ParseClaims() = %v, %v, want %s", claims, err, subject)
+		}
+	}
+
+	// 旧密钥下线后，旧令牌被拒绝
+	delete(keys, "2024")
+	if _, err := verifier.ParseClaims(ctx, oldToken.GetToken()); !errors.IsUnauthorized(err) {
+		t.Fatalf("ParseClaims() after retiring the old key error = %v, want Unauthorized", err)
+	}
+}
+
+func TestWithSignerUnsupportedKey(t *testing.T) {
+	ctx := context.Background()
+	key, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	if _, err := jwt.NewSigningMethod(key); err == nil {
+		t.Fatal("NewSigningMethod() error = nil for a P-224 key")
+	}
+
+	auth := jwt.New(memory.NewStore(), jwt.WithSigner(key))
+	if _, err := auth.Sign(ctx, "alice"); errors.Reason(err) != jwt.ErrUnsupportedSigner.Reason {
+		t.Fatalf("Sign() error = %v, want %v", err, jwt.ErrUnsupportedSigner)
+	}
+	if _, err := auth.SignOneTime(ctx, "alice", jwt.PurposeVerifyEmail, 0); errors.Reason(err) != jwt.ErrUnsupportedSigner.Reason {
+		t.Fatalf("SignOneTime() error = %v, want %v", err, jwt.ErrUnsupportedSigner)
+	}
+
+	// 不会退回默认的 HS256 密钥验证令牌
+	token, err := jwt.New(memory.NewStore()).Sign(ctx, "alice")
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if _, err := auth.ParseClaims(ctx, token.GetToken()); !errors.IsUnauthorized(err) {
+		t.Fatalf("ParseClaims() error = %v, want Unauthorized", err)
+	}
+
+	// 之后传入受支持的签名器时恢复
+	auth = jwt.New(memory.NewStore(), jwt.WithSigner(key), jwt.WithSigner(generateSigner(t, "ES256")))
+	if token, err := auth.Sign(ctx, "alice"); err != nil || !strings.Contains(token.GetToken(), ".") {
+		t.Fatalf("Sign() = %v, %v", token, err)
+	}
+}
@@ -16,3 +16,25 @@ type Storer interface {
 	// Close 关闭存储
 	Close() error
 }
+
+// OnceStorer 可选的存储接口，用于一次性令牌的原子消费.
+// 未实现该接口的 Storer 会退化为 Check + Set，在并发消费时无法保证只成功一次.
+type OnceStorer interface {
+	// SetNX 仅当令牌数据不存在时存储，返回是否写入成功
+	SetNX(ctx context.Context, assessToken string, expirationTime time.Duration) (bool, error)
+}
+
+// BatchStorer 可选的存储接口，用于批量撤销与检查令牌.
+// 未实现该接口的 Storer 会退化为逐个调用 Set 与 Check.
+type BatchStorer interface {
+	// SetMulti 批量存储令牌数据，键为令牌，值为过期时间
+	SetMulti(ctx context.Context, entries map[string]time.Duration) error
+	// CheckMulti 批量检查令牌是否存在，结果与 accessTokens 一一对应
+	CheckMulti(ctx context.Context, accessTokens []string) ([]bool, error)
+}
+
+// ExportStorer 可选的存储接口，用于导出存储中的令牌数据.
+type ExportStorer interface {
+	// Export 遍历存储中所有未过期的令牌数据，ttl 为剩余的过期时间
+	Export(ctx context.Context, fn func(accessToken string, ttl time.Duration) error) error
+}
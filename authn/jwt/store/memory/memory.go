@@ -0,0 +1,262 @@
+// Package memory 提供单进程的内存令牌存储，适用于测试与单实例部署.
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/LiangNing7/onex/pkg/authn"
+	"github.com/LiangNing7/onex/pkg/authn/jwt"
+)
+
+// sweepInterval 每写入多少次清理一次过期的数据
+const sweepInterval = 1024
+
+// 定义 Store 的配置
+type options struct {
+	clock authn.Clock // 时钟
+}
+
+// Option 定义配置函数，用于选项模式
+type Option func(*options)
+
+// WithClock 设置判断数据是否过期使用的时钟（默认 authn.SystemClock）。
+func WithClock(clock authn.Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// Store 用于实现 jwt.Storer、jwt.OnceStorer、jwt.BatchStorer、jwt.ExportStorer 与 jwt.SessionStorer 接口.
+type Store struct {
+	clock authn.Clock
+
+	mu       sync.Mutex
+	tokens   map[string]time.Time               // 令牌到过期时间的映射
+	sessions map[string]map[string]*jwt.Session // 主体到会话的映射
+	writes   int                                // 写入次数，用于定期清理
+}
+
+var (
+	_ jwt.Storer        = (*Store)(nil)
+	_ jwt.OnceStorer    = (*Store)(nil)
+	_ jwt.BatchStorer   = (*Store)(nil)
+	_ jwt.ExportStorer  = (*Store)(nil)
+	_ jwt.SessionStorer = (*Store)(nil)
+)
+
+// NewStore 创建一个新的 Store 实例
+func NewStore(opts ...Option) *Store {
+	o := options{clock: authn.SystemClock}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Store{
+		clock:    o.clock,
+		tokens:   make(map[string]time.Time),
+		sessions: make(map[string]map[string]*jwt.Session),
+	}
+}
+
+// Set 存储令牌数据并指定过期时间
+func (s *Store) Set(_ context.Context, accessToken string, expiration time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.set(accessToken, expiration)
+	return nil
+}
+
+// SetNX 仅当令牌数据不存在时存储，返回是否写入成功
+func (s *Store) SetNX(_ context.Context, accessToken string, expiration time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.check(accessToken) {
+		return false, nil
+	}
+	s.set(accessToken, expiration)
+	return true, nil
+}
+
+// SetMulti 批量存储令牌数据
+func (s *Store) SetMulti(_ context.Context, entries map[string]time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for accessToken, expiration := range entries {
+		s.set(accessToken, expiration)
+	}
+	return nil
+}
+
+// Delete 从存储中删除令牌数据
+func (s *Store) Delete(_ context.Context, accessToken string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	exists := s.check(accessToken)
+	delete(s.tokens, accessToken)
+	return exists, nil
+}
+
+// Check 检查令牌是否存在
+func (s *Store) Check(_ context.Context, accessToken string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.check(accessToken), nil
+}
+
+// CheckMulti 批量检查令牌是否存在
+func (s *Store) CheckMulti(_ context.Context, accessTokens []string) ([]bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	exists := make([]bool, len(accessTokens))
+	for i, accessToken := range accessTokens {
+		exists[i] = s.check(accessToken)
+	}
+	return exists, nil
+}
+
+// Export 遍历所有未过期的令牌数据
+func (s *Store) Export(_ context.Context, fn func(accessToken string, ttl time.Duration) error) error {
+	s.mu.Lock()
+	now := s.clock.Now()
+	ttls := make(map[string]time.Duration, len(s.tokens))
+	for accessToken, expiresAt := range s.tokens {
+		if ttl := expiresAt.Sub(now); ttl > 0 {
+			ttls[accessToken] = ttl
+		}
+	}
+	s.mu.Unlock()
+
+	// 回调中可能再次访问 Store，因此不持有锁
+	for accessToken, ttl := range ttls {
+		if err := fn(accessToken, ttl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveSession 保存会话，会话在 ExpiresAt 之后过期
+func (s *Store) SaveSession(_ context.Context, session *jwt.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions, ok := s.sessions[session.Subject]
+	if !ok {
+		sessions = make(map[string]*jwt.Session)
+		s.sessions[session.Subject] = sessions
+	}
+	saved := *session
+	sessions[session.ID] = &saved
+	return nil
+}
+
+// GetSession 获取会话，会话不存在或已过期时返回 nil
+func (s *Store) GetSession(_ context.Context, subject, id string) (*jwt.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[subject][id]
+	if !ok || !s.clock.Now().Before(session.ExpiresAt) {
+		return nil, nil
+	}
+	found := *session
+	return &found, nil
+}
+
+// TouchSession 仅当会话存在且未过期时更新最后活跃时间，expiresAt 不为零值时同时更新过期时间
+func (s *Store) TouchSession(_ context.Context, subject, id string, lastSeen, expiresAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[subject][id]
+	if !ok || !s.clock.Now().Before(session.ExpiresAt) {
+		return false, nil
+	}
+	session.LastSeen = lastSeen
+	if !expiresAt.IsZero() {
+		session.ExpiresAt = expiresAt
+	}
+	return true, nil
+}
+
+// ListSessions 列出主体所有未过期的会话，并删除已过期的会话
+func (s *Store) ListSessions(_ context.Context, subject string) ([]*jwt.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	var list []*jwt.Session
+	for id, session := range s.sessions[subject] {
+		if !now.Before(session.ExpiresAt) {
+			delete(s.sessions[subject], id)
+			continue
+		}
+		found := *session
+		list = append(list, &found)
+	}
+	return list, nil
+}
+
+// DeleteSession 删除会话
+func (s *Store) DeleteSession(_ context.Context, subject, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.sessions[subject][id]
+	delete(s.sessions[subject], id)
+	if len(s.sessions[subject]) == 0 {
+		delete(s.sessions, subject)
+	}
+	return ok, nil
+}
+
+// Close 清空存储
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	clear(s.tokens)
+	clear(s.sessions)
+	return nil
+}
+
+// set 写入令牌数据，并定期清理过期的数据，调用方需要持有锁
+func (s *Store) set(accessToken string, expiration time.Duration) {
+	s.tokens[accessToken] = s.clock.Now().Add(expiration)
+	if s.writes++; s.writes%sweepInterval == 0 {
+		s.sweep()
+	}
+}
+
+// check 判断令牌是否存在且未过期，调用方需要持有锁
+func (s *Store) check(accessToken string) bool {
+	expiresAt, ok := s.tokens[accessToken]
+	return ok && s.clock.Now().Before(expiresAt)
+}
+
+// sweep 清理过期的令牌与会话，调用方需要持有锁
+func (s *Store) sweep() {
+	now := s.clock.Now()
+	for accessToken, expiresAt := range s.tokens {
+		if !now.Before(expiresAt) {
+			delete(s.tokens, accessToken)
+		}
+	}
+	for subject, sessions := range s.sessions {
+		for id, session := range sessions {
+			if !now.Before(session.ExpiresAt) {
+				delete(sessions, id)
+			}
+		}
+		if len(sessions) == 0 {
+			delete(s.sessions, subject)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/LiangNing7/onex/pkg/authn/jwt"
+)
+
+// testClock 可以拨动的时钟，jwttest 依赖本包，因此不能使用 jwttest.Clock
+type testClock struct{ now time.Time }
+
+func (c *testClock) Now() time.Time { return c.now }
+
+func newTestStore() (*Store, *testClock) {
+	clock := &testClock{now: time.Unix(1700000000, 0)}
+	return NewStore(WithClock(clock)), clock
+}
+
+func TestStoreTokens(t *testing.T) {
+	ctx := context.Background()
+	s, clock := newTestStore()
+
+	if err := s.Set(ctx, "a", time.Minute); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := s.SetMulti(ctx, map[string]time.Duration{"b": time.Hour, "c": time.Second}); err != nil {
+		t.Fatalf("SetMulti() error = %v", err)
+	}
+	exists, err := s.CheckMulti(ctx, []string{"a", "b", "c", "d"})
+	if err != nil || fmt.Sprint(exists) != "[true true true false]" {
+		t.Fatalf("CheckMulti() = %v, %v", exists, err)
+	}
+
+	// 过期后不再存在
+	clock.now = clock.now.Add(time.Minute)
+	for token, want := range map[string]bool{"a": false, "b": true, "c": false} {
+		if ok, err := s.Check(ctx, token); err != nil || ok != want {
+			t.Fatalf("Check(%s) = %v, %v, want %v", token, ok, err, want)
+		}
+	}
+
+	// 已过期的令牌可以重新写入
+	if ok, err := s.SetNX(ctx, "a", time.Minute); err != nil || !ok {
+		t.Fatalf("SetNX() on an expired token = %v, %v, want true", ok, err)
+	}
+	if ok, err := s.SetNX(ctx, "a", time.Minute); err != nil || ok {
+		t.Fatalf("SetNX() on an existing token = %v, %v, want false", ok, err)
+	}
+
+	if ok, err := s.Delete(ctx, "a"); err != nil || !ok {
+		t.Fatalf("Delete() = %v, %v, want true", ok, err)
+	}
+	if ok, err := s.Delete(ctx, "c"); err != nil || ok {
+		t.Fatalf("Delete() of an expired token = %v, %v, want false", ok, err)
+	}
+	if ok, _ := s.Check(ctx, "a"); ok {
+		t.Fatal("deleted token still exists")
+	}
+}
+
+func TestStoreExport(t *testing.T) {
+	ctx := context.Background()
+	s, clock := newTestStore()
+	_ = s.SetMulti(ctx, map[string]time.Duration{"a": time.Minute, "b": time.Hour})
+	clock.now = clock.now.Add(time.Minute)
+
+	// 只导出未过期的令牌，ttl 为剩余时间；回调中可以再次访问 Store
+	exported := make(map[string]time.Duration)
+	err := s.Export(ctx, func(accessToken string, ttl time.Duration) error {
+		exported[accessToken] = ttl
+		_, err := s.Check(ctx, accessToken)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if len(exported) != 1 || exported["b"] != time.Hour-time.Minute {
+		t.Fatalf("Export() = %v, want b with 59m ttl", exported)
+	}
+
+	errStop := fmt.Errorf("stop")
+	if err := s.Export(ctx, func(string, time.Duration) error { return errStop }); err != errStop {
+		t.Fatalf("Export() error = %v, want %v", err, errStop)
+	}
+}
+
+func TestStoreSessions(t *testing.T) {
+	ctx := context.Background()
+	s, clock := newTestStore()
+	now := clock.Now()
+
+	for _, session := range []*jwt.Session{
+		{ID: "s1", Subject: "alice", CreatedAt: now, LastSeen: now, ExpiresAt: now.Add(time.Hour)},
+		{ID: "s2", Subject: "alice", CreatedAt: now, LastSeen: now, ExpiresAt: now.Add(time.Minute)},
+		{ID: "s3", Subject: "bob", CreatedAt: now, LastSeen: now, ExpiresAt: now.Add(time.Hour)},
+	} {
+		if err := s.SaveSession(ctx, session); err != nil {
+			t.Fatalf("SaveSession() error = %v", err)
+		}
+	}
+
+	// 返回的会话是副本
+	got, err := s.GetSession(ctx, "alice", "s1")
+	if err != nil || got == nil {
+		t.Fatalf("GetSession() = %v, %v", got, err)
+	}
+	got.Device = "changed"
+	if again, _ := s.GetSession(ctx, "alice", "s1"); again.Device == "changed" {
+		t.Fatal("GetSession() returned the stored session")
+	}
+
+	lastSeen := now.Add(30 * time.Second)
+	if ok, err := s.TouchSession(ctx, "alice", "s1", lastSeen, now.Add(2*time.Hour)); err != nil || !ok {
+		t.Fatalf("TouchSession() = %v, %v, want true", ok, err)
+	}
+	if got, _ := s.GetSession(ctx, "alice", "s1"); !got.LastSeen.Equal(lastSeen) || !got.ExpiresAt.Equal(now.Add(2*time.Hour)) {
+		t.Fatalf("touched session = %+v", got)
+	}
+	if ok, _ := s.TouchSession(ctx, "alice", "missing", lastSeen, time.Time{}); ok {
+		t.Fatal("TouchSession() created a missing session")
+	}
+
+	// 过期的会话不再返回，也不能更新
+	clock.now = now.Add(time.Minute)
+	if got, _ := s.GetSession(ctx, "alice", "s2"); got != nil {
+		t.Fatalf("GetSession() of an expired session = %+v", got)
+	}
+	if ok, _ := s.TouchSession(ctx, "alice", "s2", clock.now, time.Time{}); ok {
+		t.Fatal("TouchSession() updated an expired session")
+	}
+	list, err := s.ListSessions(ctx, "alice")
+	if err != nil || len(list) != 1 || list[0].ID != "s1" {
+		t.Fatalf("ListSessions() = %v, %v, want s1", list, err)
+	}
+
+	if ok, _ := s.DeleteSession(ctx, "alice", "s1"); !ok {
+		t.Fatal("DeleteSession() = false, want true")
+	}
+	if ok, _ := s.DeleteSession(ctx, "alice", "s1"); ok {
+		t.Fatal("DeleteSession() of a deleted session = true")
+	}
+	if _, ok := s.sessions["alice"]; ok {
+		t.Fatal("empty subject was not removed")
+	}
+	if list, _ := s.ListSessions(ctx, "bob"); len(list) != 1 {
+		t.Fatalf("ListSessions(bob) = %v, want one session", list)
+	}
+}
+
+func TestStoreSweep(t *testing.T) {
+	ctx := context.Background()
+	s, clock := newTestStore()
+	now := clock.Now()
+
+	_ = s.Set(ctx, "live", time.Hour)
+	_ = s.SaveSession(ctx, &jwt.Session{ID: "old", Subject: "alice", ExpiresAt: now.Add(time.Second)})
+	_ = s.SaveSession(ctx, &jwt.Session{ID: "live", Subject: "bob", ExpiresAt: now.Add(time.Hour)})
+	for i := 1; i < sweepInterval-1; i++ {
+		_ = s.Set(ctx, fmt.Sprintf("old:%d", i), time.Second)
+	}
+	clock.now = now.Add(time.Minute)
+	// 第 sweepInterval 次写入时清理过期的数据
+	_ = s.Set(ctx, "new", time.Hour)
+
+	if len(s.tokens) != 2 {
+		t.Fatalf("%d tokens left, want 2", len(s.tokens))
+	}
+	if _, ok := s.sessions["alice"]; ok || len(s.sessions) != 1 {
+		t.Fatalf("sessions = %v, want only bob", s.sessions)
+	}
+
+	if err := s.Close(); err != nil || len(s.tokens) != 0 || len(s.sessions) != 0 {
+		t.Fatalf("Close() = %v, store not cleared", err)
+	}
+}
@@ -0,0 +1,144 @@
+package redis
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// invalidateChannel 令牌被撤销时发布失效通知的频道，格式为 <prefix>revoked
+const invalidateChannel = "revoked"
+
+// localCache 有界的本地 LRU 缓存，只缓存 "令牌未被撤销" 的检查结果.
+type localCache struct {
+	size int           // 最大条目数
+	ttl  time.Duration // 条目的有效期
+
+	mu    sync.Mutex
+	ll    *list.List               // 按最近使用排序，队首为最近使用
+	items map[string]*list.Element // 令牌到链表节点的映射
+	gen   uint64                   // 每次失效都会递增，用于丢弃失效之前发起的查询结果
+}
+
+// cacheEntry 缓存条目
+type cacheEntry struct {
+	key       string
+	expiresAt time.Time
+}
+
+// newLocalCache 创建一个新的 localCache 实例
+func newLocalCache(size int, ttl time.Duration) *localCache {
+	return &localCache{size: size, ttl: ttl, ll: list.New(), items: make(map[string]*list.Element, size)}
+}
+
+// contains 判断令牌是否在缓存中且未过期
+func (c *localCache) contains(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.items[key]
+	if !ok {
+		return false
+	}
+	if time.Now().After(e.Value.(*cacheEntry).expiresAt) {
+		c.removeElement(e)
+		return false
+	}
+	c.ll.MoveToFront(e)
+	return true
+}
+
+// generation 返回当前的失效代数，在查询 Redis 之前获取
+func (c *localCache) generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen
+}
+
+// add 缓存令牌未被撤销的结果，超出容量时淘汰最久未使用的条目.
+// 查询期间发生过失效时不缓存，避免覆盖更新的撤销结果.
+func (c *localCache) add(key string, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen != c.gen {
+		return
+	}
+
+	expiresAt := time.Now().Add(c.ttl)
+	if e, ok := c.items[key]; ok {
+		e.Value.(*cacheEntry).expiresAt = expiresAt
+		c.ll.MoveToFront(e)
+		return
+	}
+	c.items[key] = c.ll.PushFront(&cacheEntry{key: key, expiresAt: expiresAt})
+	if c.ll.Len() > c.size {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+// remove 删除令牌的缓存
+func (c *localCache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	if e, ok := c.items[key]; ok {
+		c.removeElement(e)
+	}
+}
+
+// purge 清空缓存
+func (c *localCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	c.ll.Init()
+	clear(c.items)
+}
+
+// removeElement 删除链表节点及其映射
+func (c *localCache) removeElement(e *list.Element) {
+	c.ll.Remove(e)
+	delete(c.items, e.Value.(*cacheEntry).key)
+}
+
+// subscribe 订阅失效通知并删除对应的缓存，直到 ctx 被取消.
+// 订阅建立或断线重连时可能错过通知，因此会清空缓存.
+func (s *Store) subscribe(ctx context.Context) {
+	defer close(s.done)
+	for {
+		msg, err := s.pubsub.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			s.cache.purge()
+			select {
+			case <-time.After(time.Second):
+			case <-ctx.Done():
+				return
+			}
+			continue
+		}
+		switch msg := msg.(type) {
+		case *redis.Subscription:
+			s.cache.purge()
+		case *redis.Message:
+			s.cache.remove(msg.Payload)
+		}
+	}
+}
+
+// publish 在 pipeline 中发布令牌被撤销的通知，并删除本地缓存
+func (s *Store) publish(ctx context.Context, pipe redis.Pipeliner, accessToken string) {
+	if s.cache == nil {
+		return
+	}
+	s.cache.remove(accessToken)
+	pipe.Publish(ctx, s.prefix+invalidateChannel, accessToken)
+}
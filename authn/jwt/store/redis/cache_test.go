@@ -0,0 +1,142 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// waitFor 轮询 cond 直到返回 true 或超时
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) bool {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return true
+}
+
+// newCachedStore 创建启用本地缓存的 Store，并等待订阅建立时的清空完成
+func newCachedStore(t *testing.T, m *miniredis.Miniredis, size int, ttl time.Duration) *Store {
+	t.Helper()
+	s := NewStore(Config{Addr: m.Addr(), KeyPrefix: "jwt:"}, WithLocalCache(size, ttl))
+	t.Cleanup(func() { _ = s.Close() })
+	if !waitFor(t, time.Second, func() bool { return s.cache.generation() > 0 }) {
+		t.Fatal("store did not subscribe")
+	}
+	return s
+}
+
+func mustCheck(t *testing.T, s *Store, accessToken string) bool {
+	t.Helper()
+	revoked, err := s.Check(context.Background(), accessToken)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	return revoked
+}
+
+func TestLocalCacheHit(t *testing.T) {
+	m := miniredis.RunT(t)
+	s := newCachedStore(t, m, 10, time.Hour)
+
+	if mustCheck(t, s, "token") {
+		t.Fatal("Check() = true, want false")
+	}
+	// 缓存命中时不访问 Redis，直接写入的撤销记录不可见
+	m.Set("jwt:token", "1")
+	if mustCheck(t, s, "token") {
+		t.Fatal("Check() = true, want cached false")
+	}
+	revoked, err := s.CheckMulti(context.Background(), []string{"token", "other"})
+	if err != nil || revoked[0] || revoked[1] {
+		t.Fatalf("CheckMulti() = %v, %v, want [false false]", revoked, err)
+	}
+
+}
+
+func TestLocalCacheExpiry(t *testing.T) {
+	const ttl = 20 * time.Millisecond
+	m := miniredis.RunT(t)
+	s := newCachedStore(t, m, 10, ttl)
+
+	if mustCheck(t, s, "token") {
+		t.Fatal("Check() = true, want false")
+	}
+	// 错过失效通知时，缓存过期后重新查询 Redis
+	m.Set("jwt:token", "1")
+	time.Sleep(2 * ttl)
+	if !mustCheck(t, s, "token") {
+		t.Fatal("Check() = false after cache expiry, want true")
+	}
+}
+
+func TestLocalCacheGeneration(t *testing.T) {
+	s := newCachedStore(t, miniredis.RunT(t), 10, time.Hour)
+
+	// 查询期间发生失效时不缓存查询结果
+	gen := s.cache.generation()
+	s.cache.remove("token")
+	s.cache.add("token", gen)
+	if s.cache.contains("token") {
+		t.Fatal("result queried before invalidation was cached")
+	}
+	s.cache.add("token", s.cache.generation())
+	if !s.cache.contains("token") {
+		t.Fatal("result was not cached")
+	}
+}
+
+func TestLocalCacheEviction(t *testing.T) {
+	s := newCachedStore(t, miniredis.RunT(t), 2, time.Hour)
+
+	for _, token := range []string{"a", "b", "a", "c"} {
+		mustCheck(t, s, token)
+	}
+	// 超出容量时淘汰最久未使用的 b
+	if !s.cache.contains("a") || s.cache.contains("b") || !s.cache.contains("c") {
+		t.Fatal("cache did not evict the least recently used token")
+	}
+}
+
+func TestLocalCacheInvalidation(t *testing.T) {
+	m := miniredis.RunT(t)
+	ctx := context.Background()
+	a, b := newCachedStore(t, m, 10, time.Hour), newCachedStore(t, m, 10, time.Hour)
+
+	tests := []struct {
+		name   string
+		revoke func(token string) error
+	}{
+		{"Set", func(token string) error { return a.Set(ctx, token, time.Hour) }},
+		{"SetNX", func(token string) error { _, err := a.SetNX(ctx, token, time.Hour); return err }},
+		{"SetMulti", func(token string) error { return a.SetMulti(ctx, map[string]time.Duration{token: time.Hour}) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := "token-" + tt.name
+			if mustCheck(t, a, token) || mustCheck(t, b, token) {
+				t.Fatal("Check() = true before revocation")
+			}
+			if err := tt.revoke(token); err != nil {
+				t.Fatalf("revoke error = %v", err)
+			}
+			// 撤销的实例立即删除缓存，其它实例收到通知后删除缓存
+			if !mustCheck(t, a, token) {
+				t.Fatal("revoking store still reports the token as valid")
+			}
+			if !waitFor(t, time.Second, func() bool { return mustCheck(t, b, token) }) {
+				t.Fatal("other store did not receive the invalidation")
+			}
+			revoked, err := b.CheckMulti(ctx, []string{token})
+			if err != nil || !revoked[0] {
+				t.Fatalf("CheckMulti() = %v, %v, want [true]", revoked, err)
+			}
+		})
+	}
+}
@@ -2,11 +2,37 @@ package redis
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/LiangNing7/onex/pkg/authn/jwt"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+	"strings"
 	"time"
 )
 
+// instrumentationName 是 tracer 的名称
+const instrumentationName = "github.com/LiangNing7/onex/pkg/authn/jwt/store/redis"
+
+// exportBatchSize 导出时每次 SCAN 与读取的键数量
+const exportBatchSize = 500
+
+// ErrExportPrefixRequired 表示导出需要设置 KeyPrefix
+var ErrExportPrefixRequired = errors.New("redis store export requires a key prefix")
+
+// extendScript 仅当键的剩余过期时间短于 ARGV[1] 毫秒时延长过期时间，与 EXPIRE GT/NX 相同但不需要 Redis 7
+var extendScript = redis.NewScript(`
+local ttl = redis.call("pttl", KEYS[1])
+if ttl == -2 or (ttl ~= -1 and ttl >= tonumber(ARGV[1])) then
+	return 0
+end
+return redis.call("pexpire", KEYS[1], ARGV[1])
+`)
+
 // Config 包含了必要的 Redis 配置选项
 type Config struct {
 	Addr      string // 地址
@@ -16,21 +42,70 @@ type Config struct {
 	KeyPrefix string // 存储键的前缀
 }
 
+// 定义 Store 的配置
+type options struct {
+	tracerProvider trace.TracerProvider // 链路追踪
+	cacheSize      int                  // 本地缓存的最大条目数，为 0 时不使用本地缓存
+	cacheTTL       time.Duration        // 本地缓存条目的有效期
+}
+
+// Option 定义配置函数，用于选项模式
+type Option func(*options)
+
+// WithTracerProvider 设置用于记录链路追踪的 TracerProvider（默认不记录）。
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(o *options) {
+		o.tracerProvider = tp
+	}
+}
+
+// WithLocalCache 设置本地缓存，Check 结果为 "未撤销" 时在本地缓存 ttl，最多 size 条（默认不使用）。
+// 任意实例撤销令牌时通过 Redis pub/sub 通知所有实例删除缓存，ttl 是错过通知时的最长延迟。
+// Check 和 CheckMulti 使用本地缓存，会话的读写始终访问 Redis，注销会话立即对所有实例生效。
+func WithLocalCache(size int, ttl time.Duration) Option {
+	return func(o *options) {
+		o.cacheSize = size
+		o.cacheTTL = ttl
+	}
+}
+
 // Store 用于实现 store.Storer 接口
 type Store struct {
 	cli    *redis.Client // redis 客户端
 	prefix string        // 前缀
+	tracer trace.Tracer  // 链路追踪
+
+	cache  *localCache        // 本地缓存，为空时不使用
+	pubsub *redis.PubSub      // 失效通知的订阅
+	cancel context.CancelFunc // 停止订阅失效通知
+	done   chan struct{}      // 订阅协程退出时关闭
 }
 
 // NewStore 根据 Config 创建一个 *Store 实例
-func NewStore(cfg Config) *Store {
+func NewStore(cfg Config, opts ...Option) *Store {
+	o := options{tracerProvider: tracenoop.NewTracerProvider()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	cli := redis.NewClient(&redis.Options{
 		Addr:     cfg.Addr,
 		DB:       cfg.Database,
 		Username: cfg.Username,
 		Password: cfg.Password,
 	})
-	return &Store{cli: cli, prefix: cfg.KeyPrefix}
+	s := &Store{cli: cli, prefix: cfg.KeyPrefix, tracer: o.tracerProvider.Tracer(instrumentationName)}
+
+	// 启用本地缓存时订阅失效通知
+	if o.cacheSize > 0 && o.cacheTTL > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		s.cache = newLocalCache(o.cacheSize, o.cacheTTL)
+		s.cancel = cancel
+		s.pubsub = cli.Subscribe(ctx, s.prefix+invalidateChannel)
+		s.done = make(chan struct{})
+		go s.subscribe(ctx)
+	}
+	return s
 }
 
 // wrapperKey 用于构建 Redis 中的键名
@@ -38,15 +113,56 @@ func (s *Store) wrapperKey(key string) string {
 	return fmt.Sprintf("%s%s", s.prefix, key)
 }
 
+// start 为 Redis 命令开始一个新的 span
+func (s *Store) start(ctx context.Context, op string) (context.Context, trace.Span) {
+	return s.tracer.Start(ctx, "redis.Store."+op, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.system", "redis"), attribute.String("db.operation", op)))
+}
+
+// end 根据错误设置 span 的状态并结束 span
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
 // Set 调用 Redis 设置具有过期时间的键值对
 // 键的格式为 <prefix><accessToken>
-func (s *Store) Set(ctx context.Context, accessToken string, expiration time.Duration) error {
-	cmd := s.cli.Set(ctx, s.wrapperKey(accessToken), "1", expiration)
-	return cmd.Err()
+func (s *Store) Set(ctx context.Context, accessToken string, expiration time.Duration) (err error) {
+	ctx, span := s.start(ctx, "Set")
+	defer func() { end(span, err) }()
+
+	_, err = s.cli.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, s.wrapperKey(accessToken), "1", expiration)
+		s.publish(ctx, pipe, accessToken)
+		return nil
+	})
+	return err
+}
+
+// SetNX 仅当键不存在时设置具有过期时间的键值对，返回是否设置成功
+func (s *Store) SetNX(ctx context.Context, accessToken string, expiration time.Duration) (_ bool, err error) {
+	ctx, span := s.start(ctx, "SetNX")
+	defer func() { end(span, err) }()
+
+	ok, err := s.cli.SetNX(ctx, s.wrapperKey(accessToken), "1", expiration).Result()
+	if err != nil || !ok || s.cache == nil {
+		return ok, err
+	}
+	_, err = s.cli.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		s.publish(ctx, pipe, accessToken)
+		return nil
+	})
+	return ok, err
 }
 
 // Delete 删除 Redis 中指定的 JWT 令牌
-func (s *Store) Delete(ctx context.Context, accessToken string) (bool, error) {
+func (s *Store) Delete(ctx context.Context, accessToken string) (_ bool, err error) {
+	ctx, span := s.start(ctx, "Delete")
+	defer func() { end(span, err) }()
+
 	cmd := s.cli.Del(ctx, s.wrapperKey(accessToken))
 	if err := cmd.Err(); err != nil {
 		return false, err
@@ -55,15 +171,301 @@ func (s *Store) Delete(ctx context.Context, accessToken string) (bool, error) {
 }
 
 // Check 检查 Redis 中指定的 JWT 令牌是否存在
-func (s *Store) Check(ctx context.Context, accessToken string) (bool, error) {
+func (s *Store) Check(ctx context.Context, accessToken string) (_ bool, err error) {
+	ctx, span := s.start(ctx, "Check")
+	defer func() { end(span, err) }()
+
+	// 本地缓存命中时说明令牌未被撤销
+	var gen uint64
+	if s.cache != nil {
+		if s.cache.contains(accessToken) {
+			span.SetAttributes(attribute.Bool("cache.hit", true))
+			return false, nil
+		}
+		gen = s.cache.generation()
+	}
+
 	cmd := s.cli.Exists(ctx, s.wrapperKey(accessToken))
 	if err := cmd.Err(); err != nil {
 		return false, err
 	}
-	return cmd.Val() > 0, nil
+	exists := cmd.Val() > 0
+	if !exists && s.cache != nil {
+		s.cache.add(accessToken, gen)
+	}
+	return exists, nil
+}
+
+// SetMulti 使用 pipeline 批量设置具有过期时间的键值对
+func (s *Store) SetMulti(ctx context.Context, entries map[string]time.Duration) (err error) {
+	ctx, span := s.start(ctx, "SetMulti")
+	defer func() { end(span, err) }()
+	span.SetAttributes(attribute.Int("db.batch.size", len(entries)))
+
+	_, err = s.cli.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for accessToken, expiration := range entries {
+			pipe.Set(ctx, s.wrapperKey(accessToken), "1", expiration)
+			s.publish(ctx, pipe, accessToken)
+		}
+		return nil
+	})
+	return err
 }
 
-// Close 用于关闭 Redis client
+// CheckMulti 使用 pipeline 批量检查令牌是否存在，本地缓存命中的令牌不再查询 Redis
+func (s *Store) CheckMulti(ctx context.Context, accessTokens []string) (_ []bool, err error) {
+	ctx, span := s.start(ctx, "CheckMulti")
+	defer func() { end(span, err) }()
+	span.SetAttributes(attribute.Int("db.batch.size", len(accessTokens)))
+
+	exists := make([]bool, len(accessTokens))
+	misses := make([]int, 0, len(accessTokens))
+	var gen uint64
+	if s.cache != nil {
+		gen = s.cache.generation()
+	}
+	for i, accessToken := range accessTokens {
+		if s.cache == nil || !s.cache.contains(accessToken) {
+			misses = append(misses, i)
+		}
+	}
+	span.SetAttributes(attribute.Int("cache.hits", len(accessTokens)-len(misses)))
+	if len(misses) == 0 {
+		return exists, nil
+	}
+
+	cmds := make([]*redis.IntCmd, len(misses))
+	_, err = s.cli.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for j, i := range misses {
+			cmds[j] = pipe.Exists(ctx, s.wrapperKey(accessTokens[i]))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range misses {
+		exists[i] = cmds[j].Val() > 0
+		if !exists[i] && s.cache != nil {
+			s.cache.add(accessTokens[i], gen)
+		}
+	}
+	return exists, nil
+}
+
+// Export 使用 SCAN 遍历 <prefix> 下所有的令牌数据，会话数据不会被导出.
+// 没有设置 KeyPrefix 时无法区分令牌数据与其它数据，返回 ErrExportPrefixRequired
+func (s *Store) Export(ctx context.Context, fn func(accessToken string, ttl time.Duration) error) (err error) {
+	ctx, span := s.start(ctx, "Export")
+	defer func() { end(span, err) }()
+
+	if s.prefix == "" {
+		return ErrExportPrefixRequired
+	}
+	iter := s.cli.Scan(ctx, 0, escapeGlob(s.prefix)+"*", exportBatchSize).Iterator()
+	keys := make([]string, 0, exportBatchSize)
+	for iter.Next(ctx) {
+		key := iter.Val()
+		if strings.HasPrefix(key, s.prefix+"session:") || strings.HasPrefix(key, s.prefix+"sessions:") {
+			continue
+		}
+		if keys = append(keys, key); len(keys) == exportBatchSize {
+			if err := s.export(ctx, keys, fn); err != nil {
+				return err
+			}
+			keys = keys[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	return s.export(ctx, keys, fn)
+}
+
+// escapeGlob 转义 SCAN MATCH 模式中的通配符，使前缀按字面匹配
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// export 读取一批键的值与剩余过期时间，只导出值为 "1" 且设置了过期时间的令牌数据
+func (s *Store) export(ctx context.Context, keys []string, fn func(accessToken string, ttl time.Duration) error) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	vals := make([]*redis.StringCmd, len(keys))
+	ttls := make([]*redis.DurationCmd, len(keys))
+	_, err := s.cli.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			vals[i] = pipe.Get(ctx, key)
+			ttls[i] = pipe.PTTL(ctx, key)
+		}
+		return nil
+	})
+	// 遍历期间过期的键返回 redis.Nil，其他类型的键返回 WRONGTYPE，忽略即可
+	var rerr redis.Error
+	if err != nil && !errors.As(err, &rerr) {
+		return err
+	}
+	for i, key := range keys {
+		if vals[i].Val() != "1" || ttls[i].Val() <= 0 {
+			continue
+		}
+		if err := fn(strings.TrimPrefix(key, s.prefix), ttls[i].Val()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sessionKey 会话的键名，格式为 <prefix>session:<subject>:<id>
+func (s *Store) sessionKey(subject, id string) string {
+	return fmt.Sprintf("%ssession:%s:%s", s.prefix, subject, id)
+}
+
+// sessionsKey 主体会话索引的键名，格式为 <prefix>sessions:<subject>，类型为集合
+func (s *Store) sessionsKey(subject string) string {
+	return fmt.Sprintf("%ssessions:%s", s.prefix, subject)
+}
+
+// SaveSession 保存会话并加入主体的会话索引，会话在 ExpiresAt 之后过期
+func (s *Store) SaveSession(ctx context.Context, session *jwt.Session) (err error) {
+	ctx, span := s.start(ctx, "SaveSession")
+	defer func() { end(span, err) }()
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	ttl := time.Until(session.ExpiresAt)
+	index := s.sessionsKey(session.Subject)
+	_, err = s.cli.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, s.sessionKey(session.Subject, session.ID), data, ttl)
+		pipe.SAdd(ctx, index, session.ID)
+		// 索引的过期时间不短于其中任何一个会话
+		extendScript.Eval(ctx, pipe, []string{index}, ttl.Milliseconds())
+		return nil
+	})
+	return err
+}
+
+// TouchSession 仅当会话存在时更新最后活跃时间，expiresAt 不为零值时同时更新过期时间.
+// 使用 SET XX 写入，会话在读取之后被删除时不会重新创建
+func (s *Store) TouchSession(ctx context.Context, subject, id string, lastSeen, expiresAt time.Time) (_ bool, err error) {
+	ctx, span := s.start(ctx, "TouchSession")
+	defer func() { end(span, err) }()
+
+	session, err := s.GetSession(ctx, subject, id)
+	if err != nil || session == nil {
+		return false, err
+	}
+	session.LastSeen = lastSeen
+	if !expiresAt.IsZero() {
+		session.ExpiresAt = expiresAt
+	}
+	data, err := json.Marshal(session)
+	if err != nil {
+		return false, err
+	}
+	ttl := time.Until(session.ExpiresAt)
+	ok, err := s.cli.SetXX(ctx, s.sessionKey(subject, id), data, ttl).Result()
+	if err != nil || !ok || expiresAt.IsZero() {
+		return ok, err
+	}
+	return true, extendScript.Run(ctx, s.cli, []string{s.sessionsKey(subject)}, ttl.Milliseconds()).Err()
+}
+
+// GetSession 获取会话，会话不存在时返回 nil
+func (s *Store) GetSession(ctx context.Context, subject, id string) (_ *jwt.Session, err error) {
+	ctx, span := s.start(ctx, "GetSession")
+	defer func() { end(span, err) }()
+
+	data, err := s.cli.Get(ctx, s.sessionKey(subject, id)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	session := &jwt.Session{}
+	if err := json.Unmarshal(data, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// ListSessions 列出主体所有未过期的会话，并从索引中清理已过期的会话
+func (s *Store) ListSessions(ctx context.Context, subject string) (_ []*jwt.Session, err error) {
+	ctx, span := s.start(ctx, "ListSessions")
+	defer func() { end(span, err) }()
+
+	index := s.sessionsKey(subject)
+	ids, err := s.cli.SMembers(ctx, index).Result()
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = s.sessionKey(subject, id)
+	}
+	vals, err := s.cli.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*jwt.Session, 0, len(vals))
+	var expired []any
+	for i, val := range vals {
+		data, ok := val.(string)
+		if !ok {
+			expired = append(expired, ids[i])
+			continue
+		}
+		session := &jwt.Session{}
+		if err := json.Unmarshal([]byte(data), session); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	if len(expired) > 0 {
+		if err := s.cli.SRem(ctx, index, expired...).Err(); err != nil {
+			return nil, err
+		}
+	}
+	return sessions, nil
+}
+
+// DeleteSession 删除会话并从主体的会话索引中移除
+func (s *Store) DeleteSession(ctx context.Context, subject, id string) (_ bool, err error) {
+	ctx, span := s.start(ctx, "DeleteSession")
+	defer func() { end(span, err) }()
+
+	var del *redis.IntCmd
+	_, err = s.cli.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		del = pipe.Del(ctx, s.sessionKey(subject, id))
+		pipe.SRem(ctx, s.sessionsKey(subject), id)
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return del.Val() > 0, nil
+}
+
+// Close 停止订阅失效通知并关闭 Redis client
 func (s *Store) Close() error {
+	if s.cancel != nil {
+		s.cancel()
+		// 关闭订阅以中断阻塞中的 Receive
+		s.pubsub.Close()
+		<-s.done
+	}
 	return s.cli.Close()
 }
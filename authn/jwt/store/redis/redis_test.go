@@ -0,0 +1,155 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/LiangNing7/onex/pkg/authn/jwt"
+	"github.com/alicebob/miniredis/v2"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestStore(t *testing.T, opts ...Option) (*miniredis.Miniredis, *Store) {
+	t.Helper()
+	m := miniredis.RunT(t)
+	s := NewStore(Config{Addr: m.Addr(), KeyPrefix: "jwt:"}, opts...)
+	t.Cleanup(func() { _ = s.Close() })
+	return m, s
+}
+
+func TestStoreTouchSession(t *testing.T) {
+	m, s := newTestStore(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	session := &jwt.Session{ID: "s1", Subject: "alice", CreatedAt: now, LastSeen: now, ExpiresAt: now.Add(time.Hour)}
+	if err := s.SaveSession(ctx, session); err != nil {
+		t.Fatalf("SaveSession() error = %v", err)
+	}
+	if ttl := m.TTL("jwt:sessions:alice"); ttl <= 0 || ttl > time.Hour {
+		t.Fatalf("index ttl = %v, want within an hour", ttl)
+	}
+
+	// 更新最后活跃时间，过期时间不变
+	lastSeen := now.Add(time.Minute)
+	ok, err := s.TouchSession(ctx, "alice", "s1", lastSeen, time.Time{})
+	if err != nil || !ok {
+		t.Fatalf("TouchSession() = %v, %v, want true", ok, err)
+	}
+	got, err := s.GetSession(ctx, "alice", "s1")
+	if err != nil || got == nil {
+		t.Fatalf("GetSession() = %v, %v", got, err)
+	}
+	if !got.LastSeen.Equal(lastSeen) || !got.ExpiresAt.Equal(session.ExpiresAt) {
+		t.Fatalf("session = %+v, want last seen %v and unchanged expiry", got, lastSeen)
+	}
+
+	// 延长过期时间时同时延长索引
+	if ok, err := s.TouchSession(ctx, "alice", "s1", lastSeen, now.Add(2*time.Hour)); err != nil || !ok {
+		t.Fatalf("TouchSession() = %v, %v, want true", ok, err)
+	}
+	if ttl := m.TTL("jwt:sessions:alice"); ttl <= time.Hour {
+		t.Fatalf("index ttl = %v, want extended beyond an hour", ttl)
+	}
+
+	// 已删除的会话不会被重新创建
+	if _, err := s.DeleteSession(ctx, "alice", "s1"); err != nil {
+		t.Fatalf("DeleteSession() error = %v", err)
+	}
+	if ok, err := s.TouchSession(ctx, "alice", "s1", lastSeen, time.Time{}); err != nil || ok {
+		t.Fatalf("TouchSession() after delete = %v, %v, want false", ok, err)
+	}
+	if m.Exists("jwt:session:alice:s1") {
+		t.Fatal("deleted session was recreated")
+	}
+}
+
+func TestStoreSessionIndexExpiry(t *testing.T) {
+	m, s := newTestStore(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	// 索引的过期时间取最长的会话，保存较短的会话时不缩短
+	for _, session := range []*jwt.Session{
+		{ID: "long", Subject: "alice", ExpiresAt: now.Add(2 * time.Hour)},
+		{ID: "short", Subject: "alice", ExpiresAt: now.Add(time.Minute)},
+	} {
+		if err := s.SaveSession(ctx, session); err != nil {
+			t.Fatalf("SaveSession() error = %v", err)
+		}
+	}
+	if ttl := m.TTL("jwt:sessions:alice"); ttl <= time.Hour {
+		t.Fatalf("index ttl = %v, want the longest session ttl", ttl)
+	}
+	list, err := s.ListSessions(ctx, "alice")
+	if err != nil || len(list) != 2 {
+		t.Fatalf("ListSessions() = %d sessions, %v, want 2", len(list), err)
+	}
+}
+
+func TestStoreExport(t *testing.T) {
+	m := miniredis.RunT(t)
+	ctx := context.Background()
+
+	// 前缀中的通配符按字面匹配，jwt1: 不属于 jwt[1]:
+	s := NewStore(Config{Addr: m.Addr(), KeyPrefix: "jwt[1]:"})
+	defer s.Close()
+	if err := s.Set(ctx, "revoked", time.Hour); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	m.Set("jwt1:other", "1")
+	m.SetTTL("jwt1:other", time.Hour)
+
+	var exported []string
+	err := s.Export(ctx, func(accessToken string, ttl time.Duration) error {
+		exported = append(exported, accessToken)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if len(exported) != 1 || exported[0] != "revoked" {
+		t.Fatalf("Export() = %v, want [revoked]", exported)
+	}
+
+	// 没有前缀时拒绝导出
+	empty := NewStore(Config{Addr: m.Addr()})
+	defer empty.Close()
+	if err := empty.Export(ctx, func(string, time.Duration) error { return nil }); !errors.Is(err, ErrExportPrefixRequired) {
+		t.Fatalf("Export() error = %v, want %v", err, ErrExportPrefixRequired)
+	}
+}
+
+func TestStoreTracing(t *testing.T) {
+	spans := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans))
+	defer tp.Shutdown(context.Background())
+	m, s := newTestStore(t, WithTracerProvider(tp))
+	ctx := context.Background()
+
+	if err := s.Set(ctx, "token", time.Hour); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	m.SetError("unavailable")
+	if _, err := s.Check(ctx, "token"); err == nil {
+		t.Fatal("Check() error = nil, want an error")
+	}
+
+	got := spans.GetSpans()
+	if len(got) != 2 || got[0].Name != "redis.Store.Set" || got[1].Name != "redis.Store.Check" {
+		t.Fatalf("spans = %v, want redis.Store.Set and redis.Store.Check", got)
+	}
+	for _, span := range got {
+		if span.SpanKind != trace.SpanKindClient {
+			t.Fatalf("%s span kind = %v, want client", span.Name, span.SpanKind)
+		}
+	}
+	if got[0].Status.Code == codes.Error || got[1].Status.Code != codes.Error {
+		t.Fatalf("span status = %v, %v, want ok and error", got[0].Status, got[1].Status)
+	}
+}
@@ -0,0 +1,93 @@
+package jwt
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName 是 tracer 和 meter 的名称
+const instrumentationName = "github.com/LiangNing7/onex/pkg/authn/jwt"
+
+// 令牌解析失败的原因，作为指标的 reason 属性
+const (
+	failureMalformed     = "malformed"      // 令牌格式错误
+	failureExpired       = "expired"        // 令牌已过期或尚未生效
+	failureInvalid       = "invalid"        // 令牌无效或签名无效
+	failureSigningMethod = "signing_method" // 签名算法不一致
+	failureRevoked       = "revoked"        // 令牌已被销毁
+	failurePurpose       = "purpose"        // 令牌用途不符
+	failureAudience      = "audience"       // 令牌的接收方不符
+	failureStore         = "store"          // 访问存储失败
+	failureOther         = "other"          // 其他错误
+)
+
+// telemetry 保存 JWTAuth 使用的链路追踪和指标
+type telemetry struct {
+	tracer        trace.Tracer
+	issued        metric.Int64Counter     // 签发的令牌数
+	parseFailures metric.Int64Counter     // 解析失败的次数
+	storeLatency  metric.Float64Histogram // 存储调用的耗时
+}
+
+// newTelemetry 根据 TracerProvider 和 MeterProvider 创建 telemetry
+func newTelemetry(tp trace.TracerProvider, mp metric.MeterProvider) *telemetry {
+	meter := mp.Meter(instrumentationName)
+	// 创建指标失败时 otel 会返回可用的 no-op 实现，因此忽略错误
+	issued, _ := meter.Int64Counter("authn.jwt.tokens.issued",
+		metric.WithDescription("Number of tokens issued."))
+	parseFailures, _ := meter.Int64Counter("authn.jwt.parse.failures",
+		metric.WithDescription("Number of token parse failures by reason."))
+	storeLatency, _ := meter.Float64Histogram("authn.jwt.store.duration",
+		metric.WithDescription("Latency of token store calls."), metric.WithUnit("ms"))
+	return &telemetry{
+		tracer:        tp.Tracer(instrumentationName),
+		issued:        issued,
+		parseFailures: parseFailures,
+		storeLatency:  storeLatency,
+	}
+}
+
+// start 开始一个新的 span
+func (t *telemetry) start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return t.tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// tokenIssued 记录一次令牌签发
+func (t *telemetry) tokenIssued(ctx context.Context, purpose string) {
+	t.issued.Add(ctx, 1, metric.WithAttributes(attribute.String("purpose", purpose)))
+}
+
+// parseFailed 记录一次令牌解析失败
+func (t *telemetry) parseFailed(ctx context.Context, reason string) {
+	// 记录失败原因，用于审计事件，并写入解析操作的 span
+	span := trace.SpanFromContext(ctx)
+	if f, ok := ctx.Value(failureKey{}).(*failure); ok {
+		f.reason = reason
+		span = f.span
+	}
+	t.parseFailures.Add(ctx, 1, metric.WithAttributes(attribute.String("reason", reason)))
+	span.SetAttributes(attribute.String("authn.failure.reason", reason))
+}
+
+// storeCalled 记录一次存储调用的耗时
+func (t *telemetry) storeCalled(ctx context.Context, op string, begin time.Time, err error) {
+	elapsed := float64(time.Since(begin).Nanoseconds()) / 1e6
+	t.storeLatency.Record(ctx, elapsed, metric.WithAttributes(
+		attribute.String("operation", op),
+		attribute.Bool("error", err != nil),
+	))
+}
+
+// end 根据错误设置 span 的状态并结束 span
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,221 @@
+package jwt_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/LiangNing7/onex/pkg/authn/jwt"
+	"github.com/LiangNing7/onex/pkg/authn/jwt/jwttest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// telemetryRecorder 保存测试使用的 span 导出器与指标读取器
+type telemetryRecorder struct {
+	spans  *tracetest.InMemoryExporter
+	reader *sdkmetric.ManualReader
+}
+
+// newTelemetryAuth 创建记录链路追踪与指标的 JWTAuth
+func newTelemetryAuth(t *testing.T, opts ...jwt.Option) (*jwt.JWTAuth, *jwttest.Minter, *telemetryRecorder) {
+	t.Helper()
+	r := &telemetryRecorder{spans: tracetest.NewInMemoryExporter(), reader: sdkmetric.NewManualReader()}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(r.spans))
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(r.reader))
+	t.Cleanup(func() {
+		_ = tp.Shutdown(context.Background())
+		_ = mp.Shutdown(context.Background())
+	})
+
+	clock := jwttest.NewClock(time.Now())
+	opts = append([]jwt.Option{
+		jwt.WithTracerProvider(tp),
+		jwt.WithMeterProvider(mp),
+	}, opts...)
+	auth, minter := jwttest.New(clock, opts...)
+	return auth, minter, r
+}
+
+// span 返回名称为 name 的最后一个 span
+func (r *telemetryRecorder) span(t *testing.T, name string) tracetest.SpanStub {
+	t.Helper()
+	spans := r.spans.GetSpans()
+	for i := len(spans) - 1; i >= 0; i-- {
+		if spans[i].Name == name {
+			return spans[i]
+		}
+	}
+	t.Fatalf("span %q not recorded", name)
+	return tracetest.SpanStub{}
+}
+
+// metric 返回名称为 name 的指标
+func (r *telemetryRecorder) metric(t *testing.T, name string) metricdata.Aggregation {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := r.reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				return m.Data
+			}
+		}
+	}
+	return nil
+}
+
+// counter 返回计数器中属性 key 为 value 的数据点的值
+func (r *telemetryRecorder) counter(t *testing.T, name, key, value string) int64 {
+	t.Helper()
+	sum, _ := r.metric(t, name).(metricdata.Sum[int64])
+	for _, dp := range sum.DataPoints {
+		if v, ok := dp.Attributes.Value(attribute.Key(key)); ok && v.AsString() == value {
+			return dp.Value
+		}
+	}
+	return 0
+}
+
+// spanAttr 返回 span 中属性 key 的值
+func spanAttr(span tracetest.SpanStub, key string) (attribute.Value, bool) {
+	for _, kv := range span.Attributes {
+		if string(kv.Key) == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestTelemetrySign(t *testing.T) {
+	auth, _, r := newTelemetryAuth(t)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := auth.Sign(ctx, "alice"); err != nil {
+			t.Fatalf("Sign() error = %v", err)
+		}
+	}
+	if _, err := auth.SignOneTime(ctx, "alice", "reset_password", time.Minute); err != nil {
+		t.Fatalf("SignOneTime() error = %v", err)
+	}
+
+	if n := r.counter(t, "authn.jwt.tokens.issued", "purpose", ""); n != 2 {
+		t.Fatalf("issued session tokens = %d, want 2", n)
+	}
+	if n := r.counter(t, "authn.jwt.tokens.issued", "purpose", "reset_password"); n != 1 {
+		t.Fatalf("issued one-time tokens = %d, want 1", n)
+	}
+	if span := r.span(t, "jwt.Sign"); span.Status.Code == codes.Error {
+		t.Fatalf("jwt.Sign span status = %v, want ok", span.Status)
+	}
+}
+
+func TestTelemetryParseFailures(t *testing.T) {
+	auth, minter, r := newTelemetryAuth(t)
+	ctx := context.Background()
+
+	revoked, err := auth.Sign(ctx, "alice")
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if err := auth.Destroy(ctx, revoked.GetToken()); err != nil {
+		t.Fatalf("Destroy() error = %v", err)
+	}
+	oneTime, err := auth.SignOneTime(ctx, "alice", "reset_password", time.Minute)
+	if err != nil {
+		t.Fatalf("SignOneTime() error = %v", err)
+	}
+
+	tests := []struct {
+		reason string
+		token  string
+	}{
+		{"malformed", ""},
+		{"malformed", "not-a-token"},
+		{"expired", minter.Expired(t, "alice", time.Minute)},
+		{"expired", minter.Future(t, "alice", time.Hour)},
+		{"invalid", minter.Forged(t, "alice")},
+		{"revoked", revoked.GetToken()},
+		{"purpose", oneTime.GetToken()},
+	}
+	want := make(map[string]int64)
+	for _, tt := range tests {
+		if _, err := auth.ParseClaims(ctx, tt.token); err == nil {
+			t.Fatalf("ParseClaims() for %s token succeeded", tt.reason)
+		}
+		want[tt.reason]++
+
+		span := r.span(t, "jwt.ParseClaims")
+		if span.Status.Code != codes.Error {
+			t.Fatalf("jwt.ParseClaims span status = %v, want error", span.Status)
+		}
+		if v, ok := spanAttr(span, "authn.failure.reason"); !ok || v.AsString() != tt.reason {
+			t.Fatalf("authn.failure.reason = %v, want %q", v.AsString(), tt.reason)
+		}
+	}
+	for reason, n := range want {
+		if got := r.counter(t, "authn.jwt.parse.failures", "reason", reason); got != n {
+			t.Fatalf("parse failures with reason %q = %d, want %d", reason, got, n)
+		}
+	}
+}
+
+func TestTelemetryStoreLatency(t *testing.T) {
+	auth, _, r := newTelemetryAuth(t)
+	ctx := context.Background()
+
+	token, err := auth.Sign(ctx, "alice")
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if _, err := auth.ParseClaims(ctx, token.GetToken()); err != nil {
+		t.Fatalf("ParseClaims() error = %v", err)
+	}
+	if err := auth.Destroy(ctx, token.GetToken()); err != nil {
+		t.Fatalf("Destroy() error = %v", err)
+	}
+
+	// 存储调用的 span 是外层 span 的子 span
+	check, parse := r.span(t, "jwt.store.check"), r.span(t, "jwt.ParseClaims")
+	if check.Parent.SpanID() != parse.SpanContext.SpanID() {
+		t.Fatal("jwt.store.check span is not a child of jwt.ParseClaims")
+	}
+	r.span(t, "jwt.store.set")
+
+	hist, ok := r.metric(t, "authn.jwt.store.duration").(metricdata.Histogram[float64])
+	if !ok {
+		t.Fatal("authn.jwt.store.duration not recorded")
+	}
+	counts := make(map[string]uint64)
+	for _, dp := range hist.DataPoints {
+		op, _ := dp.Attributes.Value("operation")
+		counts[op.AsString()] += dp.Count
+	}
+	if counts["check"] != 1 || counts["set"] != 1 {
+		t.Fatalf("store calls = %v, want one check and one set", counts)
+	}
+}
+
+func TestTelemetryCanceled(t *testing.T) {
+	auth, _, r := newTelemetryAuth(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := auth.Sign(ctx, "alice"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Sign() error = %v, want %v", err, context.Canceled)
+	}
+	if span := r.span(t, "jwt.Sign"); span.Status.Code != codes.Error {
+		t.Fatalf("jwt.Sign span status = %v, want error", span.Status)
+	}
+	if n := r.counter(t, "authn.jwt.tokens.issued", "purpose", ""); n != 0 {
+		t.Fatalf("issued tokens = %d after cancellation, want 0", n)
+	}
+}
@@ -1,12 +1,75 @@
 package jwt
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
 
-// tokenInfo authn.IToken 接口的实现
+	"github.com/LiangNing7/onex/pkg/authn"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Format 定义令牌 JSON 的编码格式
+type Format int
+
+const (
+	// FormatLegacy 原有的编码格式：{"token": "...", "type": "Bearer", "expireAt": 1700000000}
+	FormatLegacy Format = iota
+	// FormatOAuth2 RFC 6749 第 5.1 节定义的令牌响应格式：
+	// {"access_token": "...", "token_type": "Bearer", "expires_in": 7200, "refresh_token": "...", "scope": "..."}
+	FormatOAuth2
+)
+
+// Claims 定义 JWTAuth 签发的令牌声明
+type Claims struct {
+	jwt.RegisteredClaims
+	// Purpose 一次性令牌的用途，会话令牌为空
+	Purpose string `json:"purpose,omitempty"`
+	// Scope 令牌的授权范围，以空格分隔
+	Scope string `json:"scope,omitempty"`
+	// SessionID 令牌所属的会话，只在配置了会话存储时设置
+	SessionID string `json:"sid,omitempty"`
+	// Act 代表主体执行操作的参与者（RFC 8693），用于模拟登录与委托调用
+	Act *Actor `json:"act,omitempty"`
+	// Exchanged 令牌由 Exchange 签发，沿用原令牌的会话但不拥有该会话，撤销时不删除会话
+	Exchanged bool `json:"exchanged,omitempty"`
+}
+
+// Actor 表示 RFC 8693 中的 act 声明，嵌套的 Act 为更早的参与者.
+type Actor struct {
+	Subject string `json:"sub"`           // 参与者
+	Act     *Actor `json:"act,omitempty"` // 更早的参与者
+}
+
+// ActorChain 返回参与者链，第一个为当前的参与者，没有参与者时返回 nil.
+func (c *Claims) ActorChain() []string {
+	var chain []string
+	for act := c.Act; act != nil; act = act.Act {
+		chain = append(chain, act.Subject)
+	}
+	return chain
+}
+
+// tokenInfo authn.OAuth2Token 接口的实现
 type tokenInfo struct {
-	Token     string `json:"token"`    // 令牌字符串
-	Type      string `json:"type"`     // 令牌类型
-	ExpiresAt int64  `json:"expireAt"` // 令牌过期时间
+	Token        string `json:"token"`    // 令牌字符串
+	Type         string `json:"type"`     // 令牌类型
+	ExpiresAt    int64  `json:"expireAt"` // 令牌过期时间
+	RefreshToken string `json:"-"`        // 刷新令牌，只在 OAuth2 格式中输出
+	Scope        string `json:"-"`        // 授权范围，只在 OAuth2 格式中输出
+	IssuedAt     int64  `json:"-"`        // 令牌签发时间
+	ExpiresIn    int64  `json:"-"`        // 令牌有效期（秒）
+	format       Format // JSON 编码格式
+}
+
+var _ authn.OAuth2Token = (*tokenInfo)(nil)
+
+// oauth2Token RFC 6749 令牌响应的 JSON 结构
+type oauth2Token struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
 }
 
 // GetToken 获取 Token
@@ -24,7 +87,47 @@ func (t *tokenInfo) GetExpiresAt() int64 {
 	return t.ExpiresAt
 }
 
-// EncodeToJSON JSON 编码
+// GetRefreshToken 获取刷新令牌
+func (t *tokenInfo) GetRefreshToken() string {
+	return t.RefreshToken
+}
+
+// GetScope 获取授权范围
+func (t *tokenInfo) GetScope() string {
+	return t.Scope
+}
+
+// GetIssuedAt 获取签发时间
+func (t *tokenInfo) GetIssuedAt() int64 {
+	return t.IssuedAt
+}
+
+// GetExpiresIn 获取有效期
+func (t *tokenInfo) GetExpiresIn() int64 {
+	return t.ExpiresIn
+}
+
+// EncodeToJSON JSON 编码，格式由 WithFormat 决定
 func (t *tokenInfo) EncodeToJSON() ([]byte, error) {
+	if t.format == FormatOAuth2 {
+		return EncodeOAuth2(t)
+	}
 	return json.Marshal(t)
 }
+
+// EncodeOAuth2 将任意 IToken 编码为 RFC 6749 的令牌响应 JSON，
+// 没有实现 authn.OAuth2Token 时 expires_in 根据过期时间计算，不输出 refresh_token 与 scope
+func EncodeOAuth2(t authn.IToken) ([]byte, error) {
+	resp := &oauth2Token{
+		AccessToken: t.GetToken(),
+		TokenType:   t.GetTokenType(),
+	}
+	if ot, ok := t.(authn.OAuth2Token); ok {
+		resp.ExpiresIn = ot.GetExpiresIn()
+		resp.RefreshToken = ot.GetRefreshToken()
+		resp.Scope = ot.GetScope()
+	} else if t.GetExpiresAt() > 0 {
+		resp.ExpiresIn = max(t.GetExpiresAt()-time.Now().Unix(), 0)
+	}
+	return json.Marshal(resp)
+}
@@ -0,0 +1,119 @@
+package jwt_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/LiangNing7/onex/pkg/authn"
+	"github.com/LiangNing7/onex/pkg/authn/jwt"
+	"github.com/LiangNing7/onex/pkg/authn/jwt/jwttest"
+)
+
+// decodeJSON 将 JSON 解码为 map，用于检查字段名
+func decodeJSON(t *testing.T, data []byte) map[string]any {
+	t.Helper()
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatalf("Unmarshal(%s) error = %v", data, err)
+	}
+	return m
+}
+
+func TestEncodeToJSON(t *testing.T) {
+	ctx := context.Background()
+	clock := jwttest.NewClock(time.Unix(1700000000, 0))
+
+	tests := []struct {
+		name   string
+		opts   []jwt.Option
+		scopes []string
+		want   func(token authn.OAuth2Token) map[string]any
+	}{
+		{
+			name:   "legacy",
+			opts:   []jwt.Option{jwt.WithRefreshExpired(24 * time.Hour)},
+			scopes: []string{"order:read"},
+			// 原有的格式不包含刷新令牌与授权范围
+			want: func(token authn.OAuth2Token) map[string]any {
+				return map[string]any{"token": token.GetToken(), "type": "Bearer", "expireAt": float64(1700003600)}
+			},
+		},
+		{
+			name:   "oauth2",
+			opts:   []jwt.Option{jwt.WithFormat(jwt.FormatOAuth2), jwt.WithRefreshExpired(24 * time.Hour)},
+			scopes: []string{"order:read", "order:write"},
+			want: func(token authn.OAuth2Token) map[string]any {
+				return map[string]any{
+					"access_token":  token.GetToken(),
+					"token_type":    "Bearer",
+					"expires_in":    float64(3600),
+					"refresh_token": token.GetRefreshToken(),
+					"scope":         "order:read order:write",
+				}
+			},
+		},
+		{
+			name: "oauth2 without refresh token and scope",
+			opts: []jwt.Option{jwt.WithFormat(jwt.FormatOAuth2)},
+			want: func(token authn.OAuth2Token) map[string]any {
+				return map[string]any{"access_token": token.GetToken(), "token_type": "Bearer", "expires_in": float64(3600)}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, _ := jwttest.New(clock, append([]jwt.Option{jwt.WithExpired(time.Hour)}, tt.opts...)...)
+			token, err := auth.SignWith(ctx, "alice", jwt.WithScope(tt.scopes...))
+			if err != nil {
+				t.Fatalf("SignWith() error = %v", err)
+			}
+			ot, ok := token.(authn.OAuth2Token)
+			if !ok {
+				t.Fatalf("%T does not implement authn.OAuth2Token", token)
+			}
+			if ot.GetIssuedAt() != 1700000000 || ot.GetExpiresIn() != 3600 {
+				t.Fatalf("issued at = %d, expires in = %d", ot.GetIssuedAt(), ot.GetExpiresIn())
+			}
+			data, err := token.EncodeToJSON()
+			if err != nil {
+				t.Fatalf("EncodeToJSON() error = %v", err)
+			}
+			if got, want := decodeJSON(t, data), tt.want(ot); !reflect.DeepEqual(got, want) {
+				t.Fatalf("EncodeToJSON() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// plainToken 只实现了 authn.IToken
+type plainToken struct{ expiresAt int64 }
+
+func (t *plainToken) GetToken() string              { return "plain" }
+func (t *plainToken) GetTokenType() string          { return "Bearer" }
+func (t *plainToken) GetExpiresAt() int64           { return t.expiresAt }
+func (t *plainToken) EncodeToJSON() ([]byte, error) { return json.Marshal(t) }
+
+func TestEncodeOAuth2PlainToken(t *testing.T) {
+	data, err := jwt.EncodeOAuth2(&plainToken{expiresAt: time.Now().Add(time.Hour).Unix()})
+	if err != nil {
+		t.Fatalf("EncodeOAuth2() error = %v", err)
+	}
+	got := decodeJSON(t, data)
+	// expires_in 根据过期时间计算，允许 1 秒的误差
+	if expiresIn, _ := got["expires_in"].(float64); expiresIn < 3599 || expiresIn > 3600 {
+		t.Fatalf("expires_in = %v, want about 3600", got["expires_in"])
+	}
+	delete(got, "expires_in")
+	if want := map[string]any{"access_token": "plain", "token_type": "Bearer"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("EncodeOAuth2() = %v, want %v", got, want)
+	}
+
+	// 已经过期的令牌不输出 expires_in
+	data, _ = jwt.EncodeOAuth2(&plainToken{expiresAt: time.Now().Add(-time.Hour).Unix()})
+	if _, ok := decodeJSON(t, data)["expires_in"]; ok {
+		t.Fatalf("EncodeOAuth2() = %s, want no expires_in for an expired token", data)
+	}
+}
@@ -0,0 +1,247 @@
+// Package limiter 为登录提供防暴力破解保护，按账号和 IP 分别使用滑动窗口统计失败次数，
+// 超过阈值后按指数增长的时长锁定.
+package limiter
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/LiangNing7/onex/pkg/authn"
+	"github.com/LiangNing7/onex/pkg/authn/audit"
+	"github.com/LiangNing7/onex/pkg/i18n"
+	"github.com/go-kratos/kratos/v2/errors"
+	goi18n "github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+// reason 保存错误原因.
+const reason string = "TooManyRequests"
+
+// 定义错误类型
+var (
+	// ErrLoginLocked 表示登录失败次数过多，已被锁定
+	ErrLoginLocked = errors.New(http.StatusTooManyRequests, reason, "Too many failed login attempts, please try again later")
+)
+
+// 定义 I18n 的消息
+var (
+	MessageLoginLocked = &goi18n.Message{ID: "authn.login.locked", Other: ErrLoginLocked.Message}
+)
+
+// Store 登录失败记录的存储接口.
+type Store interface {
+	// AddFailure 记录一次失败，并返回滑动窗口内的失败次数
+	AddFailure(ctx context.Context, key string, now time.Time, window time.Duration) (int, error)
+	// ClearFailures 清除失败记录
+	ClearFailures(ctx context.Context, key string) error
+	// Lockout 返回锁定截止时间和累计锁定次数，没有锁定记录时返回零值
+	Lockout(ctx context.Context, key string) (until time.Time, level int, err error)
+	// SetLockout 保存锁定截止时间和累计锁定次数，记录在 ttl 后过期
+	SetLockout(ctx context.Context, key string, until time.Time, level int, ttl time.Duration) error
+	// Reset 清除失败记录和锁定记录
+	Reset(ctx context.Context, key string) error
+	// Close 关闭存储
+	Close() error
+}
+
+// 定义登录限制的配置
+type options struct {
+	accountLimit int           // 窗口内每个账号允许的失败次数
+	ipLimit      int           // 窗口内每个 IP 允许的失败次数
+	window       time.Duration // 滑动窗口大小
+	lockout      time.Duration // 首次锁定的时长
+	maxLockout   time.Duration // 锁定时长的上限
+	memory       time.Duration // 锁定次数的保留时间，超过后锁定时长重新从 lockout 开始
+}
+
+// 定义默认配置
+var defaultOptions = options{
+	accountLimit: 5,
+	ipLimit:      20,
+	window:       15 * time.Minute,
+	lockout:      time.Minute,
+	maxLockout:   24 * time.Hour,
+	memory:       24 * time.Hour,
+}
+
+// Option 定义配置函数，用于选项模式
+type Option func(*options)
+
+// WithAccountLimit 设置窗口内每个账号允许的失败次数（默认 5 次）。
+func WithAccountLimit(limit int) Option {
+	return func(o *options) {
+		o.accountLimit = limit
+	}
+}
+
+// WithIPLimit 设置窗口内每个 IP 允许的失败次数（默认 20 次）。
+func WithIPLimit(limit int) Option {
+	return func(o *options) {
+		o.ipLimit = limit
+	}
+}
+
+// WithWindow 设置滑动窗口大小（默认 15 分钟）。
+func WithWindow(window time.Duration) Option {
+	return func(o *options) {
+		o.window = window
+	}
+}
+
+// WithLockout 设置首次锁定时长和锁定时长上限（默认 1 分钟和 24 小时），每次再被锁定时长翻倍。
+func WithLockout(lockout, maxLockout time.Duration) Option {
+	return func(o *options) {
+		o.lockout = lockout
+		o.maxLockout = maxLockout
+	}
+}
+
+// WithLockoutMemory 设置锁定次数的保留时间（默认 24 小时）。
+func WithLockoutMemory(memory time.Duration) Option {
+	return func(o *options) {
+		o.memory = memory
+	}
+}
+
+// Limiter 登录限制器.
+type Limiter struct {
+	opts  *options
+	store Store
+}
+
+// New 创建一个新的 Limiter 实例
+func New(store Store, opts ...Option) *Limiter {
+	o := defaultOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Limiter{opts: &o, store: store}
+}
+
+// Allow 检查账号和 IP 是否处于锁定状态，锁定时返回 ErrLoginLocked.
+func (l *Limiter) Allow(ctx context.Context, account, ip string) error {
+	for _, t := range l.targets(account, ip) {
+		until, _, err := l.store.Lockout(ctx, t.key)
+		if err != nil {
+			return err
+		}
+		if wait := time.Until(until); wait > 0 {
+			return locked(ctx, wait)
+		}
+	}
+	return nil
+}
+
+// Fail 记录一次登录失败，超过阈值时锁定对应的账号或 IP.
+func (l *Limiter) Fail(ctx context.Context, account, ip string) error {
+	now := time.Now()
+	for _, t := range l.targets(account, ip) {
+		count, err := l.store.AddFailure(ctx, t.key, now, l.opts.window)
+		if err != nil {
+			return err
+		}
+		if count < t.limit {
+			continue
+		}
+		if err := l.lock(ctx, t.key, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Succeed 记录一次登录成功，清除账号的失败和锁定记录.
+// IP 的失败记录不会清除，避免攻击者穿插登录自己的账号来重置计数.
+func (l *Limiter) Succeed(ctx context.Context, account, ip string) error {
+	if account == "" {
+		return nil
+	}
+	return l.store.Reset(ctx, accountKey(account))
+}
+
+// Compare 在登录限制的保护下比较密码.
+// hashedPassword 为空表示用户不存在，此时执行 authn.CompareDummy 以保持耗时一致.
+// 配置了 authn.SetAuditor 时会记录登录的审计事件，附加信息中包含 ip.
+func (l *Limiter) Compare(ctx context.Context, account, ip, hashedPassword, password string) error {
+	if err := l.Allow(ctx, account, ip); err != nil {
+		return err
+	}
+
+	var err error
+	ctx = audit.WithMetadata(ctx, "ip", ip)
+	if hashedPassword == "" {
+		err = authn.CompareDummyContext(ctx, account, password)
+	} else {
+		err = authn.CompareContext(ctx, account, hashedPassword, password)
+	}
+	if err != nil {
+		if ferr := l.Fail(ctx, account, ip); ferr != nil {
+			return ferr
+		}
+		return err
+	}
+	return l.Succeed(ctx, account, ip)
+}
+
+// Release 用于释放请求的资源
+func (l *Limiter) Release() error {
+	return l.store.Close()
+}
+
+// lock 按指数增长的时长锁定 key，并清除窗口内的失败记录
+func (l *Limiter) lock(ctx context.Context, key string, now time.Time) error {
+	_, level, err := l.store.Lockout(ctx, key)
+	if err != nil {
+		return err
+	}
+	level++
+
+	// 锁定时长为 lockout * 2^(level-1)，不超过 maxLockout
+	duration := l.opts.lockout
+	for i := 1; i < level && duration < l.opts.maxLockout; i++ {
+		duration *= 2
+	}
+	if duration > l.opts.maxLockout {
+		duration = l.opts.maxLockout
+	}
+
+	ttl := l.opts.memory
+	if ttl < duration {
+		ttl = duration
+	}
+	if err := l.store.SetLockout(ctx, key, now.Add(duration), level, ttl); err != nil {
+		return err
+	}
+	return l.store.ClearFailures(ctx, key)
+}
+
+// locked 返回本地化的锁定错误，并在元数据中带上需要等待的秒数
+func locked(ctx context.Context, wait time.Duration) error {
+	seconds := int64((wait + time.Second - 1) / time.Second)
+	return errors.New(http.StatusTooManyRequests, reason, i18n.FromContext(ctx).LocalizeT(MessageLoginLocked)).
+		WithMetadata(map[string]string{"retry_after": strconv.FormatInt(seconds, 10)})
+}
+
+// target 表示一个需要限制的对象
+type target struct {
+	key   string // 存储键
+	limit int    // 窗口内允许的失败次数
+}
+
+// targets 返回账号和 IP 对应的限制对象，忽略空值
+func (l *Limiter) targets(account, ip string) []target {
+	ts := make([]target, 0, 2)
+	if account != "" {
+		ts = append(ts, target{key: accountKey(account), limit: l.opts.accountLimit})
+	}
+	if ip != "" {
+		ts = append(ts, target{key: "ip:" + ip, limit: l.opts.ipLimit})
+	}
+	return ts
+}
+
+// accountKey 返回账号对应的存储键
+func accountKey(account string) string {
+	return "account:" + account
+}
@@ -0,0 +1,155 @@
+package limiter_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/LiangNing7/onex/pkg/authn"
+	"github.com/LiangNing7/onex/pkg/authn/limiter"
+	"github.com/LiangNing7/onex/pkg/authn/limiter/store/memory"
+	"github.com/LiangNing7/onex/pkg/authn/limiter/store/redis"
+	"github.com/alicebob/miniredis/v2"
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testStores 返回需要测试的存储实现
+func testStores(t *testing.T) map[string]func() limiter.Store {
+	return map[string]func() limiter.Store{
+		"memory": func() limiter.Store { return memory.NewStore() },
+		"redis": func() limiter.Store {
+			m := miniredis.RunT(t)
+			return redis.NewStore(redis.Config{Addr: m.Addr(), KeyPrefix: "login:"})
+		},
+	}
+}
+
+// fail 记录 n 次失败
+func fail(t *testing.T, l *limiter.Limiter, account, ip string, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := l.Fail(context.Background(), account, ip); err != nil {
+			t.Fatalf("Fail() error = %v", err)
+		}
+	}
+}
+
+// retryAfter 检查 err 为锁定错误并返回需要等待的秒数
+func retryAfter(t *testing.T, err error) int {
+	t.Helper()
+	if !errors.Is(err, limiter.ErrLoginLocked) {
+		t.Fatalf("error = %v, want %v", err, limiter.ErrLoginLocked)
+	}
+	seconds, err := strconv.Atoi(kerrors.FromError(err).Metadata["retry_after"])
+	if err != nil {
+		t.Fatalf("invalid retry_after: %v", err)
+	}
+	return seconds
+}
+
+func TestLimiterAccountLockout(t *testing.T) {
+	for name, newStore := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			l := limiter.New(newStore(), limiter.WithAccountLimit(3), limiter.WithLockout(10*time.Second, 30*time.Second))
+			defer l.Release()
+
+			fail(t, l, "alice", "", 2)
+			if err := l.Allow(ctx, "alice", ""); err != nil {
+				t.Fatalf("Allow() below the limit error = %v", err)
+			}
+			fail(t, l, "alice", "", 1)
+			if s := retryAfter(t, l.Allow(ctx, "alice", "")); s != 10 {
+				t.Fatalf("retry_after = %d, want 10", s)
+			}
+			// 其它账号不受影响
+			if err := l.Allow(ctx, "bob", ""); err != nil {
+				t.Fatalf("Allow() for another account error = %v", err)
+			}
+
+			// 再次被锁定时时长翻倍，不超过上限
+			for _, want := range []int{20, 30, 30} {
+				fail(t, l, "alice", "", 3)
+				if s := retryAfter(t, l.Allow(ctx, "alice", "")); s != want {
+					t.Fatalf("retry_after = %d, want %d", s, want)
+				}
+			}
+
+			// 登录成功后清除账号的锁定记录
+			if err := l.Succeed(ctx, "alice", ""); err != nil {
+				t.Fatalf("Succeed() error = %v", err)
+			}
+			if err := l.Allow(ctx, "alice", ""); err != nil {
+				t.Fatalf("Allow() after success error = %v", err)
+			}
+		})
+	}
+}
+
+func TestLimiterIPLockout(t *testing.T) {
+	for name, newStore := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			l := limiter.New(newStore(), limiter.WithAccountLimit(100), limiter.WithIPLimit(3))
+			defer l.Release()
+
+			// 同一个 IP 尝试不同账号
+			for _, account := range []string{"a", "b", "c"} {
+				fail(t, l, account, "10.0.0.1", 1)
+			}
+			// 登录成功不清除 IP 的记录
+			if err := l.Succeed(ctx, "d", "10.0.0.1"); err != nil {
+				t.Fatalf("Succeed() error = %v", err)
+			}
+			retryAfter(t, l.Allow(ctx, "d", "10.0.0.1"))
+			if err := l.Allow(ctx, "d", "10.0.0.2"); err != nil {
+				t.Fatalf("Allow() from another IP error = %v", err)
+			}
+		})
+	}
+}
+
+func TestLimiterLockoutExpires(t *testing.T) {
+	ctx := context.Background()
+	l := limiter.New(memory.NewStore(), limiter.WithAccountLimit(1), limiter.WithLockout(50*time.Millisecond, time.Second))
+	defer l.Release()
+
+	fail(t, l, "alice", "", 1)
+	retryAfter(t, l.Allow(ctx, "alice", ""))
+	time.Sleep(60 * time.Millisecond)
+	if err := l.Allow(ctx, "alice", ""); err != nil {
+		t.Fatalf("Allow() after lockout error = %v", err)
+	}
+}
+
+func TestLimiterCompare(t *testing.T) {
+	ctx := context.Background()
+	hashed, err := authn.Encrypt("secret")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	l := limiter.New(memory.NewStore(), limiter.WithAccountLimit(2))
+	defer l.Release()
+
+	if err := l.Compare(ctx, "alice", "", hashed, "wrong"); !errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		t.Fatalf("Compare() error = %v, want %v", err, bcrypt.ErrMismatchedHashAndPassword)
+	}
+	// 成功登录清除失败记录
+	if err := l.Compare(ctx, "alice", "", hashed, "secret"); err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if err := l.Compare(ctx, "alice", "", hashed, "wrong"); !errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		t.Fatalf("Compare() error = %v, want %v", err, bcrypt.ErrMismatchedHashAndPassword)
+	}
+
+	// 不存在的用户同样计入失败次数
+	for i := 0; i < 2; i++ {
+		if err := l.Compare(ctx, "nobody", "", "", "secret"); !errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			t.Fatalf("Compare() for unknown user error = %v, want %v", err, bcrypt.ErrMismatchedHashAndPassword)
+		}
+	}
+	retryAfter(t, l.Compare(ctx, "nobody", "", "", "secret"))
+}
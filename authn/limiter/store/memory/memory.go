@@ -0,0 +1,138 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval 每写入多少次清理一次过期的数据
+const sweepInterval = 1024
+
+// failures 保存滑动窗口内的失败记录
+type failures struct {
+	times    []time.Time // 失败时间，按时间先后排列
+	expireAt time.Time   // 记录过期时间，最后一次失败移出窗口的时间
+}
+
+// lockout 保存一次锁定记录
+type lockout struct {
+	until    time.Time // 锁定截止时间
+	level    int       // 累计锁定次数
+	expireAt time.Time // 记录过期时间
+}
+
+// Store 用于实现 limiter.Store 接口，数据只保存在当前进程中.
+// 每写入 sweepInterval 次清理一次过期的失败记录和锁定记录，不会被访问的键同样会被清理.
+type Store struct {
+	mu       sync.Mutex
+	failures map[string]*failures
+	lockouts map[string]lockout
+	writes   int // 写入次数，用于定期清理
+}
+
+// NewStore 创建一个 *Store 实例
+func NewStore() *Store {
+	return &Store{
+		failures: make(map[string]*failures),
+		lockouts: make(map[string]lockout),
+	}
+}
+
+// AddFailure 记录一次失败，并返回滑动窗口内的失败次数
+func (s *Store) AddFailure(ctx context.Context, key string, now time.Time, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.failures[key]
+	if !ok {
+		f = &failures{}
+		s.failures[key] = f
+	}
+
+	// 丢弃窗口之外的失败记录
+	start := now.Add(-window)
+	kept := f.times[:0]
+	for _, t := range f.times {
+		if t.After(start) {
+			kept = append(kept, t)
+		}
+	}
+	f.times = append(kept, now)
+	f.expireAt = now.Add(window)
+	s.written(now)
+	return len(f.times), nil
+}
+
+// ClearFailures 清除失败记录
+func (s *Store) ClearFailures(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.failures, key)
+	return nil
+}
+
+// Lockout 返回锁定截止时间和累计锁定次数
+func (s *Store) Lockout(ctx context.Context, key string) (time.Time, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.lockouts[key]
+	if !ok {
+		return time.Time{}, 0, nil
+	}
+	if time.Now().After(l.expireAt) {
+		delete(s.lockouts, key)
+		return time.Time{}, 0, nil
+	}
+	return l.until, l.level, nil
+}
+
+// SetLockout 保存锁定截止时间和累计锁定次数
+func (s *Store) SetLockout(ctx context.Context, key string, until time.Time, level int, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.lockouts[key] = lockout{until: until, level: level, expireAt: now.Add(ttl)}
+	s.written(now)
+	return nil
+}
+
+// Reset 清除失败记录和锁定记录
+func (s *Store) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.failures, key)
+	delete(s.lockouts, key)
+	return nil
+}
+
+// Close 清空所有记录
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.failures)
+	clear(s.lockouts)
+	return nil
+}
+
+// written 记录一次写入，并定期清理过期的数据，调用方需要持有锁
+func (s *Store) written(now time.Time) {
+	if s.writes++; s.writes%sweepInterval == 0 {
+		s.sweep(now)
+	}
+}
+
+// sweep 清理过期的失败记录和锁定记录，调用方需要持有锁
+func (s *Store) sweep(now time.Time) {
+	for key, f := range s.failures {
+		if !now.Before(f.expireAt) {
+			delete(s.failures, key)
+		}
+	}
+	for key, l := range s.lockouts {
+		if now.After(l.expireAt) {
+			delete(s.lockouts, key)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestStoreSweep(t *testing.T) {
+	ctx := context.Background()
+	s := NewStore()
+	past := time.Now().Add(-time.Hour)
+
+	// 只失败过一次、之后不再访问的键在过期后被清理
+	for i := 0; i < sweepInterval/2; i++ {
+		if _, err := s.AddFailure(ctx, fmt.Sprintf("ip:%d", i), past, time.Minute); err != nil {
+			t.Fatalf("AddFailure() error = %v", err)
+		}
+	}
+	if err := s.SetLockout(ctx, "account:expired", past, 1, -time.Minute); err != nil {
+		t.Fatalf("SetLockout() error = %v", err)
+	}
+	if err := s.SetLockout(ctx, "account:locked", time.Now().Add(time.Hour), 1, time.Hour); err != nil {
+		t.Fatalf("SetLockout() error = %v", err)
+	}
+	for i := len(s.failures) + len(s.lockouts); i < sweepInterval; i++ {
+		if _, err := s.AddFailure(ctx, "ip:active", time.Now(), time.Minute); err != nil {
+			t.Fatalf("AddFailure() error = %v", err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.failures) != 1 || s.failures["ip:active"] == nil {
+		t.Fatalf("%d failure records left, want only the active one", len(s.failures))
+	}
+	if _, ok := s.lockouts["account:locked"]; len(s.lockouts) != 1 || !ok {
+		t.Fatalf("%d lockout records left, want only the active one", len(s.lockouts))
+	}
+}
+
+func TestStoreAddFailureWindow(t *testing.T) {
+	ctx := context.Background()
+	s := NewStore()
+	now := time.Now()
+
+	for i, want := range []int{1, 2, 3} {
+		count, err := s.AddFailure(ctx, "account:alice", now.Add(time.Duration(i)*time.Second), 5*time.Second)
+		if err != nil || count != want {
+			t.Fatalf("AddFailure() = %d, %v, want %d", count, err, want)
+		}
+	}
+	// 窗口之外的失败不再计数
+	count, err := s.AddFailure(ctx, "account:alice", now.Add(6*time.Second), 5*time.Second)
+	if err != nil || count != 2 {
+		t.Fatalf("AddFailure() = %d, %v, want 2", count, err)
+	}
+}
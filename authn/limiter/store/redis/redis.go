@@ -0,0 +1,107 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Config 包含了必要的 Redis 配置选项
+type Config struct {
+	Addr      string // 地址
+	Username  string // 用户名
+	Password  string // 密码
+	Database  int    // 数据库编号
+	KeyPrefix string // 存储键的前缀
+}
+
+// Store 用于实现 limiter.Store 接口
+type Store struct {
+	cli    *redis.Client // redis 客户端
+	prefix string        // 前缀
+}
+
+// NewStore 根据 Config 创建一个 *Store 实例
+func NewStore(cfg Config) *Store {
+	cli := redis.NewClient(&redis.Options{
+		Addr:     cfg.Addr,
+		DB:       cfg.Database,
+		Username: cfg.Username,
+		Password: cfg.Password,
+	})
+	return &Store{cli: cli, prefix: cfg.KeyPrefix}
+}
+
+// failuresKey 失败记录的键名，格式为 <prefix>failures:<key>，类型为有序集合
+func (s *Store) failuresKey(key string) string {
+	return fmt.Sprintf("%sfailures:%s", s.prefix, key)
+}
+
+// lockoutKey 锁定记录的键名，格式为 <prefix>lockout:<key>，类型为哈希
+func (s *Store) lockoutKey(key string) string {
+	return fmt.Sprintf("%slockout:%s", s.prefix, key)
+}
+
+// AddFailure 使用有序集合实现滑动窗口，分值为失败时间的毫秒时间戳
+func (s *Store) AddFailure(ctx context.Context, key string, now time.Time, window time.Duration) (int, error) {
+	k := s.failuresKey(key)
+	// 成员需要唯一，避免同一毫秒内的失败被合并
+	member := fmt.Sprintf("%d-%d", now.UnixNano(), rand.Int63())
+
+	var card *redis.IntCmd
+	_, err := s.cli.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRemRangeByScore(ctx, k, "-inf", strconv.FormatInt(now.Add(-window).UnixMilli(), 10))
+		pipe.ZAdd(ctx, k, redis.Z{Score: float64(now.UnixMilli()), Member: member})
+		card = pipe.ZCard(ctx, k)
+		pipe.PExpire(ctx, k, window)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(card.Val()), nil
+}
+
+// ClearFailures 删除失败记录
+func (s *Store) ClearFailures(ctx context.Context, key string) error {
+	return s.cli.Del(ctx, s.failuresKey(key)).Err()
+}
+
+// Lockout 读取锁定截止时间和累计锁定次数
+func (s *Store) Lockout(ctx context.Context, key string) (time.Time, int, error) {
+	vals, err := s.cli.HGetAll(ctx, s.lockoutKey(key)).Result()
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	if len(vals) == 0 {
+		return time.Time{}, 0, nil
+	}
+	until, _ := strconv.ParseInt(vals["until"], 10, 64)
+	level, _ := strconv.Atoi(vals["level"])
+	return time.UnixMilli(until), level, nil
+}
+
+// SetLockout 保存锁定截止时间和累计锁定次数，并设置记录的过期时间
+func (s *Store) SetLockout(ctx context.Context, key string, until time.Time, level int, ttl time.Duration) error {
+	k := s.lockoutKey(key)
+	_, err := s.cli.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, k, "until", until.UnixMilli(), "level", level)
+		pipe.PExpire(ctx, k, ttl)
+		return nil
+	})
+	return err
+}
+
+// Reset 删除失败记录和锁定记录
+func (s *Store) Reset(ctx context.Context, key string) error {
+	return s.cli.Del(ctx, s.failuresKey(key), s.lockoutKey(key)).Err()
+}
+
+// Close 用于关闭 Redis client
+func (s *Store) Close() error {
+	return s.cli.Close()
+}
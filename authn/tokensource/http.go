@@ -0,0 +1,42 @@
+package tokensource
+
+import "net/http"
+
+// Transport 是为每个请求添加 Authorization 头的 http.RoundTripper.
+type Transport struct {
+	// Source 提供令牌
+	Source *TokenSource
+	// Base 实际发送请求的 RoundTripper，为空时使用 http.DefaultTransport
+	Base http.RoundTripper
+}
+
+var _ http.RoundTripper = (*Transport)(nil)
+
+// RoundTrip 获取令牌并发送请求，不会修改传入的请求.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.Source.Token(req.Context())
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	req2 := req.Clone(req.Context())
+	req2.Header.Set("Authorization", authorization(token))
+
+	resp, err := t.base().RoundTrip(req2)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		// 令牌可能已被服务端销毁，下一次请求重新获取
+		t.Source.Invalidate(token)
+	}
+	return resp, err
+}
+
+// base 返回实际发送请求的 RoundTripper
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
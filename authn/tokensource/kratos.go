@@ -0,0 +1,31 @@
+package tokensource
+
+import (
+	"context"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// Client 返回用于 Kratos 客户端的中间件，为每个请求添加 Authorization 头.
+func Client(source *TokenSource) middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			token, err := source.Token(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if tr, ok := transport.FromClientContext(ctx); ok {
+				tr.RequestHeader().Set("Authorization", authorization(token))
+			}
+
+			reply, err := handler(ctx, req)
+			if errors.IsUnauthorized(err) {
+				// 令牌可能已被服务端销毁，下一次请求重新获取
+				source.Invalidate(token)
+			}
+			return reply, err
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// Package tokensource 为服务间调用提供客户端令牌缓存，在令牌过期之前自动刷新.
+package tokensource
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/LiangNing7/onex/pkg/authn"
+)
+
+// FetchFunc 获取一个新的令牌.
+type FetchFunc func(ctx context.Context) (authn.IToken, error)
+
+// FromAuthenticator 返回使用 Authenticator 为 userID 签发令牌的 FetchFunc.
+func FromAuthenticator(a authn.Authenticator, userID string) FetchFunc {
+	return func(ctx context.Context) (authn.IToken, error) {
+		return a.Sign(ctx, userID)
+	}
+}
+
+// 定义 TokenSource 的配置
+type options struct {
+	refreshBefore time.Duration // 在过期前多久开始刷新
+	jitter        time.Duration // 刷新时间的随机抖动，避免多个实例同时刷新
+	minBackoff    time.Duration // 获取失败后第一次重试的等待时间
+	maxBackoff    time.Duration // 重试等待时间的上限
+}
+
+// 定义默认配置
+var defaultOptions = options{
+	refreshBefore: time.Minute,
+	jitter:        10 * time.Second,
+	minBackoff:    time.Second,
+	maxBackoff:    time.Minute,
+}
+
+// Option 定义配置函数，用于选项模式
+type Option func(*options)
+
+// WithRefreshBefore 设置在令牌过期前多久开始刷新（默认 1 分钟）。
+func WithRefreshBefore(d time.Duration) Option {
+	return func(o *options) {
+		o.refreshBefore = d
+	}
+}
+
+// WithJitter 设置刷新时间的最大随机抖动（默认 10 秒）。
+func WithJitter(d time.Duration) Option {
+	return func(o *options) {
+		o.jitter = d
+	}
+}
+
+// WithRetryBackoff 设置获取失败后重试的等待时间，每次失败翻倍，最长为 max（默认 1 秒到 1 分钟）。
+func WithRetryBackoff(min, max time.Duration) Option {
+	return func(o *options) {
+		o.minBackoff = min
+		o.maxBackoff = max
+	}
+}
+
+// call 表示一次正在进行的刷新
+type call struct {
+	done  chan struct{}
+	token authn.IToken
+	err   error
+}
+
+// TokenSource 缓存一个令牌，并在过期前刷新，同一时刻只会有一个刷新请求.
+type TokenSource struct {
+	fetch FetchFunc
+	opts  *options
+
+	mu        sync.Mutex
+	token     authn.IToken  // 缓存的令牌
+	refreshAt time.Time     // 开始刷新的时间
+	call      *call         // 正在进行的刷新
+	err       error         // 最近一次获取失败的错误
+	backoff   time.Duration // 连续获取失败时的等待时间
+	retryAt   time.Time     // 获取失败后，在该时间之前不再获取
+}
+
+// New 创建一个新的 TokenSource 实例
+func New(fetch FetchFunc, opts ...Option) *TokenSource {
+	o := defaultOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &TokenSource{fetch: fetch, opts: &o}
+}
+
+// Token 返回一个可用的令牌.
+// 令牌进入刷新窗口但尚未过期时，在后台刷新并立即返回当前令牌；已经过期时等待刷新完成.
+// 获取失败后按有上限的指数回避算法重试，等待期间返回当前令牌，没有可用的令牌时返回上一次的错误.
+func (s *TokenSource) Token(ctx context.Context) (authn.IToken, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	token := s.token
+	if token != nil && now.Unix() >= token.GetExpiresAt() {
+		token = nil
+	}
+	if token != nil && now.Before(s.refreshAt) {
+		s.mu.Unlock()
+		return token, nil
+	}
+	if now.Before(s.retryAt) {
+		err := s.err
+		s.mu.Unlock()
+		if token != nil {
+			return token, nil
+		}
+		return nil, err
+	}
+	// 合并并发的刷新请求
+	c := s.call
+	if c == nil {
+		c = &call{done: make(chan struct{})}
+		s.call = c
+		// 刷新不应因为某一个调用方取消而中断，因此不继承取消信号
+		go s.refresh(context.WithoutCancel(ctx), c)
+	}
+	s.mu.Unlock()
+
+	// 当前令牌仍然有效，不等待刷新
+	if token != nil {
+		return token, nil
+	}
+
+	select {
+	case <-c.done:
+		return c.token, c.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Invalidate 在服务端拒绝 rejected（例如返回 401）时丢弃缓存的令牌，下一次 Token 会重新获取.
+// 缓存的令牌已经不是 rejected 时（例如已被并发的请求刷新）不做处理.
+func (s *TokenSource) Invalidate(rejected authn.IToken) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rejected != nil && s.token != nil && s.token.GetToken() == rejected.GetToken() {
+		s.token = nil
+	}
+}
+
+// refresh 获取新的令牌并更新缓存
+func (s *TokenSource) refresh(ctx context.Context, c *call) {
+	token, err := s.fetch(ctx)
+
+	s.mu.Lock()
+	if err == nil {
+		s.token = token
+		s.refreshAt = s.nextRefresh(token)
+		s.err, s.backoff, s.retryAt = nil, 0, time.Time{}
+	} else {
+		s.err = err
+		s.backoff = min(max(s.backoff*2, s.opts.minBackoff), s.opts.maxBackoff)
+		s.retryAt = time.Now().Add(s.backoff)
+	}
+	s.call = nil
+	s.mu.Unlock()
+
+	c.token, c.err = token, err
+	close(c.done)
+}
+
+// nextRefresh 计算下一次刷新的时间：过期时间 - refreshBefore - [0, jitter)
+func (s *TokenSource) nextRefresh(token authn.IToken) time.Time {
+	at := time.Unix(token.GetExpiresAt(), 0).Add(-s.opts.refreshBefore)
+	if s.opts.jitter > 0 {
+		at = at.Add(-time.Duration(rand.Int63n(int64(s.opts.jitter))))
+	}
+	return at
+}
+
+// authorization 返回 Authorization 头的值
+func authorization(token authn.IToken) string {
+	return token.GetTokenType() + " " + token.GetToken()
+}
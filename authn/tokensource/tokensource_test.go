@@ -0,0 +1,245 @@
+package tokensource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/LiangNing7/onex/pkg/authn"
+)
+
+// testToken 只实现测试需要的字段
+type testToken struct {
+	authn.IToken
+	token     string
+	expiresAt int64
+}
+
+func (t *testToken) GetToken() string     { return t.token }
+func (t *testToken) GetTokenType() string { return "Bearer" }
+func (t *testToken) GetExpiresAt() int64  { return t.expiresAt }
+
+// testFetcher 按顺序签发 token-1、token-2 ...，fail 不为空时返回错误
+type testFetcher struct {
+	calls atomic.Int32
+	ttl   time.Duration
+	fail  atomic.Pointer[error]
+	block chan struct{}
+}
+
+func (f *testFetcher) fetch(ctx context.Context) (authn.IToken, error) {
+	n := f.calls.Add(1)
+	if f.block != nil {
+		<-f.block
+	}
+	if err := f.fail.Load(); err != nil {
+		return nil, *err
+	}
+	return &testToken{token: fmt.Sprintf("token-%d", n), expiresAt: time.Now().Add(f.ttl).Unix()}, nil
+}
+
+func (f *testFetcher) setFail(err error) {
+	if err == nil {
+		f.fail.Store(nil)
+		return
+	}
+	f.fail.Store(&err)
+}
+
+func mustToken(t *testing.T, s *TokenSource) authn.IToken {
+	t.Helper()
+	token, err := s.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	return token
+}
+
+func TestTokenSourceCache(t *testing.T) {
+	f := &testFetcher{ttl: time.Hour}
+	s := New(f.fetch)
+
+	first := mustToken(t, s)
+	for i := 0; i < 10; i++ {
+		if got := mustToken(t, s); got != first {
+			t.Fatalf("Token() = %s, want cached %s", got.GetToken(), first.GetToken())
+		}
+	}
+	if n := f.calls.Load(); n != 1 {
+		t.Fatalf("fetch called %d times, want 1", n)
+	}
+}
+
+func TestTokenSourceConcurrentFetch(t *testing.T) {
+	f := &testFetcher{ttl: time.Hour, block: make(chan struct{})}
+	s := New(f.fetch)
+
+	var wg sync.WaitGroup
+	tokens := make([]authn.IToken, 10)
+	for i := range tokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tokens[i], _ = s.Token(context.Background())
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(f.block)
+	wg.Wait()
+
+	if n := f.calls.Load(); n != 1 {
+		t.Fatalf("fetch called %d times, want 1", n)
+	}
+	for _, token := range tokens {
+		if token == nil || token.GetToken() != "token-1" {
+			t.Fatalf("Token() = %v, want token-1", token)
+		}
+	}
+}
+
+func TestTokenSourceBackgroundRefresh(t *testing.T) {
+	// 令牌签发后立即进入刷新窗口
+	f := &testFetcher{ttl: 30 * time.Second}
+	s := New(f.fetch, WithJitter(0))
+
+	first := mustToken(t, s)
+	// 后台刷新期间返回当前令牌
+	if got := mustToken(t, s); got != first {
+		t.Fatalf("Token() = %s, want current token during refresh", got.GetToken())
+	}
+	deadline := time.Now().Add(time.Second)
+	for mustToken(t, s).GetToken() == first.GetToken() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := mustToken(t, s); got.GetToken() == first.GetToken() {
+		t.Fatal("token was not refreshed in the background")
+	}
+}
+
+func TestTokenSourceRetryBackoff(t *testing.T) {
+	const backoff = 50 * time.Millisecond
+	errFetch := errors.New("issuer unavailable")
+	f := &testFetcher{ttl: time.Hour}
+	f.setFail(errFetch)
+	s := New(f.fetch, WithRetryBackoff(backoff, 2*backoff))
+
+	// 没有可用的令牌时，回避期间直接返回上一次的错误
+	for i := 0; i < 5; i++ {
+		if _, err := s.Token(context.Background()); !errors.Is(err, errFetch) {
+			t.Fatalf("Token() error = %v, want %v", err, errFetch)
+		}
+	}
+	if n := f.calls.Load(); n != 1 {
+		t.Fatalf("fetch called %d times during backoff, want 1", n)
+	}
+
+	// 回避时间翻倍
+	time.Sleep(backoff)
+	if _, err := s.Token(context.Background()); !errors.Is(err, errFetch) {
+		t.Fatalf("Token() error = %v, want %v", err, errFetch)
+	}
+	time.Sleep(backoff)
+	if _, err := s.Token(context.Background()); !errors.Is(err, errFetch) {
+		t.Fatalf("Token() error = %v, want %v", err, errFetch)
+	}
+	if n := f.calls.Load(); n != 2 {
+		t.Fatalf("fetch called %d times, want 2 before doubled backoff elapsed", n)
+	}
+
+	f.setFail(nil)
+	time.Sleep(backoff)
+	if token := mustToken(t, s); token == nil {
+		t.Fatal("Token() returned nil after recovery")
+	}
+}
+
+func TestTokenSourceRefreshFailureKeepsToken(t *testing.T) {
+	const backoff = 50 * time.Millisecond
+	f := &testFetcher{ttl: 30 * time.Second}
+	s := New(f.fetch, WithJitter(0), WithRetryBackoff(backoff, backoff))
+
+	first := mustToken(t, s)
+	f.setFail(errors.New("issuer unavailable"))
+	// 后台刷新失败后，回避期间继续返回当前令牌且不再刷新
+	mustToken(t, s)
+	deadline := time.Now().Add(time.Second)
+	for f.calls.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	for i := 0; i < 5; i++ {
+		if got := mustToken(t, s); got != first {
+			t.Fatalf("Token() = %s, want current token", got.GetToken())
+		}
+	}
+	if n := f.calls.Load(); n != 2 {
+		t.Fatalf("fetch called %d times during backoff, want 2", n)
+	}
+}
+
+func TestTokenSourceInvalidate(t *testing.T) {
+	f := &testFetcher{ttl: time.Hour}
+	s := New(f.fetch)
+
+	first := mustToken(t, s)
+	// 其它令牌被拒绝时不丢弃缓存
+	s.Invalidate(&testToken{token: "stale"})
+	if got := mustToken(t, s); got != first {
+		t.Fatalf("Token() = %s, want %s", got.GetToken(), first.GetToken())
+	}
+
+	s.Invalidate(first)
+	second := mustToken(t, s)
+	if second.GetToken() == first.GetToken() {
+		t.Fatal("Token() returned the rejected token")
+	}
+	// 使用旧令牌的并发请求返回 401 时不丢弃新令牌
+	s.Invalidate(first)
+	if got := mustToken(t, s); got != second {
+		t.Fatalf("Token() = %s, want %s", got.GetToken(), second.GetToken())
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var reject atomic.Value
+	reject.Store("")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer "+reject.Load().(string) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer srv.Close()
+
+	f := &testFetcher{ttl: time.Hour}
+	cli := &http.Client{Transport: &Transport{Source: New(f.fetch)}}
+	get := func() (int, string) {
+		t.Helper()
+		resp, err := cli.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		defer resp.Body.Close()
+		var buf [64]byte
+		n, _ := resp.Body.Read(buf[:])
+		return resp.StatusCode, string(buf[:n])
+	}
+
+	if code, auth := get(); code != http.StatusOK || auth != "Bearer token-1" {
+		t.Fatalf("response = %d %q, want 200 with token-1", code, auth)
+	}
+	reject.Store("token-1")
+	if code, _ := get(); code != http.StatusUnauthorized {
+		t.Fatalf("response = %d, want 401", code)
+	}
+	if code, auth := get(); code != http.StatusOK || auth != "Bearer token-2" {
+		t.Fatalf("response = %d %q, want 200 with token-2", code, auth)
+	}
+}
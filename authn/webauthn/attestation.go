@@ -0,0 +1,99 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// 证明格式
+const (
+	AttestationNone   = "none"
+	AttestationPacked = "packed"
+)
+
+// oidAAGUID 证明证书中 AAGUID 扩展的 OID：id-fido-gen-ce-aaguid
+var oidAAGUID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 45724, 1, 1, 4}
+
+// attestationObject 注册时认证器返回的证明对象
+type attestationObject struct {
+	Format   string          `cbor:"fmt"`
+	AttStmt  cbor.RawMessage `cbor:"attStmt"`
+	AuthData []byte          `cbor:"authData"`
+}
+
+// packedStatement packed 格式的证明声明
+type packedStatement struct {
+	Alg int      `cbor:"alg"`
+	Sig []byte   `cbor:"sig"`
+	X5C [][]byte `cbor:"x5c,omitempty"`
+}
+
+// verifyAttestation 校验证明声明，clientDataJSON 为注册时的客户端数据.
+// packed 格式带有证书链时只校验证书本身的要求与签名，不校验证书链的信任锚.
+func verifyAttestation(obj *attestationObject, ad *authenticatorData, key *publicKey, clientDataJSON []byte) error {
+	switch obj.Format {
+	case AttestationNone:
+		var stmt map[string]any
+		if err := cbor.Unmarshal(obj.AttStmt, &stmt); err != nil || len(stmt) != 0 {
+			return errors.New("none attestation must have an empty statement")
+		}
+		return nil
+	case AttestationPacked:
+		var stmt packedStatement
+		if err := cbor.Unmarshal(obj.AttStmt, &stmt); err != nil {
+			return fmt.Errorf("decode packed statement: %w", err)
+		}
+		return verifyPacked(&stmt, ad, key, signedData(obj.AuthData, clientDataJSON))
+	default:
+		return fmt.Errorf("unsupported attestation format %q", obj.Format)
+	}
+}
+
+// verifyPacked 校验 packed 格式的证明：带证书时使用证书公钥，否则为自证明，使用凭证公钥
+func verifyPacked(stmt *packedStatement, ad *authenticatorData, key *publicKey, signed []byte) error {
+	if len(stmt.X5C) == 0 {
+		if stmt.Alg != key.alg {
+			return errors.New("self attestation algorithm does not match credential key")
+		}
+		return key.verify(signed, stmt.Sig)
+	}
+
+	cert, err := x509.ParseCertificate(stmt.X5C[0])
+	if err != nil {
+		return fmt.Errorf("parse attestation certificate: %w", err)
+	}
+	algorithm, ok := x509Algorithm(stmt.Alg)
+	if !ok {
+		return fmt.Errorf("unsupported algorithm %d", stmt.Alg)
+	}
+	if err := cert.CheckSignature(algorithm, signed, stmt.Sig); err != nil {
+		return fmt.Errorf("invalid attestation signature: %w", err)
+	}
+
+	// 证书的要求见 WebAuthn §8.2.1
+	if cert.Version != 3 {
+		return errors.New("attestation certificate must be version 3")
+	}
+	if !slices.Contains(cert.Subject.OrganizationalUnit, "Authenticator Attestation") {
+		return errors.New("attestation certificate has invalid subject OU")
+	}
+	if cert.BasicConstraintsValid && cert.IsCA {
+		return errors.New("attestation certificate must not be a CA")
+	}
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(oidAAGUID) {
+			continue
+		}
+		var aaguid []byte
+		if _, err := asn1.Unmarshal(ext.Value, &aaguid); err != nil || !bytes.Equal(aaguid, ad.aaguid) {
+			return errors.New("attestation certificate AAGUID mismatch")
+		}
+	}
+	return nil
+}
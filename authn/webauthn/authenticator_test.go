@@ -0,0 +1,246 @@
+package webauthn
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// 测试使用的依赖方配置
+const (
+	testRPID   = "example.com"
+	testOrigin = "https://example.com"
+	testUserID = "alice"
+)
+
+// testAAGUID 软件认证器的型号
+var testAAGUID = []byte("onex-test-aaguid")
+
+// testAuthenticator 软件实现的认证器，用于生成注册与登录的响应
+type testAuthenticator struct {
+	alg          int
+	key          crypto.Signer
+	credentialID []byte
+	signCount    uint32
+}
+
+// newTestAuthenticator 创建使用 alg 算法的认证器
+func newTestAuthenticator(t *testing.T, alg int) *testAuthenticator {
+	t.Helper()
+	var key crypto.Signer
+	var err error
+	switch alg {
+	case AlgES256:
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgEdDSA:
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	case AlgRS256:
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	default:
+		t.Fatalf("unsupported algorithm %d", alg)
+	}
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return &testAuthenticator{alg: alg, key: key, credentialID: id}
+}
+
+// coseKey 返回 COSE 编码的公钥
+func (a *testAuthenticator) coseKey(t *testing.T) []byte {
+	t.Helper()
+	var m map[int]any
+	switch pub := a.key.Public().(type) {
+	case *ecdsa.PublicKey:
+		m = map[int]any{coseLabelKty: coseKtyEC2, coseLabelAlg: a.alg, coseLabelCrv: coseCrvP256,
+			coseLabelX: pub.X.FillBytes(make([]byte, 32)), coseLabelY: pub.Y.FillBytes(make([]byte, 32))}
+	case ed25519.PublicKey:
+		m = map[int]any{coseLabelKty: coseKtyOKP, coseLabelAlg: a.alg, coseLabelCrv: coseCrvEd25519, coseLabelX: []byte(pub)}
+	case *rsa.PublicKey:
+		m = map[int]any{coseLabelKty: coseKtyRSA, coseLabelAlg: a.alg,
+			coseLabelN: pub.N.Bytes(), coseLabelE: big.NewInt(int64(pub.E)).Bytes()}
+	}
+	b, err := cbor.Marshal(m)
+	if err != nil {
+		t.Fatalf("encode COSE key: %v", err)
+	}
+	return b
+}
+
+// authData 返回认证器数据，attested 为 true 时包含凭证数据
+func (a *testAuthenticator) authData(t *testing.T, attested bool) []byte {
+	t.Helper()
+	hash := sha256.Sum256([]byte(testRPID))
+	data := append([]byte{}, hash[:]...)
+	flags := byte(flagUserPresent | flagUserVerified)
+	if attested {
+		flags |= flagAttestedCredData
+	}
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	if attested {
+		data = append(data, testAAGUID...)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+		data = append(data, a.credentialID...)
+		data = append(data, a.coseKey(t)...)
+	}
+	return data
+}
+
+// sign 使用 key 按 alg 算法签名
+func sign(t *testing.T, key crypto.Signer, alg int, message []byte) []byte {
+	t.Helper()
+	var sig []byte
+	var err error
+	if alg == AlgEdDSA {
+		sig, err = key.Sign(rand.Reader, message, crypto.Hash(0))
+	} else {
+		digest := sha256.Sum256(message)
+		sig, err = key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return sig
+}
+
+// clientData 返回客户端数据
+func clientData(t *testing.T, typ string, challenge []byte) []byte {
+	t.Helper()
+	b, err := json.Marshal(collectedClientData{Type: typ, Challenge: base64.RawURLEncoding.EncodeToString(challenge), Origin: testOrigin})
+	if err != nil {
+		t.Fatalf("encode client data: %v", err)
+	}
+	return b
+}
+
+// register 生成注册响应，format 为 none、packed（自证明）或 packed-x5c（证书证明）
+func (a *testAuthenticator) register(t *testing.T, challenge []byte, format string) *RegistrationResponse {
+	t.Helper()
+	cdj := clientData(t, clientDataCreate, challenge)
+	authData := a.authData(t, true)
+
+	obj := map[string]any{"fmt": format, "authData": authData, "attStmt": map[string]any{}}
+	switch format {
+	case AttestationNone:
+	case AttestationPacked:
+		obj["attStmt"] = map[string]any{"alg": a.alg, "sig": sign(t, a.key, a.alg, signedData(authData, cdj))}
+	case "packed-x5c":
+		cert, key := attestationCert(t)
+		obj["fmt"] = AttestationPacked
+		obj["attStmt"] = map[string]any{"alg": AlgES256, "sig": sign(t, key, AlgES256, signedData(authData, cdj)), "x5c": [][]byte{cert}}
+	}
+	attObj, err := cbor.Marshal(obj)
+	if err != nil {
+		t.Fatalf("encode attestation object: %v", err)
+	}
+
+	resp := &RegistrationResponse{ID: base64.RawURLEncoding.EncodeToString(a.credentialID), RawID: a.credentialID, Type: "public-key"}
+	resp.Response.ClientDataJSON = cdj
+	resp.Response.AttestationObject = attObj
+	resp.Response.Transports = []string{"internal"}
+	return resp
+}
+
+// login 生成登录断言，签名计数器加一
+func (a *testAuthenticator) login(t *testing.T, challenge []byte) *AssertionResponse {
+	t.Helper()
+	a.signCount++
+	cdj := clientData(t, clientDataGet, challenge)
+	authData := a.authData(t, false)
+
+	resp := &AssertionResponse{ID: base64.RawURLEncoding.EncodeToString(a.credentialID), RawID: a.credentialID, Type: "public-key"}
+	resp.Response.ClientDataJSON = cdj
+	resp.Response.AuthenticatorData = authData
+	resp.Response.Signature = sign(t, a.key, a.alg, signedData(authData, cdj))
+	resp.Response.UserHandle = URLEncoded(testUserID)
+	return resp
+}
+
+// attestationCert 生成满足 WebAuthn §8.2.1 要求的自签名证明证书
+func attestationCert(t *testing.T) ([]byte, crypto.Signer) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	aaguid, _ := asn1.Marshal(testAAGUID)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{Country: []string{"CN"}, Organization: []string{"OneX"}, OrganizationalUnit: []string{"Authenticator Attestation"}, CommonName: "OneX Test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(10, 0, 0),
+		BasicConstraintsValid: true,
+		ExtraExtensions:       []pkix.Extension{{Id: oidAAGUID, Value: aaguid}},
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	return cert, key
+}
+
+// testStore 内存中的 CredentialStore
+type testStore struct {
+	mu          sync.Mutex
+	credentials map[string]*Credential
+}
+
+func newTestStore() *testStore {
+	return &testStore{credentials: make(map[string]*Credential)}
+}
+
+func (s *testStore) SaveCredential(_ context.Context, credential *Credential) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := *credential
+	s.credentials[string(credential.ID)] = &c
+	return nil
+}
+
+func (s *testStore) GetCredential(_ context.Context, id []byte) (*Credential, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.credentials[string(id)]
+	if !ok {
+		return nil, nil
+	}
+	cc := *c
+	return &cc, nil
+}
+
+func (s *testStore) ListCredentials(_ context.Context, userID string) ([]*Credential, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []*Credential
+	for _, c := range s.credentials {
+		if c.UserID == userID {
+			cc := *c
+			list = append(list, &cc)
+		}
+	}
+	return list, nil
+}
+
+// newTestRelyingParty 创建测试使用的依赖方
+func newTestRelyingParty(store CredentialStore, opts ...Option) *RelyingParty {
+	return New(Config{RPID: testRPID, RPName: "OneX", Origins: []string{testOrigin}}, store, nil, opts...)
+}
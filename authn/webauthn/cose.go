@@ -0,0 +1,199 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// COSE 密钥类型与曲线
+const (
+	coseKtyOKP = 1
+	coseKtyEC2 = 2
+	coseKtyRSA = 3
+
+	coseCrvP256    = 1
+	coseCrvP384    = 2
+	coseCrvP521    = 3
+	coseCrvEd25519 = 6
+)
+
+// COSE 密钥的标签
+const (
+	coseLabelKty = 1
+	coseLabelAlg = 3
+	coseLabelCrv = -1 // EC2、OKP 的曲线
+	coseLabelN   = -1 // RSA 的模数
+	coseLabelX   = -2 // EC2、OKP 的 x 坐标
+	coseLabelE   = -2 // RSA 的指数
+	coseLabelY   = -3 // EC2 的 y 坐标
+)
+
+// publicKey 凭证公钥
+type publicKey struct {
+	alg int
+	key crypto.PublicKey
+}
+
+// parsePublicKey 解析 COSE 编码的公钥
+func parsePublicKey(data []byte) (*publicKey, error) {
+	var m map[int]any
+	if err := cbor.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("decode COSE key: %w", err)
+	}
+	kty, _ := coseInt(m, coseLabelKty)
+	alg, ok := coseInt(m, coseLabelAlg)
+	if !ok {
+		return nil, errors.New("COSE key has no algorithm")
+	}
+
+	pk := &publicKey{alg: alg}
+	switch kty {
+	case coseKtyEC2:
+		crv, _ := coseInt(m, coseLabelCrv)
+		x, y := coseBytes(m, coseLabelX), coseBytes(m, coseLabelY)
+		key, err := ecPublicKey(alg, crv, x, y)
+		if err != nil {
+			return nil, err
+		}
+		pk.key = key
+	case coseKtyOKP:
+		crv, _ := coseInt(m, coseLabelCrv)
+		x := coseBytes(m, coseLabelX)
+		if alg != AlgEdDSA || crv != coseCrvEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid OKP key")
+		}
+		pk.key = ed25519.PublicKey(x)
+	case coseKtyRSA:
+		n, e := coseBytes(m, coseLabelN), coseBytes(m, coseLabelE)
+		if (alg != AlgRS256 && alg != AlgPS256) || len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("invalid RSA key")
+		}
+		pk.key = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	default:
+		return nil, fmt.Errorf("unsupported COSE key type %d", kty)
+	}
+	return pk, nil
+}
+
+// ecPublicKey 根据算法与曲线创建 ECDSA 公钥，并校验点是否在曲线上
+func ecPublicKey(alg, crv int, x, y []byte) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	var ec ecdh.Curve
+	switch {
+	case alg == AlgES256 && crv == coseCrvP256:
+		curve, ec = elliptic.P256(), ecdh.P256()
+	case alg == AlgES384 && crv == coseCrvP384:
+		curve, ec = elliptic.P384(), ecdh.P384()
+	case alg == AlgES512 && crv == coseCrvP521:
+		curve, ec = elliptic.P521(), ecdh.P521()
+	default:
+		return nil, fmt.Errorf("unsupported EC2 algorithm %d with curve %d", alg, crv)
+	}
+
+	size := (curve.Params().BitSize + 7) / 8
+	if len(x) != size || len(y) != size {
+		return nil, errors.New("invalid EC2 coordinates")
+	}
+	point := append(append([]byte{4}, x...), y...)
+	if _, err := ec.NewPublicKey(point); err != nil {
+		return nil, fmt.Errorf("invalid EC2 point: %w", err)
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+}
+
+// verify 使用公钥校验签名，ECDSA 签名为 ASN.1 DER 编码
+func (pk *publicKey) verify(message, sig []byte) error {
+	algorithm, ok := x509Algorithm(pk.alg)
+	if !ok {
+		return fmt.Errorf("unsupported algorithm %d", pk.alg)
+	}
+	return checkSignature(algorithm, pk.key, message, sig)
+}
+
+// x509Algorithm 返回 COSE 算法对应的 x509.SignatureAlgorithm
+func x509Algorithm(alg int) (x509.SignatureAlgorithm, bool) {
+	switch alg {
+	case AlgES256:
+		return x509.ECDSAWithSHA256, true
+	case AlgES384:
+		return x509.ECDSAWithSHA384, true
+	case AlgES512:
+		return x509.ECDSAWithSHA512, true
+	case AlgEdDSA:
+		return x509.PureEd25519, true
+	case AlgRS256:
+		return x509.SHA256WithRSA, true
+	case AlgPS256:
+		return x509.SHA256WithRSAPSS, true
+	}
+	return x509.UnknownSignatureAlgorithm, false
+}
+
+// checkSignature 使用公钥校验签名
+func checkSignature(algorithm x509.SignatureAlgorithm, key crypto.PublicKey, message, sig []byte) error {
+	var hash crypto.Hash
+	switch algorithm {
+	case x509.ECDSAWithSHA256, x509.SHA256WithRSA, x509.SHA256WithRSAPSS:
+		hash = crypto.SHA256
+	case x509.ECDSAWithSHA384:
+		hash = crypto.SHA384
+	case x509.ECDSAWithSHA512:
+		hash = crypto.SHA512
+	}
+	digest := message
+	if hash != 0 {
+		h := hash.New()
+		h.Write(message)
+		digest = h.Sum(nil)
+	}
+
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		if hash == 0 || !ecdsa.VerifyASN1(key, digest, sig) {
+			return errors.New("invalid ECDSA signature")
+		}
+	case ed25519.PublicKey:
+		if algorithm != x509.PureEd25519 || !ed25519.Verify(key, message, sig) {
+			return errors.New("invalid Ed25519 signature")
+		}
+	case *rsa.PublicKey:
+		switch algorithm {
+		case x509.SHA256WithRSA:
+			return rsa.VerifyPKCS1v15(key, hash, digest, sig)
+		case x509.SHA256WithRSAPSS:
+			return rsa.VerifyPSS(key, hash, digest, sig, nil)
+		default:
+			return errors.New("invalid RSA algorithm")
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", key)
+	}
+	return nil
+}
+
+// coseInt 读取整数类型的标签
+func coseInt(m map[int]any, label int) (int, bool) {
+	switch v := m[label].(type) {
+	case uint64:
+		return int(v), true
+	case int64:
+		return int(v), true
+	}
+	return 0, false
+}
+
+// coseBytes 读取字节串类型的标签
+func coseBytes(m map[int]any, label int) []byte {
+	b, _ := m[label].([]byte)
+	return b
+}
@@ -0,0 +1,149 @@
+package webauthn
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/LiangNing7/onex/pkg/authn"
+)
+
+// RequestOptions 发送给浏览器 navigator.credentials.get 的 publicKey 参数.
+type RequestOptions struct {
+	Challenge        URLEncoded             `json:"challenge"`
+	Timeout          int64                  `json:"timeout"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials,omitempty"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// AssertionResponse 浏览器 navigator.credentials.get 返回的 PublicKeyCredential 的 JSON 形式.
+type AssertionResponse struct {
+	ID       string     `json:"id"`
+	RawID    URLEncoded `json:"rawId"`
+	Type     string     `json:"type"`
+	Response struct {
+		ClientDataJSON    URLEncoded `json:"clientDataJSON"`
+		AuthenticatorData URLEncoded `json:"authenticatorData"`
+		Signature         URLEncoded `json:"signature"`
+		UserHandle        URLEncoded `json:"userHandle,omitempty"`
+	} `json:"response"`
+}
+
+// BeginLogin 生成登录挑战，返回发送给浏览器的参数与需要保存到 FinishLogin 的 SessionData.
+// userID 为空时使用可发现凭证（通行密钥）登录，由认证器选择凭证.
+func (rp *RelyingParty) BeginLogin(ctx context.Context, userID string) (*RequestOptions, *SessionData, error) {
+	session, err := rp.newSession(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts := &RequestOptions{
+		Challenge:        session.Challenge,
+		Timeout:          rp.timeout(),
+		RPID:             rp.cfg.RPID,
+		UserVerification: rp.opts.userVerification,
+	}
+	if userID != "" {
+		credentials, err := rp.store.ListCredentials(ctx, userID)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, c := range credentials {
+			opts.AllowCredentials = append(opts.AllowCredentials, CredentialDescriptor{Type: "public-key", ID: c.ID, Transports: c.Transports})
+			session.AllowedCredentials = append(session.AllowedCredentials, c.ID)
+		}
+	}
+	return opts, session, nil
+}
+
+// FinishLogin 校验浏览器返回的断言，成功后为凭证所属的用户签发令牌.
+func (rp *RelyingParty) FinishLogin(ctx context.Context, session *SessionData, resp *AssertionResponse) (authn.IToken, error) {
+	credential, err := rp.VerifyLogin(ctx, session, resp)
+	if err != nil {
+		return nil, err
+	}
+	return rp.auth.Sign(ctx, credential.UserID)
+}
+
+// VerifyLogin 校验浏览器返回的断言并更新签名计数器，返回使用的凭证.
+// session 的挑战在校验后被清空，不能再次使用.
+func (rp *RelyingParty) VerifyLogin(ctx context.Context, session *SessionData, resp *AssertionResponse) (*Credential, error) {
+	challenge, err := session.consume()
+	if err != nil {
+		return nil, fail(ctx, ErrSessionExpired, MessageSessionExpired, err)
+	}
+	if !rp.opts.clock.Now().Before(session.ExpiresAt) {
+		return nil, fail(ctx, ErrSessionExpired, MessageSessionExpired, errors.New("challenge expired"))
+	}
+
+	credential, err := rp.store.GetCredential(ctx, resp.RawID)
+	if err != nil {
+		return nil, err
+	}
+	if credential == nil {
+		return nil, fail(ctx, ErrCredentialNotFound, MessageCredentialNotFound, errors.New("unknown credential"))
+	}
+
+	signCount, err := rp.verifyAssertion(session, challenge, credential, resp)
+	if err != nil {
+		return nil, fail(ctx, ErrAssertionFailed, MessageAssertionFailed, err)
+	}
+
+	// 签名计数器必须增长，两者都为 0 表示认证器不支持计数器
+	if (signCount != 0 || credential.SignCount != 0) && signCount <= credential.SignCount {
+		return nil, fail(ctx, ErrSignCountInvalid, MessageSignCountInvalid,
+			fmt.Errorf("sign count %d, stored %d", signCount, credential.SignCount))
+	}
+	credential.SignCount = signCount
+	credential.LastUsedAt = rp.opts.clock.Now()
+	if err := rp.store.SaveCredential(ctx, credential); err != nil {
+		return nil, err
+	}
+	return credential, nil
+}
+
+// verifyAssertion 按 WebAuthn §7.2 校验断言，返回认证器的签名计数器
+func (rp *RelyingParty) verifyAssertion(session *SessionData, challenge []byte, credential *Credential, resp *AssertionResponse) (uint32, error) {
+	if resp.Type != "public-key" {
+		return 0, fmt.Errorf("credential type %q", resp.Type)
+	}
+	if len(session.AllowedCredentials) > 0 && !slices.ContainsFunc(session.AllowedCredentials, func(id URLEncoded) bool {
+		return bytes.Equal(id, resp.RawID)
+	}) {
+		return 0, errors.New("credential is not allowed")
+	}
+
+	// 凭证必须属于发起登录的用户，可发现凭证登录时必须返回 userHandle
+	userHandle := string(resp.Response.UserHandle)
+	if session.UserID != "" && credential.UserID != session.UserID {
+		return 0, errors.New("credential does not belong to user")
+	}
+	if session.UserID == "" && userHandle == "" {
+		return 0, errors.New("user handle is required")
+	}
+	if userHandle != "" && userHandle != credential.UserID {
+		return 0, errors.New("user handle mismatch")
+	}
+
+	if err := rp.verifyClientData(resp.Response.ClientDataJSON, clientDataGet, challenge); err != nil {
+		return 0, err
+	}
+	ad, err := parseAuthenticatorData(resp.Response.AuthenticatorData)
+	if err != nil {
+		return 0, err
+	}
+	if err := ad.verify(rp.cfg.RPID, session.UserVerification); err != nil {
+		return 0, err
+	}
+
+	key, err := parsePublicKey(credential.PublicKey)
+	if err != nil {
+		return 0, err
+	}
+	if err := key.verify(signedData(ad.raw, resp.Response.ClientDataJSON), resp.Response.Signature); err != nil {
+		return 0, err
+	}
+	return ad.signCount, nil
+}
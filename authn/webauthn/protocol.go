@@ -0,0 +1,169 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// URLEncoded 以 base64url 编码的字节，JSON 编码时不带填充，解码时兼容填充.
+type URLEncoded []byte
+
+// MarshalJSON 编码为 base64url 字符串.
+func (u URLEncoded) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(u))
+}
+
+// UnmarshalJSON 从 base64url 字符串解码.
+func (u *URLEncoded) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return err
+	}
+	*u = b
+	return nil
+}
+
+// 客户端数据的类型
+const (
+	clientDataCreate = "webauthn.create"
+	clientDataGet    = "webauthn.get"
+)
+
+// 认证器数据的标志位
+const (
+	flagUserPresent      = 0x01 // UP，用户在场
+	flagUserVerified     = 0x04 // UV，用户已验证
+	flagAttestedCredData = 0x40 // AT，包含凭证数据
+	flagExtensionData    = 0x80 // ED，包含扩展数据
+)
+
+// collectedClientData 浏览器收集的客户端数据
+type collectedClientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin,omitempty"`
+}
+
+// consume 取出挑战并清空，同一个 SessionData 不能再次使用
+func (s *SessionData) consume() ([]byte, error) {
+	challenge := s.Challenge
+	s.Challenge = nil
+	if len(challenge) == 0 {
+		return nil, errors.New("challenge already used")
+	}
+	return challenge, nil
+}
+
+// verifyClientData 校验客户端数据的类型、挑战与来源
+func (rp *RelyingParty) verifyClientData(raw []byte, typ string, challenge []byte) error {
+	var cd collectedClientData
+	if err := json.Unmarshal(raw, &cd); err != nil {
+		return fmt.Errorf("decode client data: %w", err)
+	}
+	if cd.Type != typ {
+		return fmt.Errorf("client data type %q, want %q", cd.Type, typ)
+	}
+	got, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(cd.Challenge, "="))
+	if err != nil || subtle.ConstantTimeCompare(got, challenge) != 1 {
+		return errors.New("challenge mismatch")
+	}
+	if !slices.Contains(rp.cfg.Origins, cd.Origin) {
+		return fmt.Errorf("origin %q is not allowed", cd.Origin)
+	}
+	if cd.CrossOrigin {
+		return errors.New("cross-origin requests are not allowed")
+	}
+	return nil
+}
+
+// authenticatorData 认证器数据
+type authenticatorData struct {
+	raw          []byte
+	rpIDHash     []byte
+	flags        byte
+	signCount    uint32
+	aaguid       []byte
+	credentialID []byte
+	publicKey    []byte // COSE 编码的公钥
+}
+
+// parseAuthenticatorData 解析认证器数据：rpIdHash(32) || flags(1) || signCount(4) || [凭证数据] || [扩展]
+func parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < 37 {
+		return nil, errors.New("authenticator data too short")
+	}
+	ad := &authenticatorData{
+		raw:       data,
+		rpIDHash:  data[:32],
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	rest := data[37:]
+
+	if ad.flags&flagAttestedCredData != 0 {
+		if len(rest) < 18 {
+			return nil, errors.New("attested credential data too short")
+		}
+		ad.aaguid = rest[:16]
+		n := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if len(rest) < n {
+			return nil, errors.New("credential id too short")
+		}
+		ad.credentialID, rest = rest[:n], rest[n:]
+
+		var key cbor.RawMessage
+		var err error
+		if rest, err = cbor.UnmarshalFirst(rest, &key); err != nil {
+			return nil, fmt.Errorf("decode credential public key: %w", err)
+		}
+		ad.publicKey = key
+	}
+	if ad.flags&flagExtensionData != 0 {
+		var ext cbor.RawMessage
+		var err error
+		if rest, err = cbor.UnmarshalFirst(rest, &ext); err != nil {
+			return nil, fmt.Errorf("decode extensions: %w", err)
+		}
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("unexpected trailing authenticator data")
+	}
+	return ad, nil
+}
+
+// verify 校验依赖方标识与用户在场、用户验证标志
+func (ad *authenticatorData) verify(rpID, userVerification string) error {
+	hash := sha256.Sum256([]byte(rpID))
+	if !bytes.Equal(ad.rpIDHash, hash[:]) {
+		return errors.New("rp id hash mismatch")
+	}
+	if ad.flags&flagUserPresent == 0 {
+		return errors.New("user not present")
+	}
+	if userVerification == UserVerificationRequired && ad.flags&flagUserVerified == 0 {
+		return errors.New("user not verified")
+	}
+	return nil
+}
+
+// signedData 返回认证器签名的数据：authenticatorData || SHA-256(clientDataJSON)
+func signedData(authData, clientDataJSON []byte) []byte {
+	hash := sha256.Sum256(clientDataJSON)
+	return append(slices.Clip(authData), hash[:]...)
+}
@@ -0,0 +1,170 @@
+package webauthn
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// User 注册凭证的用户.
+type User struct {
+	ID          string // 用户标识，作为 user.id 与断言中的 userHandle
+	Name        string // 用户名
+	DisplayName string // 展示名称
+}
+
+// CredentialDescriptor 凭证描述.
+type CredentialDescriptor struct {
+	Type       string     `json:"type"`
+	ID         URLEncoded `json:"id"`
+	Transports []string   `json:"transports,omitempty"`
+}
+
+// CreationOptions 发送给浏览器 navigator.credentials.create 的 publicKey 参数.
+type CreationOptions struct {
+	Challenge URLEncoded `json:"challenge"`
+	RP        struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"rp"`
+	User struct {
+		ID          URLEncoded `json:"id"`
+		Name        string     `json:"name"`
+		DisplayName string     `json:"displayName"`
+	} `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials,omitempty"`
+	AuthenticatorSelection struct {
+		ResidentKey      string `json:"residentKey"`
+		UserVerification string `json:"userVerification"`
+	} `json:"authenticatorSelection"`
+	Attestation string `json:"attestation"`
+}
+
+// CredentialParameter 支持的凭证类型与算法.
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+// RegistrationResponse 浏览器 navigator.credentials.create 返回的 PublicKeyCredential 的 JSON 形式.
+type RegistrationResponse struct {
+	ID       string     `json:"id"`
+	RawID    URLEncoded `json:"rawId"`
+	Type     string     `json:"type"`
+	Response struct {
+		ClientDataJSON    URLEncoded `json:"clientDataJSON"`
+		AttestationObject URLEncoded `json:"attestationObject"`
+		Transports        []string   `json:"transports,omitempty"`
+	} `json:"response"`
+}
+
+// BeginRegistration 为用户生成注册挑战，返回发送给浏览器的参数与需要保存到 FinishRegistration 的 SessionData.
+// 用户已经注册的凭证会被排除，避免在同一个认证器上重复注册.
+func (rp *RelyingParty) BeginRegistration(ctx context.Context, user User) (*CreationOptions, *SessionData, error) {
+	session, err := rp.newSession(user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	existing, err := rp.store.ListCredentials(ctx, user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opts := &CreationOptions{Challenge: session.Challenge, Timeout: rp.timeout(), Attestation: rp.opts.attestation}
+	opts.RP.ID, opts.RP.Name = rp.cfg.RPID, rp.cfg.RPName
+	opts.User.ID, opts.User.Name, opts.User.DisplayName = URLEncoded(user.ID), user.Name, user.DisplayName
+	for _, alg := range rp.opts.algorithms {
+		opts.PubKeyCredParams = append(opts.PubKeyCredParams, CredentialParameter{Type: "public-key", Alg: alg})
+	}
+	for _, c := range existing {
+		opts.ExcludeCredentials = append(opts.ExcludeCredentials, CredentialDescriptor{Type: "public-key", ID: c.ID, Transports: c.Transports})
+	}
+	// 通行密钥需要可发现凭证，以便不输入用户名直接登录
+	opts.AuthenticatorSelection.ResidentKey = "preferred"
+	opts.AuthenticatorSelection.UserVerification = rp.opts.userVerification
+	return opts, session, nil
+}
+
+// FinishRegistration 校验浏览器返回的注册结果并保存凭证，session 的挑战在校验后被清空，不能再次使用.
+func (rp *RelyingParty) FinishRegistration(ctx context.Context, session *SessionData, resp *RegistrationResponse) (*Credential, error) {
+	credential, err := rp.verifyRegistration(ctx, session, resp)
+	if err != nil {
+		return nil, fail(ctx, ErrRegistrationFailed, MessageRegistrationFailed, err)
+	}
+	if err := rp.store.SaveCredential(ctx, credential); err != nil {
+		return nil, err
+	}
+	return credential, nil
+}
+
+// verifyRegistration 按 WebAuthn §7.1 校验注册结果
+func (rp *RelyingParty) verifyRegistration(ctx context.Context, session *SessionData, resp *RegistrationResponse) (*Credential, error) {
+	challenge, err := session.consume()
+	if err != nil {
+		return nil, err
+	}
+	if !rp.opts.clock.Now().Before(session.ExpiresAt) {
+		return nil, errors.New("challenge expired")
+	}
+	if resp.Type != "public-key" {
+		return nil, fmt.Errorf("credential type %q", resp.Type)
+	}
+	if err := rp.verifyClientData(resp.Response.ClientDataJSON, clientDataCreate, challenge); err != nil {
+		return nil, err
+	}
+
+	var obj attestationObject
+	if err := cbor.Unmarshal(resp.Response.AttestationObject, &obj); err != nil {
+		return nil, fmt.Errorf("decode attestation object: %w", err)
+	}
+	ad, err := parseAuthenticatorData(obj.AuthData)
+	if err != nil {
+		return nil, err
+	}
+	if err := ad.verify(rp.cfg.RPID, session.UserVerification); err != nil {
+		return nil, err
+	}
+	if ad.flags&flagAttestedCredData == 0 {
+		return nil, errors.New("no attested credential data")
+	}
+	if !bytes.Equal(ad.credentialID, resp.RawID) {
+		return nil, errors.New("credential id mismatch")
+	}
+
+	key, err := parsePublicKey(ad.publicKey)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(rp.opts.algorithms, key.alg) {
+		return nil, fmt.Errorf("algorithm %d is not allowed", key.alg)
+	}
+	if err := verifyAttestation(&obj, ad, key, resp.Response.ClientDataJSON); err != nil {
+		return nil, err
+	}
+
+	// 同一个凭证不能被注册两次
+	existing, err := rp.store.GetCredential(ctx, ad.credentialID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.New("credential already registered")
+	}
+
+	return &Credential{
+		ID:                ad.credentialID,
+		UserID:            session.UserID,
+		PublicKey:         ad.publicKey,
+		SignCount:         ad.signCount,
+		AAGUID:            ad.aaguid,
+		Transports:        resp.Response.Transports,
+		AttestationFormat: obj.Format,
+		CreatedAt:         rp.opts.clock.Now(),
+	}, nil
+}
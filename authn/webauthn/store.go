@@ -0,0 +1,29 @@
+package webauthn
+
+import (
+	"context"
+	"time"
+)
+
+// Credential 已注册的凭证.
+type Credential struct {
+	ID                URLEncoded `json:"id"`                   // 凭证标识
+	UserID            string     `json:"userId"`               // 所属用户
+	PublicKey         []byte     `json:"publicKey"`            // COSE 编码的公钥
+	SignCount         uint32     `json:"signCount"`            // 签名计数器
+	AAGUID            []byte     `json:"aaguid,omitempty"`     // 认证器型号
+	Transports        []string   `json:"transports,omitempty"` // 认证器支持的传输方式
+	AttestationFormat string     `json:"attestationFormat"`    // 注册时的证明格式
+	CreatedAt         time.Time  `json:"createdAt"`            // 注册时间
+	LastUsedAt        time.Time  `json:"lastUsedAt,omitempty"` // 最后一次登录时间
+}
+
+// CredentialStore 凭证存储接口.
+type CredentialStore interface {
+	// SaveCredential 保存新注册或更新后的凭证
+	SaveCredential(ctx context.Context, credential *Credential) error
+	// GetCredential 根据凭证标识获取凭证，凭证不存在时返回 nil
+	GetCredential(ctx context.Context, id []byte) (*Credential, error)
+	// ListCredentials 列出用户的所有凭证
+	ListCredentials(ctx context.Context, userID string) ([]*Credential, error)
+}
@@ -0,0 +1,172 @@
+// Package webauthn 实现 WebAuthn（通行密钥）依赖方：生成注册与登录的挑战，校验 none/packed 证明与断言，
+// 校验签名计数器，登录成功后通过 authn.Authenticator 签发令牌.
+package webauthn
+
+import (
+	"context"
+	"crypto/rand"
+	"time"
+
+	"github.com/LiangNing7/onex/pkg/authn"
+	"github.com/LiangNing7/onex/pkg/i18n"
+	"github.com/go-kratos/kratos/v2/errors"
+	goi18n "github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+// 用户验证的要求
+const (
+	UserVerificationRequired    = "required"
+	UserVerificationPreferred   = "preferred"
+	UserVerificationDiscouraged = "discouraged"
+)
+
+// COSE 算法标识
+const (
+	AlgES256 = -7
+	AlgEdDSA = -8
+	AlgES384 = -35
+	AlgES512 = -36
+	AlgPS256 = -37
+	AlgRS256 = -257
+)
+
+// challengeLength 挑战的随机字节数
+const challengeLength = 32
+
+// 定义错误类型
+var (
+	// ErrRegistrationFailed 表示注册凭证校验失败
+	ErrRegistrationFailed = errors.BadRequest("BadRequest", "Passkey registration failed")
+	// ErrAssertionFailed 表示登录断言校验失败
+	ErrAssertionFailed = errors.Unauthorized("Unauthorized", "Passkey authentication failed")
+	// ErrCredentialNotFound 表示凭证不存在
+	ErrCredentialNotFound = errors.Unauthorized("Unauthorized", "Passkey is not registered")
+	// ErrSignCountInvalid 表示签名计数器没有增长，认证器可能被克隆
+	ErrSignCountInvalid = errors.Unauthorized("Unauthorized", "Passkey sign counter is invalid, the authenticator may be cloned")
+	// ErrSessionExpired 表示挑战已经过期
+	ErrSessionExpired = errors.Unauthorized("Unauthorized", "Passkey challenge has expired")
+)
+
+// 定义 I18n 的消息
+var (
+	MessageRegistrationFailed = &goi18n.Message{ID: "webauthn.registration.failed", Other: ErrRegistrationFailed.Message}
+	MessageAssertionFailed    = &goi18n.Message{ID: "webauthn.assertion.failed", Other: ErrAssertionFailed.Message}
+	MessageCredentialNotFound = &goi18n.Message{ID: "webauthn.credential.notfound", Other: ErrCredentialNotFound.Message}
+	MessageSignCountInvalid   = &goi18n.Message{ID: "webauthn.signcount.invalid", Other: ErrSignCountInvalid.Message}
+	MessageSessionExpired     = &goi18n.Message{ID: "webauthn.session.expired", Other: ErrSessionExpired.Message}
+)
+
+// Config 依赖方的配置
+type Config struct {
+	RPID    string   // 依赖方标识，通常为不带端口的域名，例如 example.com
+	RPName  string   // 展示给用户的依赖方名称
+	Origins []string // 允许的来源，例如 https://example.com
+}
+
+// 定义依赖方的配置
+type options struct {
+	timeout          time.Duration // 挑战的有效期
+	userVerification string        // 用户验证的要求
+	attestation      string        // 证明的传递方式
+	algorithms       []int         // 支持的 COSE 算法，按优先级排序
+	clock            authn.Clock   // 时钟
+}
+
+// 定义默认配置
+var defaultOptions = options{
+	timeout:          5 * time.Minute,
+	userVerification: UserVerificationPreferred,
+	attestation:      "none",
+	algorithms:       []int{AlgES256, AlgEdDSA, AlgRS256},
+	clock:            authn.SystemClock,
+}
+
+// Option 定义配置函数，用于选项模式
+type Option func(*options)
+
+// WithTimeout 设置挑战的有效期（默认 5 分钟）。
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithUserVerification 设置用户验证的要求（默认 preferred），为 required 时认证器必须验证用户（PIN、生物识别）。
+func WithUserVerification(requirement string) Option {
+	return func(o *options) {
+		o.userVerification = requirement
+	}
+}
+
+// WithAttestation 设置证明的传递方式（默认 none），可选 none、indirect、direct。
+func WithAttestation(conveyance string) Option {
+	return func(o *options) {
+		o.attestation = conveyance
+	}
+}
+
+// WithAlgorithms 设置支持的 COSE 算法，按优先级排序（默认 ES256、EdDSA、RS256）。
+func WithAlgorithms(algorithms ...int) Option {
+	return func(o *options) {
+		o.algorithms = algorithms
+	}
+}
+
+// WithClock 设置判断挑战是否过期使用的时钟（默认 authn.SystemClock）。
+func WithClock(clock authn.Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// SessionData 保存一次注册或登录的挑战，需要由调用方在 Begin 与 Finish 之间保存（例如服务端会话或 Redis）.
+// 挑战只能使用一次：Finish 会清空传入的 SessionData 的挑战，序列化保存时调用方必须在调用 Finish 之前
+// 原子地读取并删除（例如 Redis GETDEL），否则同一个挑战可以被重放.
+type SessionData struct {
+	Challenge          URLEncoded   `json:"challenge"`                    // 挑战
+	UserID             string       `json:"userId,omitempty"`             // 用户标识，可发现凭证登录时为空
+	AllowedCredentials []URLEncoded `json:"allowedCredentials,omitempty"` // 允许使用的凭证
+	UserVerification   string       `json:"userVerification"`             // 用户验证的要求
+	ExpiresAt          time.Time    `json:"expiresAt"`                    // 过期时间
+}
+
+// RelyingParty WebAuthn 依赖方.
+type RelyingParty struct {
+	cfg   Config
+	store CredentialStore
+	auth  authn.Authenticator
+	opts  *options
+}
+
+// New 创建一个新的 RelyingParty 实例，auth 用于登录成功后签发令牌
+func New(cfg Config, store CredentialStore, auth authn.Authenticator, opts ...Option) *RelyingParty {
+	o := defaultOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &RelyingParty{cfg: cfg, store: store, auth: auth, opts: &o}
+}
+
+// newSession 生成新的挑战
+func (rp *RelyingParty) newSession(userID string) (*SessionData, error) {
+	challenge := make([]byte, challengeLength)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, err
+	}
+	return &SessionData{
+		Challenge:        challenge,
+		UserID:           userID,
+		UserVerification: rp.opts.userVerification,
+		ExpiresAt:        rp.opts.clock.Now().Add(rp.opts.timeout),
+	}, nil
+}
+
+// timeout 返回以毫秒为单位的有效期
+func (rp *RelyingParty) timeout() int64 {
+	return rp.opts.timeout.Milliseconds()
+}
+
+// fail 返回本地化的错误，cause 保存具体的校验失败原因
+func fail(ctx context.Context, e *errors.Error, message *goi18n.Message, cause error) error {
+	return errors.New(int(e.Code), e.Reason, i18n.FromContext(ctx).LocalizeT(message)).WithCause(cause)
+}
@@ -0,0 +1,245 @@
+package webauthn
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+)
+
+// update 为 true 时使用软件认证器重新生成 testdata 中的响应
+var update = flag.Bool("update", false, "regenerate testdata fixtures")
+
+// fixture 录制的一次注册与登录
+type fixture struct {
+	Registration struct {
+		Challenge URLEncoded            `json:"challenge"`
+		Response  *RegistrationResponse `json:"response"`
+	} `json:"registration"`
+	Login struct {
+		Challenge URLEncoded         `json:"challenge"`
+		Response  *AssertionResponse `json:"response"`
+	} `json:"login"`
+}
+
+// fixtures 录制的证明格式与算法
+var fixtures = []struct {
+	name   string
+	format string
+	alg    int
+}{
+	{"none-es256", AttestationNone, AlgES256},
+	{"none-eddsa", AttestationNone, AlgEdDSA},
+	{"none-rs256", AttestationNone, AlgRS256},
+	{"packed-es256", AttestationPacked, AlgES256},
+	{"packed-eddsa", AttestationPacked, AlgEdDSA},
+	{"packed-rs256", AttestationPacked, AlgRS256},
+	{"packed-x5c-es256", "packed-x5c", AlgES256},
+	{"packed-x5c-eddsa", "packed-x5c", AlgEdDSA},
+	{"packed-x5c-rs256", "packed-x5c", AlgRS256},
+}
+
+// loadFixture 读取 testdata 中的响应，update 为 true 时重新生成
+func loadFixture(t *testing.T, name, format string, alg int) *fixture {
+	t.Helper()
+	path := filepath.Join("testdata", name+".json")
+	if *update {
+		f := &fixture{}
+		a := newTestAuthenticator(t, alg)
+		f.Registration.Challenge = []byte(name + "-registration-challenge")
+		f.Registration.Response = a.register(t, f.Registration.Challenge, format)
+		f.Login.Challenge = []byte(name + "-login-challenge")
+		f.Login.Response = a.login(t, f.Login.Challenge)
+		data, err := json.MarshalIndent(f, "", "  ")
+		if err != nil {
+			t.Fatalf("encode fixture: %v", err)
+		}
+		if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+			t.Fatalf("write fixture: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	f := &fixture{}
+	if err := json.Unmarshal(data, f); err != nil {
+		t.Fatalf("decode fixture: %v", err)
+	}
+	return f
+}
+
+// isError 判断 err 是否为 want，错误共用 Unauthorized 原因，因此同时比较消息
+func isError(err error, want *errors.Error) bool {
+	e := errors.FromError(err)
+	return e != nil && stderrors.Is(err, want) && e.Message == want.Message
+}
+
+// session 返回使用 challenge 的 SessionData
+func session(challenge []byte) *SessionData {
+	return &SessionData{
+		Challenge:        append([]byte{}, challenge...),
+		UserID:           testUserID,
+		UserVerification: UserVerificationRequired,
+		ExpiresAt:        time.Now().Add(time.Minute),
+	}
+}
+
+func TestFixtures(t *testing.T) {
+	for _, tt := range fixtures {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			f := loadFixture(t, tt.name, tt.format, tt.alg)
+			rp := newTestRelyingParty(newTestStore())
+
+			credential, err := rp.FinishRegistration(ctx, session(f.Registration.Challenge), f.Registration.Response)
+			if err != nil {
+				t.Fatalf("FinishRegistration() error = %v", errors.FromError(err).Unwrap())
+			}
+			if credential.AttestationFormat != AttestationNone && credential.AttestationFormat != AttestationPacked {
+				t.Fatalf("attestation format = %q", credential.AttestationFormat)
+			}
+			if string(credential.AAGUID) != string(testAAGUID) || credential.UserID != testUserID {
+				t.Fatalf("credential = %+v, want AAGUID %q and user %q", credential, testAAGUID, testUserID)
+			}
+
+			used, err := rp.VerifyLogin(ctx, session(f.Login.Challenge), f.Login.Response)
+			if err != nil {
+				t.Fatalf("VerifyLogin() error = %v", errors.FromError(err).Unwrap())
+			}
+			if used.SignCount != 1 {
+				t.Fatalf("sign count = %d, want 1", used.SignCount)
+			}
+
+			// 重放同一个断言时签名计数器没有增长
+			if _, err := rp.VerifyLogin(ctx, session(f.Login.Challenge), f.Login.Response); !isError(err, ErrSignCountInvalid) {
+				t.Fatalf("VerifyLogin() replay error = %v, want %v", err, ErrSignCountInvalid)
+			}
+		})
+	}
+}
+
+func TestRegistrationRejected(t *testing.T) {
+	ctx := context.Background()
+	f := loadFixture(t, "packed-es256", AttestationPacked, AlgES256)
+
+	tests := []struct {
+		name   string
+		rp     *RelyingParty
+		modify func(s *SessionData, r *RegistrationResponse)
+	}{
+		{"wrong challenge", nil, func(s *SessionData, _ *RegistrationResponse) { s.Challenge = []byte("other") }},
+		{"expired", nil, func(s *SessionData, _ *RegistrationResponse) { s.ExpiresAt = time.Now().Add(-time.Second) }},
+		{"wrong origin", New(Config{RPID: testRPID, Origins: []string{"https://evil.example"}}, newTestStore(), nil), nil},
+		{"wrong rp id", New(Config{RPID: "evil.example", Origins: []string{testOrigin}}, newTestStore(), nil), nil},
+		{"algorithm not allowed", newTestRelyingParty(newTestStore(), WithAlgorithms(AlgRS256)), nil},
+		{"credential id mismatch", nil, func(_ *SessionData, r *RegistrationResponse) { r.RawID = []byte("other") }},
+		{"tampered client data", nil, func(_ *SessionData, r *RegistrationResponse) {
+			r.Response.ClientDataJSON = append(append(URLEncoded{}, r.Response.ClientDataJSON[:len(r.Response.ClientDataJSON)-1]...), ' ', '}')
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := tt.rp
+			if rp == nil {
+				rp = newTestRelyingParty(newTestStore())
+			}
+			var resp RegistrationResponse
+			data, _ := json.Marshal(f.Registration.Response)
+			_ = json.Unmarshal(data, &resp)
+			s := session(f.Registration.Challenge)
+			if tt.modify != nil {
+				tt.modify(s, &resp)
+			}
+			if _, err := rp.FinishRegistration(ctx, s, &resp); !isError(err, ErrRegistrationFailed) {
+				t.Fatalf("FinishRegistration() error = %v, want %v", err, ErrRegistrationFailed)
+			}
+		})
+	}
+}
+
+func TestSessionSingleUse(t *testing.T) {
+	ctx := context.Background()
+	rp := newTestRelyingParty(newTestStore())
+	a := newTestAuthenticator(t, AlgES256)
+
+	// 同一个 SessionData 注册两次
+	_, s, err := rp.BeginRegistration(ctx, User{ID: testUserID, Name: testUserID})
+	if err != nil {
+		t.Fatalf("BeginRegistration() error = %v", err)
+	}
+	challenge := append([]byte{}, s.Challenge...)
+	if _, err := rp.FinishRegistration(ctx, s, a.register(t, challenge, AttestationNone)); err != nil {
+		t.Fatalf("FinishRegistration() error = %v", errors.FromError(err).Unwrap())
+	}
+	if _, err := rp.FinishRegistration(ctx, s, a.register(t, challenge, AttestationNone)); !isError(err, ErrRegistrationFailed) {
+		t.Fatalf("FinishRegistration() with a used session error = %v, want %v", err, ErrRegistrationFailed)
+	}
+
+	// 同一个 SessionData 登录两次，第二次的断言签名计数器仍然增长
+	_, s, err = rp.BeginLogin(ctx, testUserID)
+	if err != nil {
+		t.Fatalf("BeginLogin() error = %v", err)
+	}
+	challenge = append([]byte{}, s.Challenge...)
+	if _, err := rp.VerifyLogin(ctx, s, a.login(t, challenge)); err != nil {
+		t.Fatalf("VerifyLogin() error = %v", errors.FromError(err).Unwrap())
+	}
+	if _, err := rp.VerifyLogin(ctx, s, a.login(t, challenge)); !isError(err, ErrSessionExpired) {
+		t.Fatalf("VerifyLogin() with a used session error = %v, want %v", err, ErrSessionExpired)
+	}
+}
+
+func TestLoginRejected(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore()
+	rp := newTestRelyingParty(store)
+	a := newTestAuthenticator(t, AlgEdDSA)
+	challenge := []byte("registration")
+	if _, err := rp.FinishRegistration(ctx, session(challenge), a.register(t, challenge, AttestationPacked)); err != nil {
+		t.Fatalf("FinishRegistration() error = %v", errors.FromError(err).Unwrap())
+	}
+	other := newTestAuthenticator(t, AlgEdDSA)
+
+	tests := []struct {
+		name string
+		want *errors.Error
+		run  func(s *SessionData) (*SessionData, *AssertionResponse)
+	}{
+		{"unknown credential", ErrCredentialNotFound, func(s *SessionData) (*SessionData, *AssertionResponse) {
+			return s, other.login(t, s.Challenge)
+		}},
+		{"wrong user", ErrAssertionFailed, func(s *SessionData) (*SessionData, *AssertionResponse) {
+			s.UserID = "bob"
+			return s, a.login(t, s.Challenge)
+		}},
+		{"not allowed", ErrAssertionFailed, func(s *SessionData) (*SessionData, *AssertionResponse) {
+			s.AllowedCredentials = []URLEncoded{other.credentialID}
+			return s, a.login(t, s.Challenge)
+		}},
+		{"forged signature", ErrAssertionFailed, func(s *SessionData) (*SessionData, *AssertionResponse) {
+			resp := a.login(t, s.Challenge)
+			resp.Response.Signature[0] ^= 0xff
+			return s, resp
+		}},
+		{"expired", ErrSessionExpired, func(s *SessionData) (*SessionData, *AssertionResponse) {
+			s.ExpiresAt = time.Now().Add(-time.Second)
+			return s, a.login(t, s.Challenge)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, resp := tt.run(session([]byte("login-" + tt.name)))
+			if _, err := rp.VerifyLogin(ctx, s, resp); !isError(err, tt.want) {
+				t.Fatalf("VerifyLogin() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
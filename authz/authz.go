@@ -0,0 +1,105 @@
+// Package authz 在 authn 的基础上提供授权能力，回答 "你是否可以这样做" 的问题.
+package authz
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/golang-jwt/jwt/v4"
+	goi18n "github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+// reason 保存错误原因.
+const reason string = "Forbidden"
+
+// 定义错误类型
+var (
+	// ErrForbidden 表示没有访问权限
+	ErrForbidden = errors.Forbidden(reason, "Permission denied")
+	// ErrInsufficientScope 表示令牌的授权范围不足
+	ErrInsufficientScope = errors.Forbidden(reason, "Insufficient scope")
+)
+
+// 定义 I18n 的消息
+var (
+	MessageForbidden         = &goi18n.Message{ID: "authz.forbidden", Other: ErrForbidden.Message}
+	MessageInsufficientScope = &goi18n.Message{ID: "authz.scope.insufficient", Other: ErrInsufficientScope.Message}
+)
+
+// Policy 定义了授权策略需要实现的方法.
+type Policy interface {
+	// Authorize 判断主体是否拥有指定的权限.
+	Authorize(ctx context.Context, subject string, permission string) (bool, error)
+}
+
+// PolicyFunc 将普通函数适配为 Policy.
+type PolicyFunc func(ctx context.Context, subject string, permission string) (bool, error)
+
+// Authorize 调用函数本身.
+func (f PolicyFunc) Authorize(ctx context.Context, subject string, permission string) (bool, error) {
+	return f(ctx, subject, permission)
+}
+
+// scopeClaims 用于读取令牌中的授权范围声明.
+type scopeClaims struct {
+	jwt.RegisteredClaims
+	Scope string   `json:"scope,omitempty"` // RFC 8693 中以空格分隔的授权范围
+	Scp   []string `json:"scp,omitempty"`   // 部分签发方使用的数组形式
+}
+
+// ScopesFromToken 读取令牌中的 scope/scp 声明.
+// 该函数不会校验签名，调用前必须已经通过 Authenticator.ParseClaims 校验过令牌.
+func ScopesFromToken(token string) ([]string, error) {
+	claims := &scopeClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(token, claims); err != nil {
+		return nil, err
+	}
+	scopes := strings.Fields(claims.Scope)
+	return append(scopes, claims.Scp...), nil
+}
+
+// HasScopes 检查已授予的范围是否包含全部所需的范围.
+func HasScopes(granted []string, required ...string) bool {
+	set := make(map[string]struct{}, len(granted))
+	for _, scope := range granted {
+		set[scope] = struct{}{}
+	}
+	for _, scope := range required {
+		if _, ok := set[scope]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchPermission 判断已授予的权限是否覆盖所需的权限.
+// 权限以 ":" 分段，如 "post:read"；"*" 可以匹配任意一段，位于末尾时匹配剩余所有段.
+func MatchPermission(granted, required string) bool {
+	gs := strings.Split(granted, ":")
+	rs := strings.Split(required, ":")
+	for i, g := range gs {
+		// 末尾的通配符匹配剩余所有段
+		if g == "*" && i == len(gs)-1 {
+			return true
+		}
+		if i >= len(rs) || (g != "*" && g != rs[i]) {
+			return false
+		}
+	}
+	return len(gs) == len(rs)
+}
+
+// claimsKey 定义在 `context.Context` 中查找令牌声明的类型
+type claimsKey struct{}
+
+// NewContext 将已认证的令牌声明保存到 Context 中.
+func NewContext(ctx context.Context, claims *jwt.RegisteredClaims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// FromContext 从 Context 中获取已认证的令牌声明.
+func FromContext(ctx context.Context) (*jwt.RegisteredClaims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*jwt.RegisteredClaims)
+	return claims, ok
+}
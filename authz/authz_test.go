@@ -0,0 +1,329 @@
+package authz
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LiangNing7/onex/pkg/authn/jwt"
+	"github.com/LiangNing7/onex/pkg/authn/jwt/jwttest"
+	"github.com/LiangNing7/onex/pkg/i18n"
+	"github.com/gin-gonic/gin"
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/transport"
+	"golang.org/x/text/language"
+)
+
+func TestMatchPermission(t *testing.T) {
+	tests := []struct {
+		granted  string
+		required string
+		want     bool
+	}{
+		{"post:read", "post:read", true},
+		{"post:read", "post:write", false},
+		{"post:*", "post:read", true},
+		{"post:*", "post:comment:read", true}, // 末尾的通配符匹配剩余所有段
+		{"post:*", "post", true},              // 剩余的段可以为空
+		{"*", "order:read", true},
+		{"*:read", "post:read", true},
+		{"*:read", "post:write", false},
+		{"*:read", "post:comment:read", false}, // 中间的通配符只匹配一段
+		{"post:*:read", "post:comment:read", true},
+		{"post:read", "post:read:draft", false},
+		{"post:read:draft", "post:read", false},
+	}
+	for _, tt := range tests {
+		if got := MatchPermission(tt.granted, tt.required); got != tt.want {
+			t.Errorf("MatchPermission(%q, %q) = %v, want %v", tt.granted, tt.required, got, tt.want)
+		}
+	}
+}
+
+func TestScopes(t *testing.T) {
+	_, minter := jwttest.New(jwttest.NewClock(time.Now()))
+	token := minter.Sign(t, &scopeClaims{Scope: "post:read post:write", Scp: []string{"order:read"}})
+
+	granted, err := ScopesFromToken(token)
+	if err != nil {
+		t.Fatalf("ScopesFromToken() error = %v", err)
+	}
+	if want := []string{"post:read", "post:write", "order:read"}; !reflect.DeepEqual(granted, want) {
+		t.Fatalf("ScopesFromToken() = %v, want %v", granted, want)
+	}
+
+	tests := []struct {
+		required []string
+		want     bool
+	}{
+		{nil, true},
+		{[]string{"post:read"}, true},
+		{[]string{"post:read", "order:read"}, true},
+		{[]string{"post:read", "order:write"}, false},
+		{[]string{"post:*"}, false}, // 授权范围按字面匹配
+	}
+	for _, tt := range tests {
+		if got := HasScopes(granted, tt.required...); got != tt.want {
+			t.Errorf("HasScopes(%v) = %v, want %v", tt.required, got, tt.want)
+		}
+	}
+
+	if _, err := ScopesFromToken("not-a-token"); err == nil {
+		t.Fatal("ScopesFromToken() error = nil for a malformed token")
+	}
+}
+
+// newTestI18n 返回加载了中文错误消息的 I18n
+func newTestI18n(t *testing.T) *i18n.I18n {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "zh.yaml")
+	content := "authz.forbidden: '没有访问权限'\nauthz.scope.insufficient: '授权范围不足'\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write locale: %v", err)
+	}
+	return i18n.New(i18n.WithFile(path), i18n.WithLanguage(language.Chinese))
+}
+
+// middlewareCase 描述一次经过中间件的请求
+type middlewareCase struct {
+	name        string
+	token       string
+	operation   string
+	wantCode    int
+	wantReason  string
+	wantMessage string
+}
+
+// newMiddlewareCases 签发测试令牌并返回 gin 与 Kratos 中间件共用的用例
+func newMiddlewareCases(t *testing.T, a *jwt.JWTAuth, read, write string) []middlewareCase {
+	t.Helper()
+	ctx := context.Background()
+	sign := func(subject string, scopes ...string) string {
+		t.Helper()
+		token, err := a.SignWith(ctx, subject, jwt.WithScope(scopes...))
+		if err != nil {
+			t.Fatalf("SignWith() error = %v", err)
+		}
+		return token.GetToken()
+	}
+	bob := sign("bob", "post")
+	return []middlewareCase{
+		{"allowed", bob, read, http.StatusOK, "", ""},
+		{"permission denied", bob, write, http.StatusForbidden, reason, "没有访问权限"},
+		{"insufficient scope", sign("bob", "order"), read, http.StatusForbidden, reason, "授权范围不足"},
+		{"missing token", "", read, http.StatusUnauthorized, "", ""},
+		{"invalid token", bob + "x", read, http.StatusUnauthorized, "", ""},
+	}
+}
+
+func newTestPolicy(t *testing.T) (*jwt.JWTAuth, *RBAC) {
+	t.Helper()
+	a, _ := jwttest.New(jwttest.NewClock(time.Now()))
+	r := NewRBAC()
+	if err := r.AddRole(Role{Name: "viewer", Permissions: []string{"post:read"}}); err != nil {
+		t.Fatalf("AddRole() error = %v", err)
+	}
+	if err := r.Bind("bob", "viewer"); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	return a, r
+}
+
+func TestGin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	a, p := newTestPolicy(t)
+	permissions := map[string]string{
+		"GET /posts/{id}":    "post:read",
+		"DELETE /posts/{id}": "post:delete",
+	}
+
+	tr := newTestI18n(t)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(i18n.NewContext(c.Request.Context(), tr))
+	})
+	router.Use(Gin(a, p, WithScopes("post"), WithPermissionFunc(func(_ context.Context, operation string) string {
+		return permissions[operation]
+	})))
+	handler := func(c *gin.Context) {
+		claims, ok := FromContext(c.Request.Context())
+		if !ok || claims.Subject != "bob" {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+	router.GET("/posts/:id", handler)
+	router.DELETE("/posts/:id", handler)
+
+	for _, tt := range newMiddlewareCases(t, a, http.MethodGet, http.MethodDelete) {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.operation, "/posts/1", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if tt.wantReason == "" {
+				return
+			}
+			var body struct {
+				Reason  string `json:"reason"`
+				Message string `json:"message"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode body %q: %v", w.Body.String(), err)
+			}
+			if body.Reason != tt.wantReason || body.Message != tt.wantMessage {
+				t.Fatalf("body = %s, want reason %q and message %q", w.Body.String(), tt.wantReason, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestGinDefaultPermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	a, p := newTestPolicy(t)
+	// 默认使用操作名作为权限，路由参数不会被当作权限的分段
+	if err := p.AddRole(Role{Name: "viewer", Permissions: []string{"GET /posts/{id}", "GET /files/{path}"}}); err != nil {
+		t.Fatalf("AddRole() error = %v", err)
+	}
+	token, err := a.Sign(context.Background(), "bob")
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	router := gin.New()
+	router.Use(Gin(a, p))
+	for _, path := range []string{"/posts/:id", "/posts/:id/comments", "/files/*path"} {
+		router.GET(path, func(c *gin.Context) { c.Status(http.StatusOK) })
+		router.DELETE(path, func(c *gin.Context) { c.Status(http.StatusOK) })
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{http.MethodGet, "/posts/1", http.StatusOK},
+		{http.MethodGet, "/files/a/b.txt", http.StatusOK},
+		{http.MethodDelete, "/posts/1", http.StatusForbidden},
+		{http.MethodGet, "/posts/1/comments", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req.Header.Set("Authorization", "Bearer "+token.GetToken())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("%s %s status = %d, want %d", tt.method, tt.path, w.Code, tt.want)
+		}
+	}
+}
+
+func TestNilPolicy(t *testing.T) {
+	a, _ := newTestPolicy(t)
+	token, err := a.Sign(context.Background(), "bob")
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	// 没有配置 Policy 时，需要权限的操作被拒绝，只需要认证的操作放行
+	for permission, want := range map[string]int{"post:read": http.StatusForbidden, "": http.StatusOK} {
+		o := newOptions(WithPermissionFunc(func(context.Context, string) string { return permission }))
+		_, err := o.authorize(context.Background(), a, nil, token.GetToken(), "GET /posts/{id}")
+		if code := errors.Code(err); code != want {
+			t.Fatalf("authorize() with permission %q error = %v, want code %d", permission, err, want)
+		}
+	}
+}
+
+// testTransport 实现 transport.Transporter，用于测试 Kratos 中间件
+type testTransport struct {
+	operation string
+	header    headerCarrier
+}
+
+func (tr *testTransport) Kind() transport.Kind            { return transport.KindGRPC }
+func (tr *testTransport) Endpoint() string                { return "" }
+func (tr *testTransport) Operation() string               { return tr.operation }
+func (tr *testTransport) RequestHeader() transport.Header { return tr.header }
+func (tr *testTransport) ReplyHeader() transport.Header   { return headerCarrier{} }
+
+// headerCarrier 将 http.Header 适配为 transport.Header
+type headerCarrier http.Header
+
+func (hc headerCarrier) Get(key string) string      { return http.Header(hc).Get(key) }
+func (hc headerCarrier) Set(key, value string)      { http.Header(hc).Set(key, value) }
+func (hc headerCarrier) Add(key, value string)      { http.Header(hc).Add(key, value) }
+func (hc headerCarrier) Values(key string) []string { return http.Header(hc).Values(key) }
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range hc {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func TestServer(t *testing.T) {
+	a, p := newTestPolicy(t)
+	const (
+		readOp  = "/blog.v1.Post/GetPost"
+		writeOp = "/blog.v1.Post/DeletePost"
+	)
+	permission := func(_ context.Context, operation string) string {
+		// 例如 "/blog.v1.Post/GetPost" -> "post:getpost"
+		return "post:" + strings.ToLower(operation[strings.LastIndex(operation, "/")+1:])
+	}
+	if err := p.AddRole(Role{Name: "viewer", Permissions: []string{permission(context.Background(), readOp)}}); err != nil {
+		t.Fatalf("AddRole() error = %v", err)
+	}
+
+	handler := Server(a, p, WithScopes("post"), WithPermissionFunc(permission))(func(ctx context.Context, req any) (any, error) {
+		claims, ok := FromContext(ctx)
+		if !ok {
+			return nil, errors.InternalServer("NoClaims", "claims not found in context")
+		}
+		return claims.Subject, nil
+	})
+	tr := newTestI18n(t)
+
+	for _, tt := range newMiddlewareCases(t, a, readOp, writeOp) {
+		t.Run(tt.name, func(t *testing.T) {
+			header := headerCarrier{}
+			if tt.token != "" {
+				header.Set("Authorization", "Bearer "+tt.token)
+			}
+			ctx := transport.NewServerContext(i18n.NewContext(context.Background(), tr), &testTransport{operation: tt.operation, header: header})
+			reply, err := handler(ctx, nil)
+
+			if code := errors.Code(err); code != tt.wantCode {
+				t.Fatalf("error = %v, want code %d", err, tt.wantCode)
+			}
+			if err == nil {
+				if reply != "bob" {
+					t.Fatalf("reply = %v, want bob", reply)
+				}
+				return
+			}
+			if tt.wantReason == "" {
+				return
+			}
+			e := errors.FromError(err)
+			if e.Reason != tt.wantReason || e.Message != tt.wantMessage {
+				t.Fatalf("error = %v, want reason %q and message %q", err, tt.wantReason, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package authz
+
+import (
+	"strings"
+
+	"github.com/LiangNing7/onex/pkg/authn"
+	"github.com/gin-gonic/gin"
+	"github.com/go-kratos/kratos/v2/errors"
+)
+
+// Gin 返回用于 gin 的认证授权中间件.
+// 操作名的格式为 "<METHOD> <路由模板>"，路由参数写作 "{name}"，例如 "GET /v1/users/{id}"，
+// 因此默认的权限映射不会把路由中的 ":" 当作权限的分隔符.
+func Gin(a authn.Authenticator, p Policy, opts ...Option) gin.HandlerFunc {
+	o := newOptions(opts...)
+	return func(c *gin.Context) {
+		token := o.extractor(c.Request.Header)
+		operation := c.Request.Method + " " + routeTemplate(c.FullPath())
+		ctx, err := o.authorize(c.Request.Context(), a, p, token, operation)
+		if err != nil {
+			e := errors.FromError(err)
+			c.AbortWithStatusJSON(int(e.Code), e)
+			return
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// routeTemplate 将 gin 的路由参数 ":id" 与 "*path" 改写为 "{id}" 与 "{path}"
+func routeTemplate(path string) string {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*") {
+			segments[i] = "{" + seg[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,29 @@
+package authz
+
+import (
+	"context"
+
+	"github.com/LiangNing7/onex/pkg/authn"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// Server 返回用于 Kratos 服务端的认证授权中间件.
+// 操作名为 transport.Transporter 的 Operation，例如 "/helloworld.v1.Greeter/SayHello".
+func Server(a authn.Authenticator, p Policy, opts ...Option) middleware.Middleware {
+	o := newOptions(opts...)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			var token, operation string
+			if tr, ok := transport.FromServerContext(ctx); ok {
+				token = o.extractor(tr.RequestHeader())
+				operation = tr.Operation()
+			}
+			ctx, err := o.authorize(ctx, a, p, token, operation)
+			if err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}
+	}
+}
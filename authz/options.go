@@ -0,0 +1,99 @@
+package authz
+
+import (
+	"context"
+
+	"github.com/LiangNing7/onex/pkg/authn"
+	"github.com/LiangNing7/onex/pkg/i18n"
+	"github.com/go-kratos/kratos/v2/errors"
+	goi18n "github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+// PermissionFunc 根据请求的操作返回所需的权限，返回空字符串表示不需要检查权限.
+// 权限以 ":" 分段（见 MatchPermission），操作名本身不会包含 ":".
+type PermissionFunc func(ctx context.Context, operation string) string
+
+// 定义中间件的配置
+type options struct {
+	permissionFunc PermissionFunc  // 权限映射函数
+	scopes         []string        // 令牌必须包含的授权范围
+	extractor      authn.Extractor // 令牌提取函数
+}
+
+// Option 定义配置函数，用于选项模式
+type Option func(*options)
+
+// WithPermissionFunc 设置操作到权限的映射函数（默认直接使用操作名作为权限）。
+func WithPermissionFunc(fn PermissionFunc) Option {
+	return func(o *options) {
+		o.permissionFunc = fn
+	}
+}
+
+// WithScopes 设置令牌必须包含的授权范围。
+func WithScopes(scopes ...string) Option {
+	return func(o *options) {
+		o.scopes = scopes
+	}
+}
+
+// WithExtractor 设置从请求头中提取令牌的函数（默认 authn.BearerToken）。
+func WithExtractor(fn authn.Extractor) Option {
+	return func(o *options) {
+		o.extractor = fn
+	}
+}
+
+// newOptions 创建默认配置并应用配置函数
+func newOptions(opts ...Option) *options {
+	o := &options{
+		permissionFunc: func(_ context.Context, operation string) string {
+			return operation
+		},
+		extractor: authn.BearerToken,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// authorize 认证令牌并检查授权范围和权限，成功时返回携带令牌声明的 Context
+func (o *options) authorize(ctx context.Context, a authn.Authenticator, p Policy, token, operation string) (context.Context, error) {
+	// 认证令牌，失败时直接返回 authn 的错误
+	claims, err := a.ParseClaims(ctx, token)
+	if err != nil {
+		return ctx, err
+	}
+	ctx = NewContext(ctx, claims)
+
+	// 检查授权范围
+	if len(o.scopes) > 0 {
+		granted, err := ScopesFromToken(token)
+		if err != nil || !HasScopes(granted, o.scopes...) {
+			return ctx, forbidden(ctx, MessageInsufficientScope)
+		}
+	}
+
+	// 检查权限，没有配置 Policy 时拒绝所有需要权限的操作
+	permission := o.permissionFunc(ctx, operation)
+	if permission == "" {
+		return ctx, nil
+	}
+	if p == nil {
+		return ctx, forbidden(ctx, MessageForbidden)
+	}
+	ok, err := p.Authorize(ctx, claims.Subject, permission)
+	if err != nil {
+		return ctx, err
+	}
+	if !ok {
+		return ctx, forbidden(ctx, MessageForbidden)
+	}
+	return ctx, nil
+}
+
+// forbidden 返回本地化的 Forbidden 错误
+func forbidden(ctx context.Context, message *goi18n.Message) error {
+	return errors.Forbidden(reason, i18n.FromContext(ctx).LocalizeT(message))
+}
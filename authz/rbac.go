@@ -0,0 +1,251 @@
+package authz
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Role 定义一个角色，角色可以继承其他角色的权限.
+type Role struct {
+	Name        string   `json:"name" yaml:"name"`                             // 角色名称
+	Permissions []string `json:"permissions,omitempty" yaml:"permissions"`     // 角色直接拥有的权限
+	Inherits    []string `json:"inherits,omitempty" yaml:"inherits,omitempty"` // 继承的角色
+}
+
+// RBACConfig 定义 RBAC 策略文件的结构.
+type RBACConfig struct {
+	Roles    []Role              `json:"roles" yaml:"roles"`       // 角色列表
+	Bindings map[string][]string `json:"bindings" yaml:"bindings"` // 主体与角色的绑定关系
+}
+
+// RBAC 是基于角色的 Policy 实现.
+type RBAC struct {
+	mu       sync.RWMutex
+	roles    map[string]Role
+	bindings map[string][]string
+}
+
+var _ Policy = (*RBAC)(nil)
+
+// NewRBAC 创建一个空的 RBAC 实例.
+func NewRBAC() *RBAC {
+	return &RBAC{
+		roles:    make(map[string]Role),
+		bindings: make(map[string][]string),
+	}
+}
+
+// NewRBACFromConfig 根据 RBACConfig 创建 RBAC 实例，并检查未定义的角色和循环继承.
+func NewRBACFromConfig(cfg *RBACConfig) (*RBAC, error) {
+	r := NewRBAC()
+	for _, role := range cfg.Roles {
+		if role.Name == "" {
+			return nil, fmt.Errorf("role name is empty")
+		}
+		if _, ok := r.roles[role.Name]; ok {
+			return nil, fmt.Errorf("duplicate role %q", role.Name)
+		}
+		r.roles[role.Name] = role
+	}
+	for subject, roles := range cfg.Bindings {
+		r.bindings[subject] = append([]string(nil), roles...)
+	}
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ParseRBAC 解析指定格式的策略内容，支持 yaml 和 json，默认为 yaml.
+func ParseRBAC(data []byte, format string) (*RBAC, error) {
+	cfg := &RBACConfig{}
+	var err error
+	switch format {
+	case "json":
+		err = json.Unmarshal(data, cfg)
+	default:
+		err = yaml.Unmarshal(data, cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse rbac policy: %w", err)
+	}
+	return NewRBACFromConfig(cfg)
+}
+
+// LoadRBAC 从文件加载策略，根据文件扩展名判断格式.
+func LoadRBAC(path string) (*RBAC, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRBAC(data, strings.TrimPrefix(filepath.Ext(path), "."))
+}
+
+// AddRole 添加或替换一个角色，继承的角色必须已经存在.
+func (r *RBAC) AddRole(role Role) error {
+	if role.Name == "" {
+		return fmt.Errorf("role name is empty")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// 先写入，校验失败时回滚
+	old, existed := r.roles[role.Name]
+	r.roles[role.Name] = role
+	if err := r.validate(); err != nil {
+		if existed {
+			r.roles[role.Name] = old
+		} else {
+			delete(r.roles, role.Name)
+		}
+		return err
+	}
+	return nil
+}
+
+// Bind 为主体绑定角色.
+func (r *RBAC) Bind(subject string, roles ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, role := range roles {
+		if _, ok := r.roles[role]; !ok {
+			return fmt.Errorf("undefined role %q", role)
+		}
+	}
+	for _, role := range roles {
+		if !contains(r.bindings[subject], role) {
+			r.bindings[subject] = append(r.bindings[subject], role)
+		}
+	}
+	return nil
+}
+
+// Unbind 解除主体与角色的绑定.
+func (r *RBAC) Unbind(subject string, roles ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.bindings[subject][:0]
+	for _, role := range r.bindings[subject] {
+		if !contains(roles, role) {
+			kept = append(kept, role)
+		}
+	}
+	if len(kept) == 0 {
+		delete(r.bindings, subject)
+		return
+	}
+	r.bindings[subject] = kept
+}
+
+// Roles 返回主体直接绑定的角色.
+func (r *RBAC) Roles(subject string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]string(nil), r.bindings[subject]...)
+}
+
+// Permissions 返回主体拥有的全部权限（包括继承得到的权限），结果已排序去重.
+func (r *RBAC) Permissions(subject string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	set := make(map[string]struct{})
+	visited := make(map[string]bool)
+	for _, role := range r.bindings[subject] {
+		r.collect(role, visited, set)
+	}
+	perms := make([]string, 0, len(set))
+	for perm := range set {
+		perms = append(perms, perm)
+	}
+	sort.Strings(perms)
+	return perms
+}
+
+// Authorize 实现 Policy 接口.
+func (r *RBAC) Authorize(ctx context.Context, subject string, permission string) (bool, error) {
+	for _, granted := range r.Permissions(subject) {
+		if MatchPermission(granted, permission) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// collect 递归收集角色及其继承角色的权限
+func (r *RBAC) collect(name string, visited map[string]bool, set map[string]struct{}) {
+	if visited[name] {
+		return
+	}
+	visited[name] = true
+	role, ok := r.roles[name]
+	if !ok {
+		return
+	}
+	for _, perm := range role.Permissions {
+		set[perm] = struct{}{}
+	}
+	for _, parent := range role.Inherits {
+		r.collect(parent, visited, set)
+	}
+}
+
+// validate 检查未定义的角色引用和循环继承
+func (r *RBAC) validate() error {
+	for subject, roles := range r.bindings {
+		for _, role := range roles {
+			if _, ok := r.roles[role]; !ok {
+				return fmt.Errorf("subject %q bound to undefined role %q", subject, role)
+			}
+		}
+	}
+
+	// 0: 未访问, 1: 访问中, 2: 已完成
+	state := make(map[string]int, len(r.roles))
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case 1:
+			return fmt.Errorf("role %q has cyclic inheritance", name)
+		case 2:
+			return nil
+		}
+		state[name] = 1
+		for _, parent := range r.roles[name].Inherits {
+			if _, ok := r.roles[parent]; !ok {
+				return fmt.Errorf("role %q inherits undefined role %q", name, parent)
+			}
+			if err := visit(parent); err != nil {
+				return err
+			}
+		}
+		state[name] = 2
+		return nil
+	}
+	for name := range r.roles {
+		if err := visit(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// contains 判断切片中是否包含指定元素
+func contains(items []string, item string) bool {
+	for _, v := range items {
+		if v == item {
+			return true
+		}
+	}
+	return false
+}
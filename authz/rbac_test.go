@@ -0,0 +1,189 @@
+package authz
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testPolicy = `
+roles:
+  - name: viewer
+    permissions: ["post:read", "comment:read"]
+  - name: editor
+    permissions: ["post:write"]
+    inherits: [viewer]
+  - name: admin
+    permissions: ["user:*"]
+    inherits: [editor]
+bindings:
+  alice: [admin]
+  bob: [viewer]
+`
+
+func mustParseRBAC(t *testing.T) *RBAC {
+	t.Helper()
+	r, err := ParseRBAC([]byte(testPolicy), "yaml")
+	if err != nil {
+		t.Fatalf("ParseRBAC() error = %v", err)
+	}
+	return r
+}
+
+func TestRBACInheritance(t *testing.T) {
+	r := mustParseRBAC(t)
+
+	tests := []struct {
+		subject    string
+		permission string
+		want       bool
+	}{
+		{"alice", "post:read", true},        // 继承自 viewer
+		{"alice", "post:write", true},       // 继承自 editor
+		{"alice", "user:delete", true},      // 通配符
+		{"alice", "order:read", false},      // 没有授予
+		{"bob", "post:read", true},          // 直接授予
+		{"bob", "post:write", false},        // 不会反向继承
+		{"bob", "user:delete", false},       // 不会反向继承
+		{"carol", "post:read", false},       // 没有绑定角色
+		{"alice", "post:read:draft", false}, // 段数不同
+	}
+	for _, tt := range tests {
+		ok, err := r.Authorize(context.Background(), tt.subject, tt.permission)
+		if err != nil {
+			t.Fatalf("Authorize(%q, %q) error = %v", tt.subject, tt.permission, err)
+		}
+		if ok != tt.want {
+			t.Errorf("Authorize(%q, %q) = %v, want %v", tt.subject, tt.permission, ok, tt.want)
+		}
+	}
+
+	want := []string{"comment:read", "post:read", "post:write", "user:*"}
+	if got := r.Permissions("alice"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Permissions(alice) = %v, want %v", got, want)
+	}
+}
+
+func TestRBACRejectsInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *RBACConfig
+		wantErr string
+	}{
+		{
+			name:    "self inheritance",
+			cfg:     &RBACConfig{Roles: []Role{{Name: "a", Inherits: []string{"a"}}}},
+			wantErr: "cyclic inheritance",
+		},
+		{
+			name: "cycle",
+			cfg: &RBACConfig{Roles: []Role{
+				{Name: "a", Inherits: []string{"b"}},
+				{Name: "b", Inherits: []string{"c"}},
+				{Name: "c", Inherits: []string{"a"}},
+			}},
+			wantErr: "cyclic inheritance",
+		},
+		{
+			name:    "undefined parent",
+			cfg:     &RBACConfig{Roles: []Role{{Name: "a", Inherits: []string{"missing"}}}},
+			wantErr: `inherits undefined role "missing"`,
+		},
+		{
+			name:    "undefined binding",
+			cfg:     &RBACConfig{Roles: []Role{{Name: "a"}}, Bindings: map[string][]string{"alice": {"missing"}}},
+			wantErr: `bound to undefined role "missing"`,
+		},
+		{
+			name:    "duplicate role",
+			cfg:     &RBACConfig{Roles: []Role{{Name: "a"}, {Name: "a"}}},
+			wantErr: `duplicate role "a"`,
+		},
+		{
+			name:    "empty name",
+			cfg:     &RBACConfig{Roles: []Role{{}}},
+			wantErr: "role name is empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRBACFromConfig(tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("NewRBACFromConfig() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRBACAddRoleRollback(t *testing.T) {
+	r := mustParseRBAC(t)
+
+	// 替换角色形成循环时回滚，原角色保持不变
+	if err := r.AddRole(Role{Name: "viewer", Inherits: []string{"admin"}}); err == nil {
+		t.Fatal("AddRole() error = nil, want cyclic inheritance")
+	}
+	if ok, _ := r.Authorize(context.Background(), "bob", "post:read"); !ok {
+		t.Fatal("viewer role was not restored after a rejected AddRole")
+	}
+	if ok, _ := r.Authorize(context.Background(), "bob", "user:delete"); ok {
+		t.Fatal("rejected inheritance was applied")
+	}
+
+	// 新角色继承未定义的角色时不会被添加
+	if err := r.AddRole(Role{Name: "auditor", Inherits: []string{"missing"}}); err == nil {
+		t.Fatal("AddRole() error = nil, want undefined role")
+	}
+	if err := r.Bind("bob", "auditor"); err == nil {
+		t.Fatal("Bind() to a rejected role succeeded")
+	}
+}
+
+func TestRBACBind(t *testing.T) {
+	r := mustParseRBAC(t)
+
+	if err := r.Bind("carol", "editor", "editor"); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	if got := r.Roles("carol"); !reflect.DeepEqual(got, []string{"editor"}) {
+		t.Fatalf("Roles(carol) = %v, want [editor]", got)
+	}
+	if ok, _ := r.Authorize(context.Background(), "carol", "post:read"); !ok {
+		t.Fatal("bound subject did not inherit viewer permissions")
+	}
+
+	r.Unbind("carol", "editor")
+	if got := r.Roles("carol"); len(got) != 0 {
+		t.Fatalf("Roles(carol) after Unbind = %v, want none", got)
+	}
+	if ok, _ := r.Authorize(context.Background(), "carol", "post:read"); ok {
+		t.Fatal("unbound subject is still authorized")
+	}
+}
+
+func TestLoadRBAC(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"policy.yaml": testPolicy,
+		"policy.json": `{"roles":[{"name":"viewer","permissions":["post:read"]}],"bindings":{"bob":["viewer"]}}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("write policy: %v", err)
+		}
+		r, err := LoadRBAC(path)
+		if err != nil {
+			t.Fatalf("LoadRBAC(%s) error = %v", name, err)
+		}
+		if ok, _ := r.Authorize(context.Background(), "bob", "post:read"); !ok {
+			t.Fatalf("LoadRBAC(%s) did not grant post:read to bob", name)
+		}
+	}
+
+	if _, err := ParseRBAC([]byte(`{"roles": [`), "json"); err == nil {
+		t.Fatal("ParseRBAC() error = nil for malformed JSON")
+	}
+}
@@ -0,0 +1,118 @@
+package id
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// codeIndex 返回字符到字符集下标的映射.
+// 不在字符集中的字符依次尝试大小写转换与易混淆字符映射，字符集中的字符不受影响.
+// 易混淆字符的映射目标同样不在字符集中时继续沿映射查找，直到找到字符集中的字符或回到起点.
+func codeIndex(chars []rune, confusables map[rune]rune) (map[rune]int, error) {
+	index := make(map[rune]int, len(chars))
+	for i, c := range chars {
+		if _, ok := index[c]; ok {
+			return nil, fmt.Errorf("duplicate code char %q", c)
+		}
+		index[c] = i
+	}
+
+	// 忽略大小写
+	for _, c := range chars {
+		for f := unicode.SimpleFold(c); f != c; f = unicode.SimpleFold(f) {
+			if _, ok := index[f]; !ok {
+				index[f] = index[c]
+			}
+		}
+	}
+
+	// 易混淆字符，同样忽略大小写.
+	// 只沿映射查找字符集中的字符（含大小写），结果与 map 的遍历顺序无关
+	mapped := make(map[rune]int, len(confusables))
+	for src := range confusables {
+		if _, ok := index[src]; ok {
+			continue
+		}
+		if i, ok := resolveConfusable(src, confusables, index); ok {
+			mapped[src] = i
+		}
+	}
+	for src, i := range mapped {
+		for f := unicode.SimpleFold(src); ; f = unicode.SimpleFold(f) {
+			if _, ok := index[f]; !ok {
+				index[f] = i
+			}
+			if f == src {
+				break
+			}
+		}
+	}
+	return index, nil
+}
+
+// resolveConfusable 沿易混淆字符的映射查找第一个在字符集中的字符，返回其下标
+func resolveConfusable(src rune, confusables map[rune]rune, index map[rune]int) (int, bool) {
+	c := src
+	for n := 0; n < len(confusables); n++ {
+		dst, ok := confusables[c]
+		if !ok || dst == src {
+			return 0, false
+		}
+		if i, ok := index[dst]; ok {
+			return i, true
+		}
+		c = dst
+	}
+	return 0, false
+}
+
+// luhnCheck 按 Luhn mod N 算法计算校验字符的下标，n 为字符集长度
+func luhnCheck(digits []int, n int) int {
+	factor, sum := 2, 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		addend := factor * digits[i]
+		addend = addend/n + addend%n
+		sum += addend
+		factor = 3 - factor
+	}
+	return (n - sum%n) % n
+}
+
+// appendCheck 在 Code 末尾添加校验字符
+func appendCheck(code []rune, chars []rune) []rune {
+	index := make(map[rune]int, len(chars))
+	for i, c := range chars {
+		index[c] = i
+	}
+	digits := make([]int, len(code))
+	for i, r := range code {
+		digits[i] = index[r]
+	}
+	return append(code, chars[luhnCheck(digits, len(chars))])
+}
+
+// codeDigits 将输入的 Code 规范化为字符集下标，并校验长度与校验字符.
+// 返回不含校验字符的 l 个下标，以及规范化后的 Code（包含校验字符）
+func codeDigits(code string, chars []rune, index map[rune]int, l int, checksum bool) ([]int, string, error) {
+	n := l
+	if checksum {
+		n++
+	}
+	runes := []rune(code)
+	if len(runes) != n {
+		return nil, "", ErrInvalidCode
+	}
+
+	digits := make([]int, n)
+	for i, r := range runes {
+		v, ok := index[r]
+		if !ok {
+			return nil, "", ErrInvalidCode
+		}
+		digits[i], runes[i] = v, chars[v]
+	}
+	if checksum && luhnCheck(digits[:l], len(chars)) != digits[l] {
+		return nil, "", ErrInvalidCode
+	}
+	return digits[:l], string(runes), nil
+}
@@ -0,0 +1,138 @@
+package id
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestChecksumRejectsSubstitution(t *testing.T) {
+	ops := getCodeOptionsOrSetDefault(nil)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		code := []rune(NewCode(uint64(r.Int63n(1<<30)), WithCodeChecksum(true)))
+		for pos := range code {
+			orig := code[pos]
+			for _, c := range ops.chars {
+				if c == orig {
+					continue
+				}
+				code[pos] = c
+				if _, err := DecodeCode(string(code), WithCodeChecksum(true)); !errors.Is(err, ErrInvalidCode) {
+					t.Fatalf("DecodeCode(%q) error = %v, want %v", string(code), err, ErrInvalidCode)
+				}
+			}
+			code[pos] = orig
+		}
+	}
+}
+
+func TestChecksumRejectsTransposition(t *testing.T) {
+	ops := getCodeOptionsOrSetDefault(nil)
+	first, last := ops.chars[0], ops.chars[len(ops.chars)-1]
+	r := rand.New(rand.NewSource(1))
+	var checked, skipped int
+	for i := 0; i < 500; i++ {
+		code := []rune(NewCode(uint64(r.Int63n(1<<30)), WithCodeChecksum(true)))
+		for pos := 0; pos+1 < len(code); pos++ {
+			a, b := code[pos], code[pos+1]
+			if a == b {
+				continue
+			}
+			// Luhn mod N 无法发现下标 0 与 N-1 的颠倒
+			if (a == first && b == last) || (a == last && b == first) {
+				skipped++
+				continue
+			}
+			code[pos], code[pos+1] = b, a
+			if _, err := DecodeCode(string(code), WithCodeChecksum(true)); !errors.Is(err, ErrInvalidCode) {
+				t.Fatalf("DecodeCode(%q) error = %v, want %v", string(code), err, ErrInvalidCode)
+			}
+			code[pos], code[pos+1] = a, b
+			checked++
+		}
+	}
+	t.Logf("%d transpositions rejected, %d skipped", checked, skipped)
+}
+
+func TestCodeIndexConfusables(t *testing.T) {
+	tests := []struct {
+		name  string
+		chars string
+		// want 输入字符到字符集中字符的映射，值为 0 时表示无法识别
+		want map[rune]rune
+	}{
+		{
+			name:  "default",
+			chars: string(getCodeOptionsOrSetDefault(nil).chars),
+			want: map[rune]rune{
+				'1': 'L', 'I': 'L', 'i': 'L', 'l': 'L',
+				'U': 'V', 'u': 'V', 'Z': '2', 'z': '2',
+				'S': 'S', '5': '5', 'B': 'B', '8': '8',
+				'0': 0, 'O': 0, 'o': 0, 'Q': 'Q', 'D': 'D',
+			},
+		},
+		{
+			name:  "digits only",
+			chars: "0123456789",
+			want: map[rune]rune{
+				'O': '0', 'o': '0', 'I': '1', 'i': '1', 'L': '1', 'l': '1',
+				'Z': '2', 'S': '5', 'B': '8', 'U': 0,
+			},
+		},
+		{
+			name:  "letters only",
+			chars: "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+			want: map[rune]rune{
+				'0': 'O', '1': 'I', '2': 'Z', '5': 'S', '8': 'B', 'l': 'L', 'u': 'U',
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chars := []rune(tt.chars)
+			index, err := codeIndex(chars, defaultConfusables)
+			if err != nil {
+				t.Fatalf("codeIndex() error = %v", err)
+			}
+			for in, want := range tt.want {
+				i, ok := index[in]
+				if want == 0 {
+					if ok {
+						t.Errorf("%q maps to %q, want unmapped", in, chars[i])
+					}
+					continue
+				}
+				if !ok || chars[i] != want {
+					t.Errorf("%q maps to index %d (%v), want %q", in, i, ok, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeCodeConfusables(t *testing.T) {
+	replacer := strings.NewReplacer("L", "1", "V", "u", "2", "z")
+	r := rand.New(rand.NewSource(1))
+	var replaced int
+	for i := 0; i < 1000; i++ {
+		id := uint64(r.Int63n(1 << 30))
+		code := NewCode(id, WithCodeChecksum(true))
+		typed := strings.ToLower(replacer.Replace(code))
+		if typed != strings.ToLower(code) {
+			replaced++
+		}
+		got, err := DecodeCode(typed, WithCodeChecksum(true))
+		if err != nil || got != id {
+			t.Fatalf("DecodeCode(%q) = %d, %v, want %d", typed, got, err, id)
+		}
+	}
+	if replaced == 0 {
+		t.Fatal("no code contains a confusable char")
+	}
+	// 关闭映射后不再识别
+	if _, err := DecodeCode("1111111", WithCodeL(7), WithCodeConfusables(nil)); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("DecodeCode() error = %v, want %v", err, ErrInvalidCode)
+	}
+}
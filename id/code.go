@@ -1,5 +1,10 @@
 package id
 
+import (
+	"errors"
+	"fmt"
+)
+
 func NewCode(id uint64, options ...func(*CodeOptions)) string {
 	// 获取或设置默认的Code选项
 	ops := getCodeOptionsOrSetDefault(nil)
@@ -27,5 +32,89 @@ func NewCode(id uint64, options ...func(*CodeOptions)) string {
 		idx := (byte(i) * byte(ops.n2)) % byte(ops.l)
 		code = append(code, ops.chars[slIdx[idx]])
 	}
+	if ops.checksum {
+		code = appendCheck(code, ops.chars)
+	}
 	return string(code)
 }
+
+// ErrInvalidCode Code 不是由 NewCode 使用相同选项生成的.
+var ErrInvalidCode = errors.New("invalid code")
+
+// DecodeCode 使用与 NewCode 相同的选项将 Code 还原为 ID，依次还原混淆、扩散、n1 倍数与 salt.
+// 解码前忽略大小写并映射易混淆字符，启用校验字符时先校验，校验失败返回 ErrInvalidCode.
+// Code 只保留了 id*n1+salt 的低 l 位（以字符集长度为进制），超出该范围的 ID 无法还原，返回 ErrInvalidCode.
+func DecodeCode(code string, options ...func(*CodeOptions)) (uint64, error) {
+	ops := getCodeOptionsOrSetDefault(nil)
+	for _, f := range options {
+		f(ops)
+	}
+
+	index, err := codeIndex(ops.chars, ops.confusables)
+	if err != nil {
+		return 0, err
+	}
+	digits, code, err := codeDigits(code, ops.chars, index, ops.l, ops.checksum)
+	if err != nil {
+		return 0, err
+	}
+
+	candidates, err := codeCandidates(digits, ops)
+	if err != nil {
+		return 0, err
+	}
+	if id, ok := decodeDigits(code, candidates, 0, 0, 1, ops, options); ok {
+		return id, nil
+	}
+	return 0, ErrInvalidCode
+}
+
+// codeCandidates 还原混淆过程，并返回扩散前每一位的所有可能取值
+func codeCandidates(digits []int, ops *CodeOptions) ([][]uint64, error) {
+	charLen := len(ops.chars)
+
+	// 还原混淆过程
+	slIdx := make([]byte, ops.l)
+	filled := make([]bool, ops.l)
+	for i, v := range digits {
+		idx := (byte(i) * byte(ops.n2)) % byte(ops.l)
+		if filled[idx] {
+			return nil, fmt.Errorf("n2 %d does not permute code length %d", ops.n2, ops.l)
+		}
+		slIdx[idx], filled[idx] = byte(v), true
+	}
+
+	// 还原扩散过程：逐个尝试每一位的取值以保持与 NewCode 相同的 byte 运算，
+	// byte 溢出时同一位可能有多个取值
+	candidates := make([][]uint64, ops.l)
+	for i := range candidates {
+		for d := 0; d < charLen; d++ {
+			if (byte(d)+byte(i)*slIdx[0])%byte(charLen) == slIdx[i] {
+				candidates[i] = append(candidates[i], uint64(d))
+			}
+		}
+		if len(candidates[i]) == 0 {
+			return nil, ErrInvalidCode
+		}
+	}
+	return candidates, nil
+}
+
+// decodeDigits 从第 i 位开始组合每一位的取值，还原 salt 与 n1，并重新编码确认结果
+func decodeDigits(code string, candidates [][]uint64, i int, x, weight uint64, ops *CodeOptions, options []func(*CodeOptions)) (uint64, bool) {
+	if i == len(candidates) {
+		// 还原 salt 与 n1
+		if x < ops.salt || ops.n1 <= 0 || (x-ops.salt)%uint64(ops.n1) != 0 {
+			return 0, false
+		}
+		id := (x - ops.salt) / uint64(ops.n1)
+		// 不同的 ID 超出范围后可能得到相同的 Code，重新编码确认结果
+		return id, NewCode(id, options...) == code
+	}
+	for _, d := range candidates[i] {
+		if id, ok := decodeDigits(code, candidates, i+1, x+d*weight, weight*uint64(len(ops.chars)), ops, options); ok {
+			return id, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,175 @@
+package id
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+// base62Chars 62 个字符的字符集，NewCode 的 byte 运算会溢出
+var base62Chars = []rune("0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz")
+
+var testCodeOptions = []struct {
+	name    string
+	options []func(*CodeOptions)
+	// wraps 为 true 时 NewCode 的 byte 运算溢出，同一位可能有多个取值
+	wraps bool
+}{
+	{name: "default"},
+	{name: "checksum", options: []func(*CodeOptions){WithCodeChecksum(true)}},
+	{name: "short", options: []func(*CodeOptions){WithCodeL(5), WithCodeN2(3), WithCodeSalt(7)}},
+	{
+		name:    "base62",
+		options: []func(*CodeOptions){WithCodeChars(base62Chars), WithCodeN1(37), WithCodeN2(5), WithCodeL(6), WithCodeSalt(918273)},
+		wraps:   true,
+	},
+	{
+		name:    "long",
+		options: []func(*CodeOptions){WithCodeL(12), WithCodeN2(7), WithCodeChecksum(true)},
+		wraps:   true,
+	},
+}
+
+// codeMaxID 返回能够还原的最大 ID
+func codeMaxID(t *testing.T, options []func(*CodeOptions)) uint64 {
+	t.Helper()
+	c, err := NewCoder(options...)
+	if err != nil {
+		t.Fatalf("NewCoder() error = %v", err)
+	}
+	return c.MaxID()
+}
+
+// hasMultipleCandidates 判断 Code 扩散前是否有某一位存在多个可能的取值
+func hasMultipleCandidates(t *testing.T, code string, options []func(*CodeOptions)) bool {
+	t.Helper()
+	ops := getCodeOptionsOrSetDefault(nil)
+	for _, f := range options {
+		f(ops)
+	}
+	index, err := codeIndex(ops.chars, ops.confusables)
+	if err != nil {
+		t.Fatalf("codeIndex() error = %v", err)
+	}
+	digits, _, err := codeDigits(code, ops.chars, index, ops.l, ops.checksum)
+	if err != nil {
+		t.Fatalf("codeDigits(%q) error = %v", code, err)
+	}
+	candidates, err := codeCandidates(digits, ops)
+	if err != nil {
+		t.Fatalf("codeCandidates(%q) error = %v", code, err)
+	}
+	for _, c := range candidates {
+		if len(c) > 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDecodeCodeRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, tt := range testCodeOptions {
+		t.Run(tt.name, func(t *testing.T) {
+			max := codeMaxID(t, tt.options)
+			ids := []uint64{0, 1, max - 1, max}
+			for i := 0; i < 2000; i++ {
+				ids = append(ids, uint64(r.Int63n(int64(min(max, 1<<62))+1)))
+			}
+
+			var aliased, multi int
+			for _, id := range ids {
+				code := NewCode(id, tt.options...)
+				if hasMultipleCandidates(t, code, tt.options) {
+					multi++
+				}
+				got, err := DecodeCode(code, tt.options...)
+				if err != nil {
+					t.Fatalf("DecodeCode(%q) for id %d error = %v", code, id, err)
+				}
+				if got == id {
+					continue
+				}
+				// byte 运算溢出时不同的 ID 可能得到相同的 Code，还原的 ID 必须生成同一个 Code
+				if !tt.wraps || NewCode(got, tt.options...) != code {
+					t.Fatalf("DecodeCode(%q) = %d, want %d", code, got, id)
+				}
+				aliased++
+			}
+			if tt.wraps && multi == 0 {
+				t.Fatalf("no code has multiple candidates, byte arithmetic never wraps")
+			}
+			if !tt.wraps && multi > 0 {
+				t.Fatalf("%d codes have multiple candidates without byte wrapping", multi)
+			}
+			t.Logf("%d of %d codes have multiple candidates, %d share their code with another id", multi, len(ids), aliased)
+		})
+	}
+}
+
+func TestDecodeCodeOutOfRange(t *testing.T) {
+	// 默认选项下 30^8 不是 n1 的倍数，(MaxID, 30^8) 范围内的 ID 都没有可以还原的 Code
+	max := codeMaxID(t, nil)
+	r := rand.New(rand.NewSource(1))
+	ids := []uint64{max + 1, max + 2, max + 17}
+	for i := 0; i < 1000; i++ {
+		ids = append(ids, max+1+uint64(r.Int63n(656100000000-int64(max)-1)))
+	}
+	for _, id := range ids {
+		code := NewCode(id)
+		if got, err := DecodeCode(code); !errors.Is(err, ErrInvalidCode) {
+			t.Fatalf("DecodeCode(NewCode(%d)) = %d, %v, want %v", id, got, err, ErrInvalidCode)
+		}
+	}
+}
+
+func TestDecodeCodeInvalid(t *testing.T) {
+	code := NewCode(42, WithCodeChecksum(true))
+	tests := []struct {
+		name string
+		code string
+	}{
+		{"empty", ""},
+		{"short", code[:len(code)-2]},
+		{"long", code + "2"},
+		{"unknown char", "!" + code[1:]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCode(tt.code, WithCodeChecksum(true)); !errors.Is(err, ErrInvalidCode) {
+				t.Fatalf("DecodeCode(%q) error = %v, want %v", tt.code, err, ErrInvalidCode)
+			}
+		})
+	}
+}
+
+func TestCoderMatchesNewCode(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, tt := range testCodeOptions {
+		if tt.wraps {
+			continue
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewCoder(tt.options...)
+			if err != nil {
+				t.Fatalf("NewCoder() error = %v", err)
+			}
+			for i := 0; i < 1000; i++ {
+				id := uint64(r.Int63n(int64(c.MaxID()) + 1))
+				code, err := c.Encode(id)
+				if err != nil {
+					t.Fatalf("Encode(%d) error = %v", id, err)
+				}
+				if want := NewCode(id, tt.options...); code != want {
+					t.Fatalf("Encode(%d) = %q, want %q", id, code, want)
+				}
+				if got, err := c.Decode(code); err != nil || got != id {
+					t.Fatalf("Decode(%q) = %d, %v, want %d", code, got, err, id)
+				}
+			}
+			if _, err := c.Encode(c.MaxID() + 1); !errors.Is(err, ErrCodeOutOfRange) {
+				t.Fatalf("Encode(MaxID()+1) error = %v, want %v", err, ErrCodeOutOfRange)
+			}
+		})
+	}
+}
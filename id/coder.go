@@ -0,0 +1,152 @@
+package id
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// ErrCodeOutOfRange ID 超出了 Code 能够无冲突表示的范围.
+var ErrCodeOutOfRange = errors.New("id exceeds code capacity")
+
+// Coder 使用校验过的 CodeOptions 在 ID 与 Code 之间转换.
+// 与 NewCode 的算法相同，但使用 int 运算，字符集可以超过 255 个字符；
+// 在 NewCode 的 byte 运算不溢出时（例如默认选项）两者生成的 Code 相同.
+type Coder struct {
+	chars    []rune
+	index    map[rune]int
+	n1       uint64
+	n2       int
+	l        int
+	salt     uint64
+	max      uint64
+	checksum bool
+}
+
+// NewCoder 根据提供的选项函数创建一个新的 Coder 实例，并校验选项：
+// 字符集至少包含 2 个且不重复的字符，n1 与字符集长度互质，n2 与 Code 长度互质，
+// 且 salt 小于 Code 能够表示的范围.
+func NewCoder(options ...func(*CodeOptions)) (*Coder, error) {
+	ops := getCodeOptionsOrSetDefault(nil)
+	for _, f := range options {
+		f(ops)
+	}
+
+	charLen := len(ops.chars)
+	if charLen < 2 {
+		return nil, errors.New("code chars must contain at least 2 characters")
+	}
+	index, err := codeIndex(ops.chars, ops.confusables)
+	if err != nil {
+		return nil, err
+	}
+	if ops.n1 <= 0 || gcd(ops.n1, charLen) != 1 {
+		return nil, fmt.Errorf("n1 %d must be positive and coprime with the number of chars %d", ops.n1, charLen)
+	}
+	if ops.n2 <= 0 || gcd(ops.n2, ops.l) != 1 {
+		return nil, fmt.Errorf("n2 %d must be positive and coprime with the code length %d", ops.n2, ops.l)
+	}
+
+	// Code 能够表示 [0, charLen^l) 范围内的数，同时 id*n1+salt 不能超出 uint64
+	capacity := uint64(math.MaxUint64)
+	for i, c := 0, uint64(1); i < ops.l; i++ {
+		hi, lo := bits.Mul64(c, uint64(charLen))
+		if hi != 0 {
+			break
+		}
+		c = lo
+		if i == ops.l-1 {
+			capacity = c - 1
+		}
+	}
+	if ops.salt > capacity {
+		return nil, fmt.Errorf("salt %d exceeds code capacity %d", ops.salt, capacity)
+	}
+
+	return &Coder{
+		chars:    ops.chars,
+		index:    index,
+		n1:       uint64(ops.n1),
+		n2:       ops.n2,
+		l:        ops.l,
+		salt:     ops.salt,
+		max:      (capacity - ops.salt) / uint64(ops.n1),
+		checksum: ops.checksum,
+	}, nil
+}
+
+// MaxID 返回能够无冲突编码的最大 ID.
+func (c *Coder) MaxID() uint64 {
+	return c.max
+}
+
+// Encode 将 ID 编码为 Code，ID 超过 MaxID 时返回 ErrCodeOutOfRange.
+func (c *Coder) Encode(id uint64) (string, error) {
+	if id > c.max {
+		return "", ErrCodeOutOfRange
+	}
+	// 扩大 ID 并添加 salt
+	x := id*c.n1 + c.salt
+
+	charLen := uint64(len(c.chars))
+	slIdx := make([]int, c.l)
+	// 扩散过程
+	for i := 0; i < c.l; i++ {
+		slIdx[i] = int((x%charLen + uint64(i)*uint64(slIdx[0])) % charLen)
+		x /= charLen
+	}
+
+	// 混淆过程
+	code := make([]rune, c.l)
+	for i := range code {
+		code[i] = c.chars[slIdx[i*c.n2%c.l]]
+	}
+	if c.checksum {
+		code = appendCheck(code, c.chars)
+	}
+	return string(code), nil
+}
+
+// Decode 将 Code 还原为 ID，Code 不是由相同选项生成时返回 ErrInvalidCode.
+// 解码前忽略大小写并映射易混淆字符，启用校验字符时先校验.
+func (c *Coder) Decode(code string) (uint64, error) {
+	digits, code, err := codeDigits(code, c.chars, c.index, c.l, c.checksum)
+	if err != nil {
+		return 0, err
+	}
+
+	// 还原混淆过程
+	slIdx := make([]int, c.l)
+	for i, v := range digits {
+		slIdx[i*c.n2%c.l] = v
+	}
+
+	// 还原扩散过程
+	charLen := len(c.chars)
+	var x, weight uint64 = 0, 1
+	for i := 0; i < c.l; i++ {
+		d := ((slIdx[i]-i*slIdx[0])%charLen + charLen) % charLen
+		x += uint64(d) * weight
+		weight *= uint64(charLen)
+	}
+
+	// 还原 salt 与 n1
+	if x < c.salt || (x-c.salt)%c.n1 != 0 {
+		return 0, ErrInvalidCode
+	}
+	id := (x - c.salt) / c.n1
+	// 字符集长度的 l 次方超出 uint64 时，x 的高位可能溢出，重新编码确认结果
+	if enc, err := c.Encode(id); err != nil || enc != code {
+		return 0, ErrInvalidCode
+	}
+	return id, nil
+}
+
+// gcd 返回最大公约数
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -0,0 +1,95 @@
+package id
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestNewCoderInvalidOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []func(*CodeOptions)
+		wantErr string
+	}{
+		{"single char", []func(*CodeOptions){WithCodeChars([]rune{'A'})}, "at least 2 characters"},
+		{"duplicate char", []func(*CodeOptions){WithCodeChars([]rune("ABCA"))}, `duplicate code char 'A'`},
+		{"n1 not coprime", []func(*CodeOptions){WithCodeN1(15)}, "n1 15 must be positive and coprime"},
+		{"n1 shares a factor", []func(*CodeOptions){WithCodeN1(4)}, "n1 4 must be positive and coprime"},
+		{"n1 zero", []func(*CodeOptions){WithCodeN1(0)}, "n1 0 must be positive"},
+		{"n2 not coprime", []func(*CodeOptions){WithCodeL(10), WithCodeN2(4)}, "n2 4 must be positive and coprime"},
+		{"n2 negative", []func(*CodeOptions){WithCodeN2(-1)}, "n2 -1 must be positive"},
+		// 默认 30 个字符、长度为 8 时最多表示 30^8-1
+		{"salt above capacity", []func(*CodeOptions){WithCodeSalt(656100000000)}, "exceeds code capacity 656099999999"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewCoder(tt.options...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("NewCoder() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// salt 等于容量上限时只能编码 ID 0
+	c, err := NewCoder(WithCodeSalt(656099999999))
+	if err != nil {
+		t.Fatalf("NewCoder() error = %v", err)
+	}
+	if c.MaxID() != 0 {
+		t.Fatalf("MaxID() = %d, want 0", c.MaxID())
+	}
+}
+
+func TestCoderLargeCharset(t *testing.T) {
+	// 300 个汉字，超出 NewCode 使用 byte 运算能够表示的 255 个字符
+	chars := make([]rune, 300)
+	for i := range chars {
+		chars[i] = rune(0x4E00 + i)
+	}
+	c, err := NewCoder(WithCodeChars(chars), WithCodeN1(7), WithCodeL(5), WithCodeN2(3), WithCodeSalt(123456789), WithCodeChecksum(true))
+	if err != nil {
+		t.Fatalf("NewCoder() error = %v", err)
+	}
+
+	r := rand.New(rand.NewSource(1))
+	ids := []uint64{0, 1, 299, 300, c.MaxID()}
+	for i := 0; i < 1000; i++ {
+		ids = append(ids, uint64(r.Int63n(int64(c.MaxID())+1)))
+	}
+	seen := make(map[string]uint64, len(ids))
+	usesHighChars := false
+	for _, id := range ids {
+		code, err := c.Encode(id)
+		if err != nil {
+			t.Fatalf("Encode(%d) error = %v", id, err)
+		}
+		if n := utf8.RuneCountInString(code); n != 6 {
+			t.Fatalf("Encode(%d) = %q has %d chars, want 5 plus a check char", id, code, n)
+		}
+		for _, ch := range code {
+			if ch >= 0x4E00+255 {
+				usesHighChars = true
+			}
+		}
+		if prev, ok := seen[code]; ok && prev != id {
+			t.Fatalf("ids %d and %d share code %q", prev, id, code)
+		}
+		seen[code] = id
+		if got, err := c.Decode(code); err != nil || got != id {
+			t.Fatalf("Decode(%q) = %d, %v, want %d", code, got, err, id)
+		}
+	}
+	if !usesHighChars {
+		t.Fatal("codes never used chars beyond the first 255")
+	}
+
+	if _, err := c.Encode(c.MaxID() + 1); !errors.Is(err, ErrCodeOutOfRange) {
+		t.Fatalf("Encode(MaxID()+1) error = %v, want %v", err, ErrCodeOutOfRange)
+	}
+	if _, err := c.Decode("ABCDEF"); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("Decode() of foreign chars error = %v, want %v", err, ErrInvalidCode)
+	}
+}
@@ -0,0 +1,82 @@
+package id
+
+import (
+	"context"
+	"io"
+	"math"
+	"sync"
+)
+
+// Generator 生成数值类型的唯一 ID，同一个实例生成的 ID 单调递增.
+type Generator interface {
+	NextID(ctx context.Context) (uint64, error)
+}
+
+// StringGenerator 生成字符串类型的唯一 ID，同一个实例生成的 ID 按字典序单调递增.
+type StringGenerator interface {
+	NextString(ctx context.Context) (string, error)
+}
+
+var (
+	_ Generator       = (*Sonyflake)(nil)
+	_ Generator       = (*Snowflake)(nil)
+	_ Generator       = (*Segment)(nil)
+	_ StringGenerator = (*UUIDv7)(nil)
+	_ StringGenerator = (*ULID)(nil)
+	_ StringGenerator = (*KSUID)(nil)
+)
+
+// monotonic 生成由时间戳与随机数组成的 ID 的单调部分.
+// 时间戳没有增长时在上一次的随机数上加一，随机数溢出时借用下一个时间戳.
+type monotonic struct {
+	mu      sync.Mutex
+	entropy io.Reader
+	bits    int // 随机数的位数
+	ts      int64
+	random  []byte
+}
+
+// newMonotonic 创建一个随机数为 bits 位的 monotonic
+func newMonotonic(entropy io.Reader, bits int) *monotonic {
+	return &monotonic{entropy: entropy, bits: bits, ts: math.MinInt64}
+}
+
+// next 返回不早于 ts 的时间戳与对应的随机数，返回的随机数可以被调用方修改
+func (m *monotonic) next(ts int64) (int64, []byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if ts <= m.ts && m.increment() {
+		return m.ts, append([]byte(nil), m.random...), nil
+	}
+	if ts <= m.ts {
+		ts = m.ts + 1
+	}
+
+	random := make([]byte, (m.bits+7)/8)
+	if _, err := io.ReadFull(m.entropy, random); err != nil {
+		return 0, nil, err
+	}
+	random[0] &= m.mask()
+	m.ts, m.random = ts, random
+	return ts, append([]byte(nil), random...), nil
+}
+
+// increment 随机数加一，溢出时返回 false
+func (m *monotonic) increment() bool {
+	for i := len(m.random) - 1; i >= 0; i-- {
+		m.random[i]++
+		if m.random[i] != 0 {
+			break
+		}
+		if i == 0 {
+			return false
+		}
+	}
+	return m.random[0]&^m.mask() == 0
+}
+
+// mask 随机数最高字节中有效位的掩码
+func (m *monotonic) mask() byte {
+	return 0xff >> ((m.bits+7)/8*8 - m.bits)
+}
@@ -0,0 +1,334 @@
+package id
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testClock 可以手动调整的时钟
+type testClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func newTestClock() *testClock {
+	return &testClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *testClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *testClock) add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+// onesReader 返回全为 1 的随机数，使 monotonic 的随机数在下一次生成时溢出
+type onesReader struct{}
+
+func (onesReader) Read(p []byte) (int, error) {
+	return copy(p, bytes.Repeat([]byte{0xff}, len(p))), nil
+}
+
+// testGenerator 将生成器统一为按字典序递增的字符串
+type testGenerator struct {
+	name string
+	new  func(clock *testClock, entropy io.Reader) (func(ctx context.Context) (string, error), error)
+}
+
+var testGenerators = []testGenerator{
+	{
+		// sonyflake 不支持替换时钟，使用真实时钟
+		name: "Sonyflake",
+		new: func(*testClock, io.Reader) (func(ctx context.Context) (string, error), error) {
+			g, err := NewSonyflakeWithError()
+			if err != nil {
+				return nil, err
+			}
+			return numeric(g), nil
+		},
+	},
+	{
+		name: "Snowflake",
+		new: func(clock *testClock, entropy io.Reader) (func(ctx context.Context) (string, error), error) {
+			g, err := NewSnowflake(1, WithClock(clock.now), WithEntropy(entropy))
+			if err != nil {
+				return nil, err
+			}
+			return numeric(g), nil
+		},
+	},
+	{
+		name: "UUIDv7",
+		new: func(clock *testClock, entropy io.Reader) (func(ctx context.Context) (string, error), error) {
+			return NewUUIDv7(WithClock(clock.now), WithEntropy(entropy)).NextString, nil
+		},
+	},
+	{
+		name: "ULID",
+		new: func(clock *testClock, entropy io.Reader) (func(ctx context.Context) (string, error), error) {
+			return NewULID(WithClock(clock.now), WithEntropy(entropy)).NextString, nil
+		},
+	},
+	{
+		name: "KSUID",
+		new: func(clock *testClock, entropy io.Reader) (func(ctx context.Context) (string, error), error) {
+			return NewKSUID(WithClock(clock.now), WithEntropy(entropy)).NextString, nil
+		},
+	},
+}
+
+// numeric 将数值 ID 补齐为定长字符串，按字典序比较即按数值比较
+func numeric(g Generator) func(ctx context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		id, err := g.NextID(ctx)
+		return fmt.Sprintf("%020d", id), err
+	}
+}
+
+func TestGeneratorConformance(t *testing.T) {
+	// 固定的时钟下 Snowflake 每毫秒最多生成 4096 个 ID
+	const goroutines, perGoroutine = 8, 500
+
+	for _, tg := range testGenerators {
+		t.Run(tg.name, func(t *testing.T) {
+			next, err := tg.new(newTestClock(), rand.New(rand.NewSource(1)))
+			if err != nil {
+				t.Fatalf("create generator: %v", err)
+			}
+
+			results := make([][]string, goroutines)
+			errs := make(chan error, goroutines)
+			var wg sync.WaitGroup
+			for i := range results {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					for j := 0; j < perGoroutine; j++ {
+						id, err := next(context.Background())
+						if err != nil {
+							errs <- err
+							return
+						}
+						results[i] = append(results[i], id)
+					}
+				}(i)
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				t.Fatalf("generate id: %v", err)
+			}
+
+			seen := make(map[string]bool, goroutines*perGoroutine)
+			for _, ids := range results {
+				for j, id := range ids {
+					if seen[id] {
+						t.Fatalf("duplicate id %s", id)
+					}
+					seen[id] = true
+					if j > 0 && id <= ids[j-1] {
+						t.Fatalf("id %s is not greater than previous id %s", id, ids[j-1])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGeneratorClockRollback(t *testing.T) {
+	for _, tg := range testGenerators {
+		if tg.name == "Sonyflake" {
+			continue
+		}
+		t.Run(tg.name, func(t *testing.T) {
+			clock := newTestClock()
+			next, err := tg.new(clock, rand.New(rand.NewSource(1)))
+			if err != nil {
+				t.Fatalf("create generator: %v", err)
+			}
+			prev, err := next(context.Background())
+			if err != nil {
+				t.Fatalf("generate id: %v", err)
+			}
+
+			clock.add(-2 * time.Millisecond)
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			id, err := next(ctx)
+			if tg.name == "Snowflake" {
+				// Snowflake 等待时钟追上，不生成重复的 ID
+				if !errors.Is(err, context.DeadlineExceeded) {
+					t.Fatalf("generate id after rollback: id %s, error %v, want %v", id, err, context.DeadlineExceeded)
+				}
+				clock.add(2 * time.Millisecond)
+				if id, err = next(context.Background()); err != nil {
+					t.Fatalf("generate id: %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("generate id after rollback: %v", err)
+			}
+			if id <= prev {
+				t.Fatalf("id %s after rollback is not greater than previous id %s", id, prev)
+			}
+		})
+	}
+}
+
+func TestSnowflakeClockMovedBackwards(t *testing.T) {
+	clock := newTestClock()
+	g, err := NewSnowflake(1, WithClock(clock.now))
+	if err != nil {
+		t.Fatalf("NewSnowflake() error = %v", err)
+	}
+	if _, err := g.NextID(context.Background()); err != nil {
+		t.Fatalf("NextID() error = %v", err)
+	}
+	clock.add(-maxClockBackwards - time.Millisecond)
+	if _, err := g.NextID(context.Background()); !errors.Is(err, ErrClockMovedBackwards) {
+		t.Fatalf("NextID() error = %v, want %v", err, ErrClockMovedBackwards)
+	}
+}
+
+func TestSnowflakeSequenceExhaustion(t *testing.T) {
+	clock := newTestClock()
+	g, err := NewSnowflake(1, WithClock(clock.now))
+	if err != nil {
+		t.Fatalf("NewSnowflake() error = %v", err)
+	}
+
+	var last uint64
+	for i := 0; i < 1<<snowflakeSequenceBits; i++ {
+		if last, err = g.NextID(context.Background()); err != nil {
+			t.Fatalf("NextID() error = %v", err)
+		}
+	}
+
+	// 同一毫秒内的序列号用完后等待进入下一毫秒
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := g.NextID(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("NextID() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	clock.add(time.Millisecond)
+	id, err := g.NextID(context.Background())
+	if err != nil {
+		t.Fatalf("NextID() error = %v", err)
+	}
+	if id <= last || id&(1<<snowflakeSequenceBits-1) != 0 {
+		t.Fatalf("NextID() = %d, want sequence 0 after %d", id, last)
+	}
+}
+
+func TestSonyflakeSequenceExhaustion(t *testing.T) {
+	g, err := NewSonyflakeWithError()
+	if err != nil {
+		t.Fatalf("NewSonyflakeWithError() error = %v", err)
+	}
+
+	// 每 10ms 最多 256 个 ID，超过时等待下一个时间单位
+	var prev uint64
+	for i := 0; i < 3*256; i++ {
+		id, err := g.NextID(context.Background())
+		if err != nil {
+			t.Fatalf("NextID() error = %v", err)
+		}
+		if id <= prev {
+			t.Fatalf("NextID() = %d, want greater than %d", id, prev)
+		}
+		prev = id
+	}
+}
+
+func TestMonotonicOverflow(t *testing.T) {
+	const ts = 1000
+
+	for _, bits := range []int{74, 80, 128} {
+		t.Run(fmt.Sprint(bits), func(t *testing.T) {
+			m := newMonotonic(onesReader{}, bits)
+			got, random, err := m.next(ts)
+			if err != nil {
+				t.Fatalf("next() error = %v", err)
+			}
+			if got != ts || random[0] != m.mask() {
+				t.Fatalf("next() = %d, %x, want %d with masked random", got, random, ts)
+			}
+
+			// 随机数全为 1 时溢出，借用下一个时间戳
+			got, _, err = m.next(ts)
+			if err != nil {
+				t.Fatalf("next() error = %v", err)
+			}
+			if got != ts+1 {
+				t.Fatalf("next() after overflow = %d, want %d", got, ts+1)
+			}
+
+			// 时间戳回退时继续使用借用的时间戳
+			got, _, err = m.next(ts - 1)
+			if err != nil {
+				t.Fatalf("next() error = %v", err)
+			}
+			if got != ts+2 {
+				t.Fatalf("next() after rollback = %d, want %d", got, ts+2)
+			}
+		})
+	}
+}
+
+func TestGeneratorMonotonicOverflow(t *testing.T) {
+	for _, tg := range testGenerators[2:] {
+		t.Run(tg.name, func(t *testing.T) {
+			next, err := tg.new(newTestClock(), onesReader{})
+			if err != nil {
+				t.Fatalf("create generator: %v", err)
+			}
+			var prev string
+			for i := 0; i < 3; i++ {
+				id, err := next(context.Background())
+				if err != nil {
+					t.Fatalf("generate id: %v", err)
+				}
+				if id <= prev {
+					t.Fatalf("id %s after overflow is not greater than previous id %s", id, prev)
+				}
+				prev = id
+			}
+		})
+	}
+}
+
+func TestGeneratorEntropyError(t *testing.T) {
+	errEntropy := errors.New("entropy failed")
+	for _, tg := range testGenerators[2:] {
+		t.Run(tg.name, func(t *testing.T) {
+			next, err := tg.new(newTestClock(), &errReader{errEntropy})
+			if err != nil {
+				t.Fatalf("create generator: %v", err)
+			}
+			if _, err := next(context.Background()); !errors.Is(err, errEntropy) {
+				t.Fatalf("generate id error = %v, want %v", err, errEntropy)
+			}
+		})
+	}
+}
+
+// errReader 总是返回错误
+type errReader struct {
+	err error
+}
+
+func (r *errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,61 @@
+package id
+
+import (
+	"context"
+	"encoding/binary"
+	"math/big"
+	"strings"
+)
+
+const (
+	// ksuidEpoch KSUID 的起始时间，单位为秒
+	ksuidEpoch = 1400000000
+	// base62 KSUID 的字符集
+	base62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+// KSUID 生成 27 个字符的 KSUID：32 位秒级时间戳与 128 位随机数，按字典序排序即按时间排序.
+// 同一秒内在上一个 KSUID 的随机数上加一，保证单调递增.
+type KSUID struct {
+	ops GeneratorOptions
+	m   *monotonic
+}
+
+// NewKSUID 根据提供的选项函数创建一个新的 KSUID 实例.
+func NewKSUID(options ...func(*GeneratorOptions)) *KSUID {
+	ops := getGeneratorOptionsOrSetDefault(nil)
+	for _, f := range options {
+		f(ops)
+	}
+	return &KSUID{ops: *ops, m: newMonotonic(ops.entropy, 128)}
+}
+
+// NextString 生成 KSUID.
+func (g *KSUID) NextString(ctx context.Context) (string, error) {
+	ts, random, err := g.m.next(g.ops.clock().Unix() - ksuidEpoch)
+	if err != nil {
+		return "", err
+	}
+	if ts < 0 || ts > 1<<32-1 {
+		return "", ErrOverTimeLimit
+	}
+
+	b := binary.BigEndian.AppendUint32(make([]byte, 0, 20), uint32(ts))
+	b = append(b, random...)
+	return encodeBase62(b, 27), nil
+}
+
+// encodeBase62 按 base62 编码，左侧补 0 到 n 个字符
+func encodeBase62(b []byte, n int) string {
+	num := new(big.Int).SetBytes(b)
+	base, mod := big.NewInt(62), new(big.Int)
+	out := make([]byte, 0, n)
+	for num.Sign() > 0 {
+		num.DivMod(num, base, mod)
+		out = append(out, base62[mod.Int64()])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return strings.Repeat("0", n-len(out)) + string(out)
+}
@@ -0,0 +1,230 @@
+package id
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrNoMachineID 所有机器ID都已经被租用.
+	ErrNoMachineID = errors.New("no machine id available")
+	// ErrMachineIDLeaseLost 机器ID的租约已经失效，继续生成 ID 可能与其它实例冲突.
+	ErrMachineIDLeaseLost = errors.New("machine id lease lost")
+)
+
+// renewScript 租约仍属于当前实例时续期
+var renewScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("pexpire", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseScript 租约仍属于当前实例时删除
+var releaseScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0
+`)
+
+type RedisLeaseOptions struct {
+	prefix string
+	ttl    time.Duration
+	min    uint16
+	max    uint16
+}
+
+func getRedisLeaseOptionsOrSetDefault(options *RedisLeaseOptions) *RedisLeaseOptions {
+	if options == nil {
+		return &RedisLeaseOptions{
+			prefix: "sonyflake:machine:",
+			ttl:    30 * time.Second,
+			min:    0,
+			max:    1<<16 - 1,
+		}
+	}
+	return options
+}
+
+// WithRedisLeasePrefix 函数返回一个函数，用于设置租约键的前缀。
+func WithRedisLeasePrefix(prefix string) func(*RedisLeaseOptions) {
+	return func(options *RedisLeaseOptions) {
+		if prefix != "" {
+			getRedisLeaseOptionsOrSetDefault(options).prefix = prefix
+		}
+	}
+}
+
+// WithRedisLeaseTTL 函数返回一个函数，用于设置租约的有效期，租约每隔 1/3 有效期续期一次。
+func WithRedisLeaseTTL(ttl time.Duration) func(*RedisLeaseOptions) {
+	return func(options *RedisLeaseOptions) {
+		if ttl > 0 {
+			getRedisLeaseOptionsOrSetDefault(options).ttl = ttl
+		}
+	}
+}
+
+// WithRedisLeaseRange 函数返回一个函数，用于设置可分配的机器ID范围 [min, max]。
+func WithRedisLeaseRange(min, max uint16) func(*RedisLeaseOptions) {
+	return func(options *RedisLeaseOptions) {
+		if min <= max {
+			ops := getRedisLeaseOptionsOrSetDefault(options)
+			ops.min, ops.max = min, max
+		}
+	}
+}
+
+// RedisLease 使用 Redis 租约分配机器ID，保证同一时刻每个机器ID只属于一个实例.
+// 租约每隔 1/3 有效期续期一次；距离上一次续期成功超过 2/3 有效期时视为失效，
+// 预留一个续期间隔，保证租约在 Redis 中过期、被其它实例租用之前本实例已经停止生成 ID.
+// Close 时释放租约.
+type RedisLease struct {
+	cli   redis.UniversalClient
+	ops   RedisLeaseOptions
+	token string
+
+	mu       sync.Mutex
+	id       uint16
+	acquired bool
+	renewed  time.Time // 最近一次续期成功的请求的发送时间
+	err      error
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewRedisLease 根据提供的 Redis 客户端与选项函数创建一个 RedisLease 实例，客户端由调用方负责关闭.
+func NewRedisLease(cli redis.UniversalClient, options ...func(*RedisLeaseOptions)) *RedisLease {
+	ops := getRedisLeaseOptionsOrSetDefault(nil)
+	for _, f := range options {
+		f(ops)
+	}
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return &RedisLease{cli: cli, ops: *ops, token: hex.EncodeToString(b)}
+}
+
+// key 租约的键名，格式为 <prefix><machine id>
+func (l *RedisLease) key(id uint16) string {
+	return fmt.Sprintf("%s%d", l.ops.prefix, id)
+}
+
+// MachineID 租用一个空闲的机器ID并开始续期，重复调用返回同一个机器ID.
+func (l *RedisLease) MachineID(ctx context.Context) (uint16, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.acquired {
+		return l.id, l.errLocked()
+	}
+
+	// 从递增的起点开始查找，避免多个实例同时争抢同一个机器ID
+	start, err := l.cli.Incr(ctx, l.ops.prefix+"seq").Result()
+	if err != nil {
+		return 0, err
+	}
+	size := int64(l.ops.max) - int64(l.ops.min) + 1
+	for i := int64(0); i < size; i++ {
+		id := l.ops.min + uint16((start+i)%size)
+		// 有效期从 Redis 收到请求时开始计算，使用发送请求前的时间偏保守
+		sent := time.Now()
+		ok, err := l.cli.SetNX(ctx, l.key(id), l.token, l.ops.ttl).Result()
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			continue
+		}
+
+		l.id, l.acquired, l.renewed, l.err = id, true, sent, nil
+		hctx, cancel := context.WithCancel(context.Background())
+		l.cancel, l.done = cancel, make(chan struct{})
+		go l.heartbeat(hctx)
+		return id, nil
+	}
+	return 0, ErrNoMachineID
+}
+
+// interval 续期间隔
+func (l *RedisLease) interval() time.Duration {
+	return l.ops.ttl / 3
+}
+
+// heartbeat 定期续期租约，租约被其它实例占用或已经过期时标记为失效；
+// 续期请求失败时继续重试，超过 2/3 有效期没有续期成功时由 Err 判定为失效
+func (l *RedisLease) heartbeat(ctx context.Context) {
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.interval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		sent := time.Now()
+		rctx, cancel := context.WithTimeout(ctx, l.interval())
+		n, err := renewScript.Run(rctx, l.cli, []string{l.key(l.id)}, l.token, l.ops.ttl.Milliseconds()).Int()
+		cancel()
+		if err != nil {
+			continue
+		}
+
+		l.mu.Lock()
+		if n == 1 {
+			l.renewed = sent
+		} else {
+			l.err = ErrMachineIDLeaseLost
+		}
+		l.mu.Unlock()
+		if n != 1 {
+			return
+		}
+	}
+}
+
+// Err 租约失效或距离上一次续期成功超过 2/3 有效期时返回 ErrMachineIDLeaseLost.
+func (l *RedisLease) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.errLocked()
+}
+
+// errLocked 与 Err 相同，调用方需要持有锁
+func (l *RedisLease) errLocked() error {
+	if l.err != nil {
+		return l.err
+	}
+	if l.acquired && time.Since(l.renewed) >= l.ops.ttl-l.interval() {
+		return ErrMachineIDLeaseLost
+	}
+	return nil
+}
+
+// Close 停止续期并释放租约.
+func (l *RedisLease) Close() error {
+	l.mu.Lock()
+	if !l.acquired {
+		l.mu.Unlock()
+		return nil
+	}
+	l.acquired = false
+	cancel, done := l.cancel, l.done
+	l.mu.Unlock()
+
+	// 续期在标记失效时需要持有锁，因此等待续期退出前先释放锁
+	cancel()
+	<-done
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return releaseScript.Run(ctx, l.cli, []string{l.key(l.id)}, l.token).Err()
+}
@@ -0,0 +1,157 @@
+package id
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const testLeaseTTL = 300 * time.Millisecond
+
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	m := miniredis.RunT(t)
+	cli := redis.NewClient(&redis.Options{Addr: m.Addr()})
+	t.Cleanup(func() { _ = cli.Close() })
+	return m, cli
+}
+
+// waitFor 轮询直到 cond 成立或超时
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) bool {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return true
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return cond()
+}
+
+func TestRedisLeaseAcquire(t *testing.T) {
+	m, cli := newTestRedis(t)
+	ctx := context.Background()
+
+	opts := []func(*RedisLeaseOptions){WithRedisLeaseTTL(testLeaseTTL), WithRedisLeaseRange(3, 4)}
+	a, b, c := NewRedisLease(cli, opts...), NewRedisLease(cli, opts...), NewRedisLease(cli, opts...)
+	defer a.Close()
+	defer b.Close()
+
+	idA, err := a.MachineID(ctx)
+	if err != nil {
+		t.Fatalf("MachineID() error = %v", err)
+	}
+	idB, err := b.MachineID(ctx)
+	if err != nil {
+		t.Fatalf("MachineID() error = %v", err)
+	}
+	if idA == idB || idA < 3 || idA > 4 || idB < 3 || idB > 4 {
+		t.Fatalf("machine ids = %d, %d, want distinct ids in [3, 4]", idA, idB)
+	}
+	if again, _ := a.MachineID(ctx); again != idA {
+		t.Fatalf("repeated MachineID() = %d, want %d", again, idA)
+	}
+	if got, _ := m.Get(a.key(idA)); got != a.token {
+		t.Fatalf("lease value = %q, want token %q", got, a.token)
+	}
+	if _, err := c.MachineID(ctx); !errors.Is(err, ErrNoMachineID) {
+		t.Fatalf("MachineID() error = %v, want %v", err, ErrNoMachineID)
+	}
+}
+
+func TestRedisLeaseHeartbeat(t *testing.T) {
+	m, cli := newTestRedis(t)
+	l := NewRedisLease(cli, WithRedisLeaseTTL(testLeaseTTL))
+	defer l.Close()
+
+	id, err := l.MachineID(context.Background())
+	if err != nil {
+		t.Fatalf("MachineID() error = %v", err)
+	}
+	// 缩短剩余有效期，续期后恢复为完整的有效期
+	m.SetTTL(l.key(id), time.Millisecond)
+	if !waitFor(t, testLeaseTTL, func() bool { return m.TTL(l.key(id)) == testLeaseTTL }) {
+		t.Fatalf("lease ttl = %v, want renewed to %v", m.TTL(l.key(id)), testLeaseTTL)
+	}
+
+	// 超过有效期后仍然有效
+	time.Sleep(2 * testLeaseTTL)
+	if err := l.Err(); err != nil {
+		t.Fatalf("Err() = %v after renewals", err)
+	}
+}
+
+func TestRedisLeaseLost(t *testing.T) {
+	m, cli := newTestRedis(t)
+	l := NewRedisLease(cli, WithRedisLeaseTTL(testLeaseTTL))
+	defer l.Close()
+
+	sf, err := NewSonyflakeWithError(WithSonyflakeMachineIdProvider(l))
+	if err != nil {
+		t.Fatalf("NewSonyflakeWithError() error = %v", err)
+	}
+	if _, err := sf.NextID(context.Background()); err != nil {
+		t.Fatalf("NextID() error = %v", err)
+	}
+
+	// 其它实例在租约过期后租用了同一个机器ID
+	id, _ := l.MachineID(context.Background())
+	m.Set(l.key(id), "other")
+	if !waitFor(t, testLeaseTTL, func() bool { return errors.Is(l.Err(), ErrMachineIDLeaseLost) }) {
+		t.Fatalf("Err() = %v, want %v", l.Err(), ErrMachineIDLeaseLost)
+	}
+	if _, err := sf.NextID(context.Background()); !errors.Is(err, ErrMachineIDLeaseLost) {
+		t.Fatalf("NextID() error = %v, want %v", err, ErrMachineIDLeaseLost)
+	}
+
+	// 释放时不删除其它实例的租约
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if got, _ := m.Get(l.key(id)); got != "other" {
+		t.Fatalf("lease value = %q, want %q", got, "other")
+	}
+}
+
+func TestRedisLeaseLostBeforeExpiry(t *testing.T) {
+	m, cli := newTestRedis(t)
+	l := NewRedisLease(cli, WithRedisLeaseTTL(testLeaseTTL))
+	defer l.Close()
+
+	if _, err := l.MachineID(context.Background()); err != nil {
+		t.Fatalf("MachineID() error = %v", err)
+	}
+	// Redis 不可用时无法续期，需要在租约过期前判定为失效
+	m.Close()
+	closed := time.Now()
+	if !waitFor(t, testLeaseTTL, func() bool { return errors.Is(l.Err(), ErrMachineIDLeaseLost) }) {
+		t.Fatalf("Err() = %v, want %v", l.Err(), ErrMachineIDLeaseLost)
+	}
+	if elapsed := time.Since(closed); elapsed >= testLeaseTTL {
+		t.Fatalf("lease lost after %v, want before ttl %v", elapsed, testLeaseTTL)
+	}
+}
+
+func TestRedisLeaseClose(t *testing.T) {
+	m, cli := newTestRedis(t)
+	l := NewRedisLease(cli, WithRedisLeaseTTL(testLeaseTTL))
+
+	id, err := l.MachineID(context.Background())
+	if err != nil {
+		t.Fatalf("MachineID() error = %v", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if m.Exists(l.key(id)) {
+		t.Fatalf("lease %s still exists after Close", l.key(id))
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("second Close() error = %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package id
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// MachineIDProvider 为 Sonyflake 分配机器ID.
+// 实现了 io.Closer 的提供者会在 Sonyflake.Close 时释放机器ID，
+// 实现了 Err() error 的提供者在机器ID失效后会使 NextID 返回该错误.
+type MachineIDProvider interface {
+	MachineID(ctx context.Context) (uint16, error)
+}
+
+// MachineIDFunc 将函数转换为 MachineIDProvider.
+type MachineIDFunc func(ctx context.Context) (uint16, error)
+
+// MachineID 调用 f(ctx).
+func (f MachineIDFunc) MachineID(ctx context.Context) (uint16, error) {
+	return f(ctx)
+}
+
+// PrivateIPMachineID 使用私有 IPv4 地址的低 16 位作为机器ID，与 sonyflake 的默认行为一致.
+// 同一个 /16 网段内的实例不会冲突.
+func PrivateIPMachineID() MachineIDProvider {
+	return MachineIDFunc(func(context.Context) (uint16, error) {
+		addrs, err := net.InterfaceAddrs()
+		if err != nil {
+			return 0, err
+		}
+		for _, addr := range addrs {
+			ipnet, ok := addr.(*net.IPNet)
+			if !ok || ipnet.IP.IsLoopback() {
+				continue
+			}
+			if ip := ipnet.IP.To4(); ip != nil && (ip.IsPrivate() || ip.IsLinkLocalUnicast()) {
+				return uint16(ip[2])<<8 + uint16(ip[3]), nil
+			}
+		}
+		return 0, errors.New("no private ip address")
+	})
+}
+
+// HostnameMachineID 使用主机名末尾的序号作为机器ID，适用于 Kubernetes StatefulSet，
+// 例如主机名 order-3 的机器ID为 3.
+func HostnameMachineID() MachineIDProvider {
+	return MachineIDFunc(func(context.Context) (uint16, error) {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return 0, err
+		}
+		return parseOrdinal(hostname)
+	})
+}
+
+// parseOrdinal 解析 <name>-<ordinal> 格式主机名中的序号
+func parseOrdinal(hostname string) (uint16, error) {
+	i := strings.LastIndexByte(hostname, '-')
+	if i < 0 {
+		return 0, fmt.Errorf("hostname %q has no ordinal", hostname)
+	}
+	n, err := strconv.ParseUint(hostname[i+1:], 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("hostname %q has no ordinal", hostname)
+	}
+	return uint16(n), nil
+}
+
+// EnvMachineID 从环境变量中读取机器ID.
+func EnvMachineID(key string) MachineIDProvider {
+	return MachineIDFunc(func(context.Context) (uint16, error) {
+		v, ok := os.LookupEnv(key)
+		if !ok {
+			return 0, fmt.Errorf("environment variable %s is not set", key)
+		}
+		n, err := strconv.ParseUint(strings.TrimSpace(v), 10, 16)
+		if err != nil {
+			return 0, fmt.Errorf("invalid machine id in %s: %w", key, err)
+		}
+		return uint16(n), nil
+	})
+}
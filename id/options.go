@@ -1,10 +1,15 @@
 package id
 
-import "time"
+import (
+	"crypto/rand"
+	"io"
+	"time"
+)
 
 type SonyflakeOptions struct {
-	machineId uint16
-	startTime time.Time
+	machineId         uint16
+	machineIdProvider MachineIDProvider
+	startTime         time.Time
 }
 
 func getSonyflakeOptionsOrSetDefault(options *SonyflakeOptions) *SonyflakeOptions {
@@ -17,7 +22,8 @@ func getSonyflakeOptionsOrSetDefault(options *SonyflakeOptions) *SonyflakeOption
 	return options
 }
 
-// WithSonyflakeMachineId 函数返回一个函数，用于设置机器ID。
+// WithSonyflakeMachineId 函数返回一个函数，用于设置机器ID，传入 0 时忽略并使用默认值 1。
+// 需要按 IP、主机名等分配机器ID时使用 WithSonyflakeMachineIdProvider。
 func WithSonyflakeMachineId(id uint16) func(*SonyflakeOptions) {
 	return func(options *SonyflakeOptions) {
 		if id > 0 {
@@ -26,6 +32,15 @@ func WithSonyflakeMachineId(id uint16) func(*SonyflakeOptions) {
 	}
 }
 
+// WithSonyflakeMachineIdProvider 函数返回一个函数，用于设置机器ID的提供者，设置后忽略 WithSonyflakeMachineId。
+func WithSonyflakeMachineIdProvider(provider MachineIDProvider) func(*SonyflakeOptions) {
+	return func(options *SonyflakeOptions) {
+		if provider != nil {
+			getSonyflakeOptionsOrSetDefault(options).machineIdProvider = provider
+		}
+	}
+}
+
 // WithSonyflakeStartTime 函数返回一个函数，用于设置起始时间。
 func WithSonyflakeStartTime(startTime time.Time) func(*SonyflakeOptions) {
 	return func(options *SonyflakeOptions) {
@@ -36,11 +51,24 @@ func WithSonyflakeStartTime(startTime time.Time) func(*SonyflakeOptions) {
 }
 
 type CodeOptions struct {
-	chars []rune
-	n1    int
-	n2    int
-	l     int
-	salt  uint64
+	chars       []rune
+	n1          int
+	n2          int
+	l           int
+	salt        uint64
+	checksum    bool
+	confusables map[rune]rune
+}
+
+// defaultConfusables 手工输入时容易混淆的字符，只在输入的字符不在字符集中时使用.
+// 每组字符的映射首尾相连，沿映射总能找到同组中在字符集中的字符，例如默认字符集中 1、I 都映射为 L
+var defaultConfusables = map[rune]rune{
+	'0': 'O', 'O': '0',
+	'1': 'I', 'I': 'L', 'L': '1',
+	'2': 'Z', 'Z': '2',
+	'5': 'S', 'S': '5',
+	'8': 'B', 'B': '8',
+	'U': 'V', 'V': 'U',
 }
 
 func getCodeOptionsOrSetDefault(options *CodeOptions) *CodeOptions {
@@ -63,6 +91,8 @@ func getCodeOptionsOrSetDefault(options *CodeOptions) *CodeOptions {
 			l: 8,
 			// random number
 			salt: 123567369,
+			// user-entered confusable chars
+			confusables: defaultConfusables,
 		}
 	}
 	return options
@@ -108,3 +138,51 @@ func WithCodeSalt(salt uint64) func(*CodeOptions) {
 		}
 	}
 }
+
+type GeneratorOptions struct {
+	clock   func() time.Time
+	entropy io.Reader
+}
+
+func getGeneratorOptionsOrSetDefault(options *GeneratorOptions) *GeneratorOptions {
+	if options == nil {
+		return &GeneratorOptions{
+			clock:   time.Now,
+			entropy: rand.Reader,
+		}
+	}
+	return options
+}
+
+// WithClock 函数返回一个函数，用于设置生成器读取当前时间的时钟。
+func WithClock(clock func() time.Time) func(*GeneratorOptions) {
+	return func(options *GeneratorOptions) {
+		if clock != nil {
+			getGeneratorOptionsOrSetDefault(options).clock = clock
+		}
+	}
+}
+
+// WithEntropy 函数返回一个函数，用于设置生成器的随机数来源，默认为 crypto/rand。
+func WithEntropy(entropy io.Reader) func(*GeneratorOptions) {
+	return func(options *GeneratorOptions) {
+		if entropy != nil {
+			getGeneratorOptionsOrSetDefault(options).entropy = entropy
+		}
+	}
+}
+
+// WithCodeChecksum 函数返回一个函数，用于在Code末尾添加 Luhn mod N 校验字符。
+func WithCodeChecksum(enable bool) func(*CodeOptions) {
+	return func(options *CodeOptions) {
+		getCodeOptionsOrSetDefault(options).checksum = enable
+	}
+}
+
+// WithCodeConfusables 函数返回一个函数，用于设置解码时易混淆字符的映射，只映射不在字符集中的字符，
+// 映射目标不在字符集中时继续沿映射查找。
+func WithCodeConfusables(confusables map[rune]rune) func(*CodeOptions) {
+	return func(options *CodeOptions) {
+		getCodeOptionsOrSetDefault(options).confusables = confusables
+	}
+}
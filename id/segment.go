@@ -0,0 +1,248 @@
+package id
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrSegmentConflict 多次乐观更新号段都与其它实例冲突.
+var ErrSegmentConflict = errors.New("segment update conflict")
+
+const (
+	// segmentMaxRetries 乐观更新号段的最大重试次数
+	segmentMaxRetries = 10
+	// segmentMinBackoff 预取失败后第一次重新预取的等待时间
+	segmentMinBackoff = 100 * time.Millisecond
+	// segmentMaxBackoff 重新预取等待时间的上限
+	segmentMaxBackoff = 10 * time.Second
+)
+
+// SegmentModel 号段表，每个业务标识一行，max_id 为已经分配出去的最大 ID.
+// 使用前需要创建表，例如 db.AutoMigrate(&id.SegmentModel{}).
+type SegmentModel struct {
+	BizTag    string    `gorm:"column:biz_tag;primaryKey;size:128"`
+	MaxID     uint64    `gorm:"column:max_id;not null;default:0"`
+	Step      uint64    `gorm:"column:step;not null"`
+	Version   uint64    `gorm:"column:version;not null;default:0"`
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+}
+
+// TableName 返回默认的表名.
+func (SegmentModel) TableName() string {
+	return "id_segments"
+}
+
+type SegmentOptions struct {
+	table     string
+	step      uint64
+	threshold float64
+	timeout   time.Duration
+}
+
+func getSegmentOptionsOrSetDefault(options *SegmentOptions) *SegmentOptions {
+	if options == nil {
+		return &SegmentOptions{
+			table:     SegmentModel{}.TableName(),
+			step:      1000,
+			threshold: 0.1,
+			timeout:   3 * time.Second,
+		}
+	}
+	return options
+}
+
+// WithSegmentTable 函数返回一个函数，用于设置号段表的表名。
+func WithSegmentTable(table string) func(*SegmentOptions) {
+	return func(options *SegmentOptions) {
+		if table != "" {
+			getSegmentOptionsOrSetDefault(options).table = table
+		}
+	}
+}
+
+// WithSegmentStep 函数返回一个函数，用于设置业务标识不存在时创建的号段长度，已存在时使用表中的 step。
+func WithSegmentStep(step uint64) func(*SegmentOptions) {
+	return func(options *SegmentOptions) {
+		if step > 0 {
+			getSegmentOptionsOrSetDefault(options).step = step
+		}
+	}
+}
+
+// WithSegmentThreshold 函数返回一个函数，用于设置当前号段使用超过该比例时在后台预取下一个号段，默认为 0.1。
+func WithSegmentThreshold(threshold float64) func(*SegmentOptions) {
+	return func(options *SegmentOptions) {
+		if threshold >= 0 && threshold <= 1 {
+			getSegmentOptionsOrSetDefault(options).threshold = threshold
+		}
+	}
+}
+
+// WithSegmentTimeout 函数返回一个函数，用于设置从数据库获取号段的超时时间。
+func WithSegmentTimeout(timeout time.Duration) func(*SegmentOptions) {
+	return func(options *SegmentOptions) {
+		if timeout > 0 {
+			getSegmentOptionsOrSetDefault(options).timeout = timeout
+		}
+	}
+}
+
+// segmentRange 内存中的号段 [next, max]
+type segmentRange struct {
+	next uint64
+	max  uint64
+	size uint64
+}
+
+// Segment 号段模式的 ID 分配器（参考美团 Leaf）：每次通过乐观更新从数据库获取一段 ID，在内存中分配，
+// 当前号段使用超过阈值时在后台预取下一个号段（双缓冲），数据库短暂不可用时不影响分配.
+// 生成的 ID 不包含时间与机器信息，且不依赖时钟.
+type Segment struct {
+	db     *gorm.DB
+	bizTag string
+	ops    SegmentOptions
+
+	mu      sync.Mutex
+	current *segmentRange
+	next    *segmentRange
+	loading chan struct{} // 正在获取号段时不为空，获取完成后关闭
+	err     error         // 最近一次获取号段的错误
+	backoff time.Duration // 连续预取失败时的等待时间
+	retryAt time.Time     // 预取失败后，在该时间之前不再预取
+	wg      sync.WaitGroup
+}
+
+// NewSegment 根据提供的数据库、业务标识与选项函数创建一个新的 Segment 实例，号段在第一次生成 ID 时获取.
+func NewSegment(db *gorm.DB, bizTag string, options ...func(*SegmentOptions)) (*Segment, error) {
+	if db == nil {
+		return nil, errors.New("segment requires a database")
+	}
+	if bizTag == "" {
+		return nil, errors.New("segment requires a biz tag")
+	}
+	ops := getSegmentOptionsOrSetDefault(nil)
+	for _, f := range options {
+		f(ops)
+	}
+	return &Segment{db: db, bizTag: bizTag, ops: *ops}, nil
+}
+
+// NextID 从内存中的号段分配 ID，号段用完时等待获取下一个号段，直到 ctx 取消或超时.
+// 预取失败时按有上限的指数回避算法重新预取；号段用完时立即重新获取，获取失败时返回本次获取的错误.
+func (s *Segment) NextID(ctx context.Context) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for {
+		if cur := s.current; cur != nil && cur.next <= cur.max {
+			id := cur.next
+			cur.next++
+			// 使用超过阈值时预取下一个号段，预取失败后等待一段时间再重新预取
+			if s.next == nil && s.loading == nil && !time.Now().Before(s.retryAt) &&
+				float64(cur.next-(cur.max-cur.size+1)) >= s.ops.threshold*float64(cur.size) {
+				s.load()
+			}
+			return id, nil
+		}
+
+		// 当前号段用完，切换到预取的号段
+		if s.next != nil {
+			s.current, s.next = s.next, nil
+			continue
+		}
+
+		// 没有正在进行的获取时重新获取，不返回之前预取失败的错误
+		if s.loading == nil {
+			s.load()
+		}
+
+		// 等待获取完成
+		loading := s.loading
+		s.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			s.mu.Lock()
+			return 0, ctx.Err()
+		case <-loading:
+		}
+		s.mu.Lock()
+
+		// 等待的获取失败时返回错误，号段已经被其它调用切换时继续分配
+		if s.next == nil && s.err != nil {
+			return 0, s.err
+		}
+	}
+}
+
+// load 在后台获取下一个号段，调用方需要持有锁
+func (s *Segment) load() {
+	loading := make(chan struct{})
+	s.loading, s.err = loading, nil
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), s.ops.timeout)
+		defer cancel()
+		r, err := s.fetch(ctx)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.next, s.err, s.loading = r, err, nil
+		if err != nil {
+			s.backoff = min(max(s.backoff*2, segmentMinBackoff), segmentMaxBackoff)
+			s.retryAt = time.Now().Add(s.backoff)
+		} else {
+			s.backoff, s.retryAt = 0, time.Time{}
+		}
+		close(loading)
+	}()
+}
+
+// fetch 使用乐观更新将 max_id 增加 step，返回新的号段 (max_id, max_id+step]
+func (s *Segment) fetch(ctx context.Context) (*segmentRange, error) {
+	db := s.db.WithContext(ctx)
+	for i := 0; i < segmentMaxRetries; i++ {
+		var row SegmentModel
+		err := db.Table(s.ops.table).Where("biz_tag = ?", s.bizTag).Take(&row).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// 业务标识不存在时创建，多个实例同时创建时只有一个成功
+			err = db.Table(s.ops.table).Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&SegmentModel{BizTag: s.bizTag, Step: s.ops.step, UpdatedAt: time.Now()}).Error
+			if err != nil {
+				return nil, fmt.Errorf("create segment %s: %w", s.bizTag, err)
+			}
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("get segment %s: %w", s.bizTag, err)
+		}
+		if row.Step == 0 {
+			return nil, fmt.Errorf("segment %s has zero step", s.bizTag)
+		}
+
+		res := db.Table(s.ops.table).Where("biz_tag = ? AND version = ?", s.bizTag, row.Version).Updates(map[string]any{
+			"max_id":     row.MaxID + row.Step,
+			"version":    row.Version + 1,
+			"updated_at": time.Now(),
+		})
+		if res.Error != nil {
+			return nil, fmt.Errorf("update segment %s: %w", s.bizTag, res.Error)
+		}
+		if res.RowsAffected == 1 {
+			return &segmentRange{next: row.MaxID + 1, max: row.MaxID + row.Step, size: row.Step}, nil
+		}
+	}
+	return nil, ErrSegmentConflict
+}
+
+// Close 等待正在进行的号段获取完成，未使用的 ID 会被丢弃.
+func (s *Segment) Close() error {
+	s.wg.Wait()
+	return nil
+}
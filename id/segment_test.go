@@ -0,0 +1,301 @@
+package id
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB 创建一个 SQLite 数据库文件并迁移号段表，多个实例打开同一个文件时模拟多个服务
+func newTestDB(t *testing.T) string {
+	t.Helper()
+	dsn := filepath.Join(t.TempDir(), "segment.db") + "?_busy_timeout=5000&_journal_mode=WAL"
+	db := openTestDB(t, dsn)
+	if err := db.AutoMigrate(&SegmentModel{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return dsn
+}
+
+func openTestDB(t *testing.T, dsn string) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// hasNext 判断是否已经预取了下一个号段
+func (s *Segment) hasNext() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.next != nil
+}
+
+func TestSegmentCreatesRow(t *testing.T) {
+	db := openTestDB(t, newTestDB(t))
+	seg, err := NewSegment(db, "order", WithSegmentStep(10), WithSegmentThreshold(1))
+	if err != nil {
+		t.Fatalf("NewSegment() error = %v", err)
+	}
+	defer seg.Close()
+
+	for want := uint64(1); want <= 3; want++ {
+		id, err := seg.NextID(context.Background())
+		if err != nil {
+			t.Fatalf("NextID() error = %v", err)
+		}
+		if id != want {
+			t.Fatalf("NextID() = %d, want %d", id, want)
+		}
+	}
+
+	var row SegmentModel
+	if err := db.Where("biz_tag = ?", "order").Take(&row).Error; err != nil {
+		t.Fatalf("get segment row: %v", err)
+	}
+	if row.MaxID != 10 || row.Step != 10 || row.Version != 1 {
+		t.Fatalf("segment row = %+v, want max_id 10, step 10, version 1", row)
+	}
+}
+
+func TestSegmentConcurrentInstances(t *testing.T) {
+	const instances, perInstance = 4, 300
+	dsn := newTestDB(t)
+
+	ids := make([][]uint64, instances)
+	errs := make(chan error, instances)
+	var wg sync.WaitGroup
+	for i := range ids {
+		seg, err := NewSegment(openTestDB(t, dsn), "order", WithSegmentStep(50), WithSegmentTimeout(10*time.Second))
+		if err != nil {
+			t.Fatalf("NewSegment() error = %v", err)
+		}
+		defer seg.Close()
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < perInstance; j++ {
+				id, err := seg.NextID(context.Background())
+				if err != nil {
+					errs <- err
+					return
+				}
+				ids[i] = append(ids[i], id)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("NextID() error = %v", err)
+	}
+
+	seen := make(map[uint64]bool, instances*perInstance)
+	for _, list := range ids {
+		for j, id := range list {
+			if seen[id] {
+				t.Fatalf("duplicate id %d across instances", id)
+			}
+			seen[id] = true
+			if j > 0 && id <= list[j-1] {
+				t.Fatalf("id %d is not greater than previous id %d", id, list[j-1])
+			}
+		}
+	}
+}
+
+func TestSegmentPrefetch(t *testing.T) {
+	db := openTestDB(t, newTestDB(t))
+	seg, err := NewSegment(db, "order", WithSegmentStep(10), WithSegmentThreshold(0.5))
+	if err != nil {
+		t.Fatalf("NewSegment() error = %v", err)
+	}
+	defer seg.Close()
+
+	next := func() uint64 {
+		t.Helper()
+		id, err := seg.NextID(context.Background())
+		if err != nil {
+			t.Fatalf("NextID() error = %v", err)
+		}
+		return id
+	}
+	for want := uint64(1); want <= 5; want++ {
+		if id := next(); id != want {
+			t.Fatalf("NextID() = %d, want %d", id, want)
+		}
+	}
+	// 使用一半后在后台预取下一个号段
+	if !waitFor(t, time.Second, seg.hasNext) {
+		t.Fatal("next segment was not prefetched")
+	}
+
+	// 切换到预取的号段后 ID 仍然连续
+	for want := uint64(6); want <= 15; want++ {
+		if id := next(); id != want {
+			t.Fatalf("NextID() = %d, want %d", id, want)
+		}
+	}
+	seg.wg.Wait()
+	var row SegmentModel
+	if err := db.Where("biz_tag = ?", "order").Take(&row).Error; err != nil {
+		t.Fatalf("get segment row: %v", err)
+	}
+	if row.MaxID != 30 {
+		t.Fatalf("max_id = %d, want 30 after prefetching the third segment", row.MaxID)
+	}
+}
+
+func TestSegmentPrefetchRetry(t *testing.T) {
+	db := openTestDB(t, newTestDB(t))
+	var failing atomic.Bool
+	errInjected := errors.New("injected failure")
+	err := db.Callback().Query().Before("gorm:query").Register("test:fail", func(tx *gorm.DB) {
+		if failing.Load() {
+			_ = tx.AddError(errInjected)
+		}
+	})
+	if err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+
+	seg, err := NewSegment(db, "order", WithSegmentStep(20), WithSegmentThreshold(0.1))
+	if err != nil {
+		t.Fatalf("NewSegment() error = %v", err)
+	}
+	defer seg.Close()
+
+	next := func(want uint64) {
+		t.Helper()
+		id, err := seg.NextID(context.Background())
+		if err != nil {
+			t.Fatalf("NextID() error = %v", err)
+		}
+		if id != want {
+			t.Fatalf("NextID() = %d, want %d", id, want)
+		}
+	}
+
+	// 第一次获取号段成功后让预取失败
+	failing.Store(true)
+	if _, err := seg.NextID(context.Background()); !errors.Is(err, errInjected) {
+		t.Fatalf("NextID() error = %v, want %v", err, errInjected)
+	}
+	failing.Store(false)
+	next(1)
+	failing.Store(true)
+	next(2)
+	next(3)
+	seg.wg.Wait()
+	if seg.hasNext() {
+		t.Fatal("prefetch succeeded while the database was failing")
+	}
+
+	// 回避时间内不重新预取，之后越过阈值时重新预取
+	failing.Store(false)
+	next(4)
+	seg.wg.Wait()
+	if seg.hasNext() {
+		t.Fatal("prefetch retried before backoff elapsed")
+	}
+	time.Sleep(segmentMinBackoff)
+	next(5)
+	if !waitFor(t, time.Second, seg.hasNext) {
+		t.Fatal("prefetch was not retried after backoff")
+	}
+	for want := uint64(6); want <= 25; want++ {
+		next(want)
+	}
+}
+
+func TestSegmentExhaustedAfterFailedPrefetch(t *testing.T) {
+	db := openTestDB(t, newTestDB(t))
+	var failing atomic.Bool
+	err := db.Callback().Query().Before("gorm:query").Register("test:fail", func(tx *gorm.DB) {
+		if failing.Load() {
+			_ = tx.AddError(errors.New("injected failure"))
+		}
+	})
+	if err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+
+	seg, err := NewSegment(db, "order", WithSegmentStep(5), WithSegmentThreshold(0.4))
+	if err != nil {
+		t.Fatalf("NewSegment() error = %v", err)
+	}
+	defer seg.Close()
+
+	if _, err := seg.NextID(context.Background()); err != nil {
+		t.Fatalf("NextID() error = %v", err)
+	}
+	// 预取失败后数据库恢复，号段用完时重新获取，不返回之前预取失败的错误
+	failing.Store(true)
+	for i := 0; i < 2; i++ {
+		if _, err := seg.NextID(context.Background()); err != nil {
+			t.Fatalf("NextID() error = %v", err)
+		}
+	}
+	seg.wg.Wait()
+	failing.Store(false)
+	for want := uint64(4); want <= 12; want++ {
+		id, err := seg.NextID(context.Background())
+		if err != nil {
+			t.Fatalf("NextID() error = %v", err)
+		}
+		if id != want {
+			t.Fatalf("NextID() = %d, want %d", id, want)
+		}
+	}
+}
+
+func TestSegmentContextCanceled(t *testing.T) {
+	db := openTestDB(t, newTestDB(t))
+	release := make(chan struct{})
+	err := db.Callback().Query().Before("gorm:query").Register("test:block", func(*gorm.DB) {
+		<-release
+	})
+	if err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+
+	seg, err := NewSegment(db, "order")
+	if err != nil {
+		t.Fatalf("NewSegment() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := seg.NextID(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("NextID() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// 取消等待不影响后台的获取，之后的调用使用获取到的号段
+	close(release)
+	id, err := seg.NextID(context.Background())
+	if err != nil {
+		t.Fatalf("NextID() error = %v", err)
+	}
+	if id != 1 {
+		t.Fatalf("NextID() = %d, want 1", id)
+	}
+	if err := seg.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package id
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Twitter Snowflake 的布局：1 位符号位，41 位毫秒时间戳，10 位机器ID，12 位序列号
+const (
+	snowflakeTimeBits     = 41
+	snowflakeNodeBits     = 10
+	snowflakeSequenceBits = 12
+
+	// SnowflakeMaxNode Snowflake 机器ID的最大值
+	SnowflakeMaxNode = 1<<snowflakeNodeBits - 1
+	// maxClockBackwards 时钟回拨超过该时间时不再等待，直接返回错误
+	maxClockBackwards = 5 * time.Second
+)
+
+// SnowflakeEpoch Snowflake 的起始时间，与 Twitter 相同.
+var SnowflakeEpoch = time.UnixMilli(1288834974657)
+
+// ErrClockMovedBackwards 时钟回拨超过 5 秒.
+var ErrClockMovedBackwards = errors.New("clock moved backwards")
+
+// Snowflake 按 Twitter Snowflake 布局生成 ID.
+type Snowflake struct {
+	ops  GeneratorOptions
+	node uint64
+
+	mu       sync.Mutex
+	last     int64
+	sequence uint64
+}
+
+// NewSnowflake 根据机器ID与提供的选项函数创建一个新的 Snowflake 实例，机器ID不能超过 SnowflakeMaxNode.
+func NewSnowflake(node uint16, options ...func(*GeneratorOptions)) (*Snowflake, error) {
+	if node > SnowflakeMaxNode {
+		return nil, fmt.Errorf("snowflake node %d exceeds %d", node, SnowflakeMaxNode)
+	}
+	ops := getGeneratorOptionsOrSetDefault(nil)
+	for _, f := range options {
+		f(ops)
+	}
+	return &Snowflake{ops: *ops, node: uint64(node), last: -1}, nil
+}
+
+// NextID 生成唯一 ID.
+// 同一毫秒内的序列号用完或时钟回拨时等待时钟追上，直到 ctx 取消或超时.
+func (s *Snowflake) NextID(ctx context.Context) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for {
+		now := s.ops.clock().Sub(SnowflakeEpoch).Milliseconds()
+		if now < 0 {
+			return 0, ErrStartTimeAhead
+		}
+		if now >= 1<<snowflakeTimeBits {
+			return 0, ErrOverTimeLimit
+		}
+
+		switch {
+		case now > s.last:
+			s.last, s.sequence = now, 0
+			return s.id(), nil
+		case now == s.last && s.sequence < 1<<snowflakeSequenceBits-1:
+			s.sequence++
+			return s.id(), nil
+		case s.last-now > maxClockBackwards.Milliseconds():
+			return 0, ErrClockMovedBackwards
+		}
+
+		// 等待进入下一毫秒
+		timer := time.NewTimer(time.Duration(s.last-now+1) * time.Millisecond)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return 0, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// id 组合时间戳、机器ID与序列号
+func (s *Snowflake) id() uint64 {
+	return uint64(s.last)<<(snowflakeNodeBits+snowflakeSequenceBits) | s.node<<snowflakeSequenceBits | s.sequence
+}
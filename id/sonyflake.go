@@ -4,71 +4,167 @@ import (
 	"context"
 	"fmt"
 	"github.com/sony/sonyflake"
+	"io"
 	"time"
 )
 
+// sonyflakeTimeUnit Sonyflake 的时间单位
+const sonyflakeTimeUnit = 10 * time.Millisecond
+
+var (
+	// ErrStartTimeAhead 起始时间晚于当前时间.
+	ErrStartTimeAhead = sonyflake.ErrStartTimeAhead
+	// ErrOverTimeLimit 从起始时间开始经过的时间超出了 Sonyflake 的 39 位时间范围（约 174 年），无法再生成 ID.
+	ErrOverTimeLimit = sonyflake.ErrOverTimeLimit
+)
+
+const (
+	// machineIDTimeout 创建实例时从提供者获取机器ID的超时时间
+	machineIDTimeout = 10 * time.Second
+)
+
 type Sonyflake struct {
 	ops   SonyflakeOptions
 	sf    *sonyflake.Sonyflake
 	Error error
 }
 
-// NewSonyflake 根据提供的选项函数创建一个新的 Sonyflake 实例
+// NewSonyflake 根据提供的选项函数创建一个新的 Sonyflake 实例，创建失败时错误记录在 Error 字段中.
 func NewSonyflake(options ...func(sonyflakeOptions *SonyflakeOptions)) *Sonyflake {
+	sf, err := NewSonyflakeWithError(options...)
+	if err != nil {
+		return &Sonyflake{Error: err}
+	}
+	return sf
+}
+
+// NewSonyflakeWithError 根据提供的选项函数创建一个新的 Sonyflake 实例.
+// 起始时间与机器ID在创建时校验，不会为了校验而消耗 ID.
+func NewSonyflakeWithError(options ...func(sonyflakeOptions *SonyflakeOptions)) (*Sonyflake, error) {
 	// 获取默认选项或设置提供的选项
 	ops := getSonyflakeOptionsOrSetDefault(nil)
 	for _, f := range options {
 		f(ops)
 	}
-	// 使用提供的选项初始化 Sonyflake
-	sf := &Sonyflake{
-		ops: *ops,
+	if err := validateStartTime(ops.startTime, time.Now()); err != nil {
+		return nil, err
 	}
+
 	st := sonyflake.Settings{
 		StartTime: ops.startTime,
 	}
-	if ops.machineId > 0 {
-		st.MachineID = func() (uint16, error) {
-			return ops.machineId, nil
+	// 优先使用机器ID的提供者，否则使用 WithSonyflakeMachineId 设置的机器ID（默认为 1）
+	if p := ops.machineIdProvider; p != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), machineIDTimeout)
+		machineId, err := p.MachineID(ctx)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("get machine id: %w", err)
 		}
+		ops.machineId = machineId
 	}
-
-	// 创建 Sonyflake 实例
-	ins := sonyflake.NewSonyflake(st)
-	if ins == nil {
-		sf.Error = fmt.Errorf("create sonyflake failed")
+	st.MachineID = func() (uint16, error) {
+		return ops.machineId, nil
 	}
 
-	// 检查时间是否有效，当时间错误后，NextID返回错误
-	_, err := ins.NextID()
+	// 创建 Sonyflake 实例，获取机器ID失败时返回错误
+	ins, err := sonyflake.New(st)
 	if err != nil {
-		sf.Error = fmt.Errorf("invalid start time")
+		_ = closeProvider(ops.machineIdProvider)
+		return nil, fmt.Errorf("create sonyflake: %w", err)
 	}
-	sf.sf = ins
-	return sf
+	return &Sonyflake{ops: *ops, sf: ins}, nil
+}
+
+// closeProvider 释放提供者分配的机器ID
+func closeProvider(p MachineIDProvider) error {
+	if c, ok := p.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// validateStartTime 校验起始时间不晚于当前时间，且到当前时间的间隔在 39 位时间范围内
+func validateStartTime(startTime, now time.Time) error {
+	if startTime.After(now) {
+		return ErrStartTimeAhead
+	}
+	if now.Sub(startTime)/sonyflakeTimeUnit >= 1<<sonyflake.BitLenTime {
+		return ErrOverTimeLimit
+	}
+	return nil
 }
 
-// Id 使用 Sonyflake 实例生成唯一 ID
+// Id 使用 Sonyflake 实例生成唯一 ID，生成失败时返回 0.
+// 需要区分失败原因时使用 NextID.
 func (s *Sonyflake) Id(ctx context.Context) (id uint64) {
+	id, _ = s.NextID(ctx)
+	return
+}
+
+// NextID 使用 Sonyflake 实例生成唯一 ID.
+// 创建实例时的错误与 ctx 的错误会直接返回；同一个 10ms 内的序列号用完时 sonyflake 会在内部休眠到下一个 10ms，
+// 休眠期间不会响应 ctx 的取消. 时间超出范围时返回 ErrOverTimeLimit，该错误无法通过重试恢复.
+func (s *Sonyflake) NextID(ctx context.Context) (uint64, error) {
 	// 如果有错误，则直接返回
 	if s.Error != nil {
-		return
+		return 0, s.Error
 	}
-
-	var err error
-	// 生成ID，生成成功则返回
-	id, err = s.sf.NextID()
-	if err == nil {
-		return
+	// 调用方已经取消时不再生成
+	if err := ctx.Err(); err != nil {
+		return 0, err
 	}
-	// 生成失败则使用指数回避算法重试
-	sleep := 1
-	for {
-		time.Sleep(time.Duration(sleep) * time.Millisecond)
-		id, err = s.sf.NextID()
-		if err == nil {
-			return
+	// 机器ID失效后继续生成可能与其它实例冲突
+	if p, ok := s.ops.machineIdProvider.(interface{ Err() error }); ok {
+		if err := p.Err(); err != nil {
+			return 0, err
 		}
-		sleep *= 2
 	}
+	return s.sf.NextID()
+}
+
+// Close 释放机器ID的提供者分配的机器ID，之后不应再生成 ID.
+func (s *Sonyflake) Close() error {
+	return closeProvider(s.ops.machineIdProvider)
+}
+
+// SonyflakeParts Sonyflake ID 的组成部分.
+type SonyflakeParts struct {
+	Time      time.Time // 生成时间，精度为 10ms
+	Sequence  uint16    // 同一个 10ms 内的序列号
+	MachineID uint16    // 机器ID
+}
+
+// Decompose 根据配置的起始时间将 ID 拆分为生成时间、序列号与机器ID.
+func (s *Sonyflake) Decompose(id uint64) SonyflakeParts {
+	const maskSequence = 1<<sonyflake.BitLenSequence - 1
+	const maskMachineID = 1<<sonyflake.BitLenMachineID - 1
+
+	elapsed := int64(id >> (sonyflake.BitLenSequence + sonyflake.BitLenMachineID))
+	return SonyflakeParts{
+		Time:      time.UnixMilli((toSonyflakeTime(s.ops.startTime) + elapsed) * sonyflakeTimeUnit.Milliseconds()).UTC(),
+		Sequence:  uint16(id >> sonyflake.BitLenMachineID & maskSequence),
+		MachineID: uint16(id & maskMachineID),
+	}
+}
+
+// MinIDForTime 返回时间 t 所在的 10ms 内可能生成的最小 ID，与 MaxIDForTime 一起将时间范围转换为主键范围.
+func (s *Sonyflake) MinIDForTime(t time.Time) uint64 {
+	return s.elapsedTime(t) << (sonyflake.BitLenSequence + sonyflake.BitLenMachineID)
+}
+
+// MaxIDForTime 返回时间 t 所在的 10ms 内可能生成的最大 ID.
+func (s *Sonyflake) MaxIDForTime(t time.Time) uint64 {
+	return s.MinIDForTime(t) | (1<<(sonyflake.BitLenSequence+sonyflake.BitLenMachineID) - 1)
+}
+
+// elapsedTime 返回从起始时间到 t 经过的时间，单位为 10ms，超出范围时取边界值
+func (s *Sonyflake) elapsedTime(t time.Time) uint64 {
+	elapsed := toSonyflakeTime(t) - toSonyflakeTime(s.ops.startTime)
+	return uint64(min(max(elapsed, 0), 1<<sonyflake.BitLenTime-1))
+}
+
+// toSonyflakeTime 与 sonyflake 相同，将时间转换为以 10ms 为单位的时间戳，按秒计算以免超出 UnixNano 的范围
+func toSonyflakeTime(t time.Time) int64 {
+	return t.Unix()*int64(time.Second/sonyflakeTimeUnit) + int64(t.Nanosecond())/int64(sonyflakeTimeUnit)
 }
@@ -0,0 +1,256 @@
+package id
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sony/sonyflake"
+)
+
+func TestNewSonyflakeWithError(t *testing.T) {
+	errProvider := errors.New("provider failed")
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		options   []func(*SonyflakeOptions)
+		wantErr   error
+		machineID uint16
+	}{
+		{
+			name:      "default",
+			machineID: 1,
+		},
+		{
+			name:    "start time ahead",
+			options: []func(*SonyflakeOptions){WithSonyflakeStartTime(now.Add(time.Hour))},
+			wantErr: ErrStartTimeAhead,
+		},
+		{
+			name:    "over time limit",
+			options: []func(*SonyflakeOptions){WithSonyflakeStartTime(now.AddDate(-180, 0, 0))},
+			wantErr: ErrOverTimeLimit,
+		},
+		{
+			name:      "zero machine id is ignored",
+			options:   []func(*SonyflakeOptions){WithSonyflakeMachineId(0)},
+			machineID: 1,
+		},
+		{
+			name:      "machine id",
+			options:   []func(*SonyflakeOptions){WithSonyflakeMachineId(42)},
+			machineID: 42,
+		},
+		{
+			name: "provider zero machine id",
+			options: []func(*SonyflakeOptions){
+				WithSonyflakeMachineId(42),
+				WithSonyflakeMachineIdProvider(MachineIDFunc(func(context.Context) (uint16, error) { return 0, nil })),
+			},
+			machineID: 0,
+		},
+		{
+			name: "provider error",
+			options: []func(*SonyflakeOptions){
+				WithSonyflakeMachineIdProvider(MachineIDFunc(func(context.Context) (uint16, error) { return 0, errProvider })),
+			},
+			wantErr: errProvider,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sf, err := NewSonyflakeWithError(tt.options...)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("NewSonyflakeWithError() error = %v, want %v", err, tt.wantErr)
+				}
+				if legacy := NewSonyflake(tt.options...); !errors.Is(legacy.Error, tt.wantErr) {
+					t.Fatalf("NewSonyflake().Error = %v, want %v", legacy.Error, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewSonyflakeWithError() error = %v", err)
+			}
+			id, err := sf.NextID(context.Background())
+			if err != nil {
+				t.Fatalf("NextID() error = %v", err)
+			}
+			if got := sf.Decompose(id).MachineID; got != tt.machineID {
+				t.Fatalf("machine id = %d, want %d", got, tt.machineID)
+			}
+		})
+	}
+}
+
+func TestValidateStartTime(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// 39 位时间范围，单位为 10ms
+	limit := time.Duration(1<<39) * sonyflakeTimeUnit
+
+	tests := []struct {
+		name      string
+		startTime time.Time
+		want      error
+	}{
+		{"now", now, nil},
+		{"past", now.Add(-time.Hour), nil},
+		{"ahead", now.Add(time.Nanosecond), ErrStartTimeAhead},
+		{"last valid unit", now.Add(-limit + sonyflakeTimeUnit), nil},
+		{"over limit", now.Add(-limit), ErrOverTimeLimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateStartTime(tt.startTime, now); !errors.Is(err, tt.want) {
+				t.Fatalf("validateStartTime() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSonyflakeNextIDContextCanceled(t *testing.T) {
+	sf, err := NewSonyflakeWithError()
+	if err != nil {
+		t.Fatalf("NewSonyflakeWithError() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := sf.NextID(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("NextID() error = %v, want %v", err, context.Canceled)
+	}
+	if id := sf.Id(ctx); id != 0 {
+		t.Fatalf("Id() = %d, want 0", id)
+	}
+	if _, err := sf.NextID(context.Background()); err != nil {
+		t.Fatalf("NextID() error = %v", err)
+	}
+}
+
+func TestSonyflakeNextIDOverTimeLimit(t *testing.T) {
+	// 创建后时间超出范围的实例：NewSonyflakeWithError 会拒绝这样的起始时间，这里直接创建 sonyflake 实例
+	startTime := time.Now().AddDate(-180, 0, 0)
+	ins, err := sonyflake.New(sonyflake.Settings{
+		StartTime: startTime,
+		MachineID: func() (uint16, error) { return 1, nil },
+	})
+	if err != nil {
+		t.Fatalf("sonyflake.New() error = %v", err)
+	}
+	sf := &Sonyflake{ops: SonyflakeOptions{startTime: startTime, machineId: 1}, sf: ins}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := sf.NextID(ctx); !errors.Is(err, ErrOverTimeLimit) {
+		t.Fatalf("NextID() error = %v, want %v", err, ErrOverTimeLimit)
+	}
+}
+
+func TestSonyflakeDecompose(t *testing.T) {
+	sf, err := NewSonyflakeWithError(WithSonyflakeMachineId(42))
+	if err != nil {
+		t.Fatalf("NewSonyflakeWithError() error = %v", err)
+	}
+
+	before := time.Now().Truncate(sonyflakeTimeUnit)
+	ids := make([]uint64, 300)
+	for i := range ids {
+		if ids[i], err = sf.NextID(context.Background()); err != nil {
+			t.Fatalf("NextID() error = %v", err)
+		}
+	}
+	after := time.Now()
+
+	prev := sf.Decompose(ids[0])
+	if prev.Sequence != 0 {
+		t.Fatalf("first sequence = %d, want 0", prev.Sequence)
+	}
+	for i, id := range ids {
+		parts := sf.Decompose(id)
+		if parts.MachineID != 42 {
+			t.Fatalf("machine id = %d, want 42", parts.MachineID)
+		}
+		if parts.Time.Before(before) || parts.Time.After(after) || !parts.Time.Equal(parts.Time.Truncate(sonyflakeTimeUnit)) {
+			t.Fatalf("time = %v, want a 10ms unit between %v and %v", parts.Time, before, after)
+		}
+		if i == 0 {
+			continue
+		}
+		// 同一个 10ms 内序列号递增，进入下一个 10ms 后从 0 开始
+		if parts.Time.Equal(prev.Time) && parts.Sequence != prev.Sequence+1 ||
+			parts.Time.After(prev.Time) && parts.Sequence != 0 || parts.Time.Before(prev.Time) {
+			t.Fatalf("parts %+v follow %+v", parts, prev)
+		}
+		prev = parts
+	}
+	// 300 个 ID 超过一个 10ms 内 256 个序列号的上限，至少跨越一个时间单位
+	if first := sf.Decompose(ids[0]); !prev.Time.After(first.Time) {
+		t.Fatalf("ids span a single time unit %v", first.Time)
+	}
+
+	// 生成的 ID 位于其生成时间的 ID 范围内
+	for _, id := range ids {
+		at := sf.Decompose(id).Time
+		if minID, maxID := sf.MinIDForTime(at), sf.MaxIDForTime(at); id < minID || id > maxID {
+			t.Fatalf("id %d outside [%d, %d] for %v", id, minID, maxID, at)
+		}
+	}
+	if ids[0] < sf.MinIDForTime(before) || ids[len(ids)-1] > sf.MaxIDForTime(after) {
+		t.Fatalf("ids outside the range of [%v, %v]", before, after)
+	}
+}
+
+func TestSonyflakeIDForTimeEdges(t *testing.T) {
+	const shift = sonyflake.BitLenSequence + sonyflake.BitLenMachineID
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sf, err := NewSonyflakeWithError(WithSonyflakeStartTime(start))
+	if err != nil {
+		t.Fatalf("NewSonyflakeWithError() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		unit uint64
+	}{
+		{"start", start, 0},
+		{"before start", start.Add(-time.Hour), 0},
+		{"end of first unit", start.Add(sonyflakeTimeUnit - time.Nanosecond), 0},
+		{"second unit", start.Add(sonyflakeTimeUnit), 1},
+		{"one second", start.Add(time.Second), 100},
+		{"over time limit", start.AddDate(200, 0, 0), 1<<sonyflake.BitLenTime - 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, want := sf.MinIDForTime(tt.at), tt.unit<<shift; got != want {
+				t.Fatalf("MinIDForTime() = %d, want %d", got, want)
+			}
+			if got, want := sf.MaxIDForTime(tt.at), (tt.unit+1)<<shift-1; got != want {
+				t.Fatalf("MaxIDForTime() = %d, want %d", got, want)
+			}
+		})
+	}
+
+	// 相邻时间单位的范围首尾相接
+	at := start.Add(time.Minute)
+	if sf.MaxIDForTime(at)+1 != sf.MinIDForTime(at.Add(sonyflakeTimeUnit)) {
+		t.Fatal("ranges of adjacent time units are not contiguous")
+	}
+	// 范围与 Decompose 一致
+	if got := sf.Decompose(sf.MaxIDForTime(at)); !got.Time.Equal(at) || got.Sequence != 1<<sonyflake.BitLenSequence-1 || got.MachineID != 1<<sonyflake.BitLenMachineID-1 {
+		t.Fatalf("Decompose(MaxIDForTime()) = %+v", got)
+	}
+
+	// 起始时间不是 10ms 的整数倍时，与 sonyflake 一样按各自所在的时间单位计算
+	unaligned, err := NewSonyflakeWithError(WithSonyflakeStartTime(start.Add(5 * time.Millisecond)))
+	if err != nil {
+		t.Fatalf("NewSonyflakeWithError() error = %v", err)
+	}
+	if got := unaligned.MinIDForTime(start.Add(10 * time.Millisecond)); got != 1<<shift {
+		t.Fatalf("MinIDForTime() = %d, want %d", got, uint64(1)<<shift)
+	}
+	if got := unaligned.MinIDForTime(start.Add(9 * time.Millisecond)); got != 0 {
+		t.Fatalf("MinIDForTime() = %d, want 0", got)
+	}
+}
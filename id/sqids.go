@@ -0,0 +1,259 @@
+package id
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// defaultSqidsBlocklist 默认屏蔽的单词，生成的 ID 包含这些单词时会重新生成
+var defaultSqidsBlocklist = []string{
+	"anal", "anus", "arse", "ass", "bitch", "boob", "butt", "cock", "crap", "cunt",
+	"damn", "dick", "dildo", "fag", "fuck", "jizz", "kike", "nazi", "nigger", "penis",
+	"piss", "porn", "pussy", "rape", "sex", "shit", "slut", "tits", "twat", "vagina",
+	"wank", "whore",
+}
+
+type SqidsOptions struct {
+	alphabet  string
+	minLength int
+	blocklist []string
+}
+
+func getSqidsOptionsOrSetDefault(options *SqidsOptions) *SqidsOptions {
+	if options == nil {
+		return &SqidsOptions{
+			alphabet:  "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789",
+			minLength: 0,
+			blocklist: defaultSqidsBlocklist,
+		}
+	}
+	return options
+}
+
+// WithSqidsAlphabet 函数返回一个函数，用于设置字符集，字符集至少包含 3 个不重复的 ASCII 字符。
+func WithSqidsAlphabet(alphabet string) func(*SqidsOptions) {
+	return func(options *SqidsOptions) {
+		if alphabet != "" {
+			getSqidsOptionsOrSetDefault(options).alphabet = alphabet
+		}
+	}
+}
+
+// WithSqidsMinLength 函数返回一个函数，用于设置生成的 ID 的最小长度，不能超过 255。
+func WithSqidsMinLength(minLength int) func(*SqidsOptions) {
+	return func(options *SqidsOptions) {
+		getSqidsOptionsOrSetDefault(options).minLength = minLength
+	}
+}
+
+// WithSqidsBlocklist 函数返回一个函数，用于设置屏蔽的单词，传入空列表时关闭屏蔽。
+func WithSqidsBlocklist(blocklist []string) func(*SqidsOptions) {
+	return func(options *SqidsOptions) {
+		getSqidsOptionsOrSetDefault(options).blocklist = blocklist
+	}
+}
+
+// Sqids 按 Sqids 算法（https://sqids.org）将一个或多个 uint64 编码为较短的 URL 安全字符串，
+// 隐藏 ID 的递增规律，且可以还原，不需要额外存储.
+type Sqids struct {
+	alphabet  []byte
+	minLength int
+	blocklist []string
+}
+
+// NewSqids 根据提供的选项函数创建一个新的 Sqids 实例.
+func NewSqids(options ...func(*SqidsOptions)) (*Sqids, error) {
+	ops := getSqidsOptionsOrSetDefault(nil)
+	for _, f := range options {
+		f(ops)
+	}
+
+	alphabet := []byte(ops.alphabet)
+	if len(alphabet) < 3 {
+		return nil, errors.New("sqids alphabet must contain at least 3 characters")
+	}
+	seen := make(map[byte]bool, len(alphabet))
+	for _, c := range alphabet {
+		if c > unicode.MaxASCII {
+			return nil, errors.New("sqids alphabet must contain only ASCII characters")
+		}
+		if seen[c] {
+			return nil, fmt.Errorf("duplicate sqids alphabet char %q", c)
+		}
+		seen[c] = true
+	}
+	if ops.minLength < 0 || ops.minLength > 255 {
+		return nil, fmt.Errorf("sqids min length %d must be between 0 and 255", ops.minLength)
+	}
+
+	// 只保留由字符集中的字符组成、长度至少为 3 的单词，比较时忽略大小写
+	lower := strings.ToLower(ops.alphabet)
+	var blocklist []string
+	for _, word := range ops.blocklist {
+		word = strings.ToLower(word)
+		if len(word) >= 3 && strings.IndexFunc(word, func(r rune) bool { return !strings.ContainsRune(lower, r) }) < 0 {
+			blocklist = append(blocklist, word)
+		}
+	}
+
+	return &Sqids{alphabet: sqidsShuffle(alphabet), minLength: ops.minLength, blocklist: blocklist}, nil
+}
+
+// Encode 将一个或多个数编码为字符串，没有传入数时返回空字符串.
+func (s *Sqids) Encode(numbers ...uint64) (string, error) {
+	if len(numbers) == 0 {
+		return "", nil
+	}
+	return s.encode(numbers, 0)
+}
+
+// encode 使用偏移量 increment 编码，生成的 ID 被屏蔽时增加偏移量重新编码
+func (s *Sqids) encode(numbers []uint64, increment int) (string, error) {
+	size := len(s.alphabet)
+	if increment > size {
+		return "", errors.New("sqids reached max attempts to generate an unblocked id")
+	}
+
+	offset := len(numbers)
+	for i, v := range numbers {
+		offset += int(s.alphabet[v%uint64(size)]) + i
+	}
+	offset = (offset + increment) % size
+
+	alphabet := append(append([]byte(nil), s.alphabet[offset:]...), s.alphabet[:offset]...)
+	prefix := alphabet[0]
+	reverse(alphabet)
+
+	id := []byte{prefix}
+	for i, v := range numbers {
+		id = append(id, sqidsToID(v, alphabet[1:])...)
+		if i < len(numbers)-1 {
+			// 第一个字符作为分隔符，之后打乱字符集
+			id = append(id, alphabet[0])
+			alphabet = sqidsShuffle(alphabet)
+		}
+	}
+
+	if len(id) < s.minLength {
+		id = append(id, alphabet[0])
+		for len(id) < s.minLength {
+			alphabet = sqidsShuffle(alphabet)
+			id = append(id, alphabet[:min(s.minLength-len(id), size)]...)
+		}
+	}
+
+	if s.blocked(string(id)) {
+		return s.encode(numbers, increment+1)
+	}
+	return string(id), nil
+}
+
+// Decode 将字符串还原为数，字符串不是由相同选项编码时返回 ErrInvalidCode.
+// 同一组数只有一个合法的编码，避免同一个资源对应多个 URL.
+func (s *Sqids) Decode(id string) ([]uint64, error) {
+	if id == "" {
+		return nil, ErrInvalidCode
+	}
+	offset := strings.IndexByte(string(s.alphabet), id[0])
+	if offset < 0 {
+		return nil, ErrInvalidCode
+	}
+	alphabet := append(append([]byte(nil), s.alphabet[offset:]...), s.alphabet[:offset]...)
+	reverse(alphabet)
+
+	var numbers []uint64
+	for rest := id[1:]; rest != ""; {
+		chunk, tail, found := strings.Cut(rest, string(alphabet[0]))
+		// 补齐最小长度时以分隔符开头的部分不包含数
+		if chunk == "" {
+			break
+		}
+		v, ok := sqidsToNumber(chunk, alphabet[1:])
+		if !ok {
+			return nil, ErrInvalidCode
+		}
+		numbers = append(numbers, v)
+		if found {
+			alphabet = sqidsShuffle(alphabet)
+		}
+		rest = tail
+	}
+
+	if len(numbers) == 0 {
+		return nil, ErrInvalidCode
+	}
+	if canonical, err := s.encode(numbers, 0); err != nil || canonical != id {
+		return nil, ErrInvalidCode
+	}
+	return numbers, nil
+}
+
+// blocked 判断 ID 是否包含屏蔽的单词：短 ID 需要完全相同，包含数字的单词只匹配开头或结尾
+func (s *Sqids) blocked(id string) bool {
+	id = strings.ToLower(id)
+	for _, word := range s.blocklist {
+		switch {
+		case len(word) > len(id):
+		case len(id) <= 3 || len(word) <= 3:
+			if id == word {
+				return true
+			}
+		case strings.ContainsAny(word, "0123456789"):
+			if strings.HasPrefix(id, word) || strings.HasSuffix(id, word) {
+				return true
+			}
+		case strings.Contains(id, word):
+			return true
+		}
+	}
+	return false
+}
+
+// sqidsShuffle 按 Sqids 算法确定性地打乱字符集，返回新的切片
+func sqidsShuffle(alphabet []byte) []byte {
+	chars := append([]byte(nil), alphabet...)
+	for i, j := 0, len(chars)-1; j > 0; i, j = i+1, j-1 {
+		r := (i*j + int(chars[i]) + int(chars[j])) % len(chars)
+		chars[i], chars[r] = chars[r], chars[i]
+	}
+	return chars
+}
+
+// sqidsToID 将数按字符集进制编码
+func sqidsToID(v uint64, alphabet []byte) []byte {
+	var id []byte
+	size := uint64(len(alphabet))
+	for {
+		id = append(id, alphabet[v%size])
+		v /= size
+		if v == 0 {
+			break
+		}
+	}
+	reverse(id)
+	return id
+}
+
+// sqidsToNumber 将字符串按字符集进制还原为数，字符不在字符集中或溢出时返回 false
+func sqidsToNumber(id string, alphabet []byte) (uint64, bool) {
+	size := uint64(len(alphabet))
+	var v uint64
+	for i := 0; i < len(id); i++ {
+		d := strings.IndexByte(string(alphabet), id[i])
+		if d < 0 || v > (math.MaxUint64-uint64(d))/size {
+			return 0, false
+		}
+		v = v*size + uint64(d)
+	}
+	return v, true
+}
+
+// reverse 原地反转
+func reverse(b []byte) {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
@@ -0,0 +1,171 @@
+package id
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func mustNewSqids(t *testing.T, options ...func(*SqidsOptions)) *Sqids {
+	t.Helper()
+	s, err := NewSqids(options...)
+	if err != nil {
+		t.Fatalf("NewSqids() error = %v", err)
+	}
+	return s
+}
+
+func TestSqidsSpecVectors(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []func(*SqidsOptions)
+		numbers []uint64
+		want    string
+	}{
+		{"default", nil, []uint64{1, 2, 3}, "86Rf07"},
+		{"min length", []func(*SqidsOptions){WithSqidsMinLength(10)}, []uint64{1, 2, 3}, "86Rf07xd4z"},
+		{"custom alphabet", []func(*SqidsOptions){WithSqidsAlphabet("0123456789abcdef")}, []uint64{1, 2, 3}, "489158"},
+		{"short alphabet", []func(*SqidsOptions){WithSqidsAlphabet("abc")}, []uint64{1, 2, 3}, "aacacbaa"},
+		{"no blocklist", []func(*SqidsOptions){WithSqidsBlocklist(nil)}, []uint64{4572721}, "aho1e"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := mustNewSqids(t, tt.options...)
+			got, err := s.Encode(tt.numbers...)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("Encode(%v) = %q, want %q", tt.numbers, got, tt.want)
+			}
+			numbers, err := s.Decode(got)
+			if err != nil || !reflect.DeepEqual(numbers, tt.numbers) {
+				t.Fatalf("Decode(%q) = %v, %v, want %v", got, numbers, err, tt.numbers)
+			}
+		})
+	}
+}
+
+func TestSqidsMinLength(t *testing.T) {
+	for _, minLength := range []int{0, 1, 5, 10, 62, 100, 255} {
+		s := mustNewSqids(t, WithSqidsMinLength(minLength))
+		for _, numbers := range [][]uint64{{0}, {1, 2, 3}, {math.MaxUint64}, {100, 200, 300, 400}} {
+			id, err := s.Encode(numbers...)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if len(id) < minLength {
+				t.Fatalf("Encode(%v) with min length %d = %q, too short", numbers, minLength, id)
+			}
+			if got, err := s.Decode(id); err != nil || !reflect.DeepEqual(got, numbers) {
+				t.Fatalf("Decode(%q) = %v, %v, want %v", id, got, err, numbers)
+			}
+		}
+	}
+}
+
+func TestSqidsBlocklist(t *testing.T) {
+	// 生成的 ID 被屏蔽时增加偏移量重新编码
+	s := mustNewSqids(t, WithSqidsBlocklist([]string{"aho1e"}))
+	id, err := s.Encode(4572721)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if id != "JExTR" {
+		t.Fatalf("Encode() = %q, want JExTR", id)
+	}
+	if got, err := s.Decode(id); err != nil || !reflect.DeepEqual(got, []uint64{4572721}) {
+		t.Fatalf("Decode(%q) = %v, %v", id, got, err)
+	}
+	// 被屏蔽的编码不是合法的编码
+	if got, err := s.Decode("aho1e"); !errors.Is(err, ErrInvalidCode) || len(got) != 0 {
+		t.Fatalf("Decode(aho1e) = %v, %v, want %v", got, err, ErrInvalidCode)
+	}
+
+	// 只使用传入的屏蔽单词，比较时忽略大小写
+	s = mustNewSqids(t, WithSqidsBlocklist([]string{"arUo"}))
+	if id, _ := s.Encode(4572721); id != "aho1e" {
+		t.Fatalf("Encode() = %q, want aho1e", id)
+	}
+	if id, _ := s.Encode(100000); id != "QyG4" {
+		t.Fatalf("Encode() = %q, want QyG4", id)
+	}
+
+	// 所有偏移量都被屏蔽时返回错误
+	s = mustNewSqids(t, WithSqidsAlphabet("abc"), WithSqidsMinLength(3), WithSqidsBlocklist([]string{"cab", "abc", "bca"}))
+	if _, err := s.Encode(0); err == nil {
+		t.Fatal("Encode() error = nil, want max attempts error")
+	}
+}
+
+func TestSqidsMaxUint64(t *testing.T) {
+	s := mustNewSqids(t)
+	numbers := []uint64{math.MaxUint64, 0, math.MaxUint64}
+	id, err := s.Encode(numbers...)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if got, err := s.Decode(id); err != nil || !reflect.DeepEqual(got, numbers) {
+		t.Fatalf("Decode(%q) = %v, %v, want %v", id, got, err, numbers)
+	}
+}
+
+func TestSqidsDecodeInvalid(t *testing.T) {
+	s := mustNewSqids(t)
+	id, err := s.Encode(1, 2, 3)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		id   string
+	}{
+		{"empty", ""},
+		{"foreign prefix", "*" + id[1:]},
+		{"foreign char", id[:3] + "*" + id[4:]},
+		{"non-ascii", id + "é"},
+		{"padded", id + id[len(id)-1:]},
+		{"overflow", strings.Repeat("z", 20)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := s.Decode(tt.id); !errors.Is(err, ErrInvalidCode) || len(got) != 0 {
+				t.Fatalf("Decode(%q) = %v, %v, want %v", tt.id, got, err, ErrInvalidCode)
+			}
+		})
+	}
+
+	// 只有一个合法的编码：其它编码即使能还原出数也会被拒绝
+	minLength := mustNewSqids(t, WithSqidsMinLength(10))
+	if got, err := minLength.Decode(id); !errors.Is(err, ErrInvalidCode) || len(got) != 0 {
+		t.Fatalf("Decode(%q) without padding = %v, %v, want %v", id, got, err, ErrInvalidCode)
+	}
+	if got, err := s.Decode("86Rf07xd4z"); !errors.Is(err, ErrInvalidCode) || len(got) != 0 {
+		t.Fatalf("Decode(86Rf07xd4z) with padding = %v, %v, want %v", got, err, ErrInvalidCode)
+	}
+}
+
+func TestNewSqidsInvalidOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []func(*SqidsOptions)
+		wantErr string
+	}{
+		{"short alphabet", []func(*SqidsOptions){WithSqidsAlphabet("ab")}, "at least 3 characters"},
+		{"non-ascii alphabet", []func(*SqidsOptions){WithSqidsAlphabet("abcé")}, "ASCII"},
+		{"duplicate char", []func(*SqidsOptions){WithSqidsAlphabet("abca")}, "duplicate"},
+		{"negative min length", []func(*SqidsOptions){WithSqidsMinLength(-1)}, "min length"},
+		{"min length too large", []func(*SqidsOptions){WithSqidsMinLength(256)}, "min length"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSqids(tt.options...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("NewSqids() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package id
+
+import (
+	"context"
+	"encoding/binary"
+)
+
+// crockford Crockford Base32 字符集
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULID 生成 26 个字符的 ULID：48 位毫秒时间戳与 80 位随机数，按字典序排序即按时间排序.
+// 同一毫秒内在上一个 ULID 的随机数上加一，保证单调递增.
+type ULID struct {
+	ops GeneratorOptions
+	m   *monotonic
+}
+
+// NewULID 根据提供的选项函数创建一个新的 ULID 实例.
+func NewULID(options ...func(*GeneratorOptions)) *ULID {
+	ops := getGeneratorOptionsOrSetDefault(nil)
+	for _, f := range options {
+		f(ops)
+	}
+	return &ULID{ops: *ops, m: newMonotonic(ops.entropy, 80)}
+}
+
+// NextString 生成 ULID.
+func (g *ULID) NextString(ctx context.Context) (string, error) {
+	ms, random, err := g.m.next(g.ops.clock().UnixMilli())
+	if err != nil {
+		return "", err
+	}
+
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(ms)<<16)
+	copy(b[6:], random)
+	return encodeCrockford(b), nil
+}
+
+// encodeCrockford 将 128 位按 Crockford Base32 编码为 26 个字符，最高位补两个 0
+func encodeCrockford(b [16]byte) string {
+	hi, lo := binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])
+	out := make([]byte, 26)
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out)
+}
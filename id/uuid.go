@@ -0,0 +1,69 @@
+package id
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+)
+
+// UUID 128 位的 UUID.
+type UUID [16]byte
+
+// String 返回 xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx 格式的字符串.
+func (u UUID) String() string {
+	buf := make([]byte, 36)
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf)
+}
+
+// UUIDv7 按 RFC 9562 生成版本 7 的 UUID：48 位毫秒时间戳与 74 位随机数，适合作为数据库主键.
+// 同一毫秒内在上一个 UUID 的随机数上加一，保证单调递增.
+type UUIDv7 struct {
+	ops GeneratorOptions
+	m   *monotonic
+}
+
+// NewUUIDv7 根据提供的选项函数创建一个新的 UUIDv7 实例.
+func NewUUIDv7(options ...func(*GeneratorOptions)) *UUIDv7 {
+	ops := getGeneratorOptionsOrSetDefault(nil)
+	for _, f := range options {
+		f(ops)
+	}
+	return &UUIDv7{ops: *ops, m: newMonotonic(ops.entropy, 74)}
+}
+
+// Next 生成 UUID.
+func (g *UUIDv7) Next(ctx context.Context) (UUID, error) {
+	ms, random, err := g.m.next(g.ops.clock().UnixMilli())
+	if err != nil {
+		return UUID{}, err
+	}
+
+	// 74 位随机数的高 12 位为 rand_a，低 62 位为 rand_b
+	hi := uint64(binary.BigEndian.Uint16(random[:2]))
+	lo := binary.BigEndian.Uint64(random[2:])
+	randA := hi<<2 | lo>>62
+	randB := lo & (1<<62 - 1)
+
+	var u UUID
+	binary.BigEndian.PutUint64(u[0:8], uint64(ms)<<16|0x7000|randA)
+	binary.BigEndian.PutUint64(u[8:], 0x8000000000000000|randB)
+	return u, nil
+}
+
+// NextString 生成 UUID 字符串.
+func (g *UUIDv7) NextString(ctx context.Context) (string, error) {
+	u, err := g.Next(ctx)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
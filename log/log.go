@@ -114,6 +114,8 @@ func NewLogger(opts *Options) *zapLogger {
 		// 是否禁止在 panic 及以上级别打印堆栈信息
 		DisableStacktrace: opts.DisableStacktrace,
 		// 指定日志级别
+		Level: zap.NewAtomicLevelAt(zapLevel),
+		// 指定日志格式
 		Encoding:      opts.Format,
 		EncoderConfig: encoderCfg,
 		// 指定日志输出位置
@@ -0,0 +1,54 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewLoggerLevel(t *testing.T) {
+	tests := []struct {
+		level string
+		want  []string // 应当输出的消息
+		skip  []string // 应当被过滤的消息
+	}{
+		{"debug", []string{"debug", "info", "warn"}, nil},
+		{"warn", []string{"warn"}, []string{"debug", "info"}},
+		// 非法的日志级别使用 info
+		{"unknown", []string{"info", "warn"}, []string{"debug"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "log.json")
+			l := NewLogger(&Options{Level: tt.level, Format: "json", OutputPaths: []string{path}})
+			l.Debugf("debug")
+			l.Infof("info")
+			l.Warnf("warn")
+			l.Sync()
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("read log: %v", err)
+			}
+			out := string(data)
+			for _, msg := range tt.want {
+				if !strings.Contains(out, `"message":"`+msg+`"`) {
+					t.Errorf("log does not contain %q:\n%s", msg, out)
+				}
+			}
+			for _, msg := range tt.skip {
+				if strings.Contains(out, `"message":"`+msg+`"`) {
+					t.Errorf("log contains %q below level %s:\n%s", msg, tt.level, out)
+				}
+			}
+		})
+	}
+}
+
+func TestNewLoggerDefault(t *testing.T) {
+	// zap 要求配置日志级别，没有配置时 NewLogger 会 panic
+	if l := NewLogger(nil); l == nil || l.Options().Level != "info" {
+		t.Fatalf("NewLogger(nil) = %v, want a logger at info level", l)
+	}
+}